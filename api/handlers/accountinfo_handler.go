@@ -1,22 +1,27 @@
 package handlers
 
 import (
+	"math/big"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/common"
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
 )
 
 const (
-	shardCount        = 20
+	shardCount        = common.ShardCount
 	maxShowAccountNum = 10000
 	txCount           = 25
 	//exclude divide zero problem
 	remianTotalBalance = 1
+
+	maxBalanceHistoryItems = 1000
+	balanceHistoryPeriod   = 30 * 24 * 3600
 )
 
 //AccountTbl represents an account list ordered by account balance
@@ -25,7 +30,7 @@ type AccountTbl struct {
 	DBClient     BlockInfoDB
 	accountTbl   []*database.DBAccount
 	accMutex     sync.RWMutex
-	totalBalance int64
+	totalBalance *big.Int
 }
 
 //ProcessGAccountTable process global account table
@@ -106,7 +111,7 @@ func (h *AccountHandler) updateImpl() {
 		h.accTbls[i-1].ProcessGAccountTable()
 	}
 
-	totalBalances, err := h.DBClient.GetTotalBalance()
+	totalBalances, err := shardBalances(h.DBClient)
 	if err != nil {
 		log.Error("[DB] err : %v", err)
 	}
@@ -115,7 +120,7 @@ func (h *AccountHandler) updateImpl() {
 		if v, exist := totalBalances[i+1]; exist != false {
 			h.accTbls[i].totalBalance = v
 		} else {
-			h.accTbls[i].totalBalance = remianTotalBalance
+			h.accTbls[i].totalBalance = big.NewInt(remianTotalBalance)
 		}
 	}
 }
@@ -138,7 +143,6 @@ func (h *AccountHandler) GetAccounts() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		p, _ := strconv.ParseUint(c.Query("p"), 10, 64)
 		ps, _ := strconv.ParseUint(c.Query("ps"), 10, 64)
-		s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
 		if ps == 0 {
 			ps = blockItemNumsPrePage
 		} else if ps > maxItemNumsPrePage {
@@ -149,13 +153,20 @@ func (h *AccountHandler) GetAccounts() gin.HandlerFunc {
 			p--
 		}
 
-		if s <= 0 {
-			s = 1
+		shardNumber, err := getShardNumber(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
 		}
-		shardNumber := int(s)
 
-		if shardNumber < 1 || shardNumber > 20 {
-			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+		cursor, paged, err := getPageCursor(c, accountsList)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		if paged {
+			h.getAccountsByCursor(c, shardNumber, cursor, ps)
 			return
 		}
 
@@ -182,9 +193,24 @@ func (h *AccountHandler) GetAccounts() gin.HandlerFunc {
 	}
 }
 
+//getRecentAddressTxs get the txs of the address still in the tx pool and its latest txCount mined txs
+func getRecentAddressTxs(db BlockInfoDB, address string) (pending, mined []*database.DBTx, err error) {
+	pendingEntries, err := db.GetAddressTxs(&database.AddressTxFilter{Address: address, Pending: true}, 0, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	minedEntries, err := db.GetAddressTxs(&database.AddressTxFilter{Address: address}, 0, txCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	return addressTxs(pendingEntries), addressTxs(minedEntries), nil
+}
+
 //GetAccountByAddressImpl use account info, account tx list and account pending tx list to assembly account information
-func (h *AccountHandler) GetAccountByAddressImpl(address string) *RetDetailAccountInfo {
+func (h *AccountHandler) GetAccountByAddressImpl(addr common.Address) *RetDetailAccountInfo {
 	dbClinet := h.DBClient
+	address := addr.Hex()
 
 	data, err := dbClinet.GetAccountByAddress(address)
 	if err != nil {
@@ -195,20 +221,20 @@ func (h *AccountHandler) GetAccountByAddressImpl(address string) *RetDetailAccou
 		return nil
 	}
 
-	txs, err := dbClinet.GetTxsByAddresss(address, txCount)
-	if err != nil {
-		return nil
-	}
-
-	pengdingTxs, err := dbClinet.GetPendingTxsByAddress(address)
+	pengdingTxs, txs, err := getRecentAddressTxs(dbClinet, address)
 	if err != nil {
 		return nil
 	}
 
 	txs = append(pengdingTxs, txs...)
 
-	var ttBalance int64
-	if data.ShardNumber >= 1 && data.ShardNumber <= shardCount {
+	//the shard encoded in the address is authoritative, the stored one is used for the reserved addresses
+	if shardNumber := addr.Shard(); common.ValidShard(shardNumber) {
+		data.ShardNumber = shardNumber
+	}
+
+	var ttBalance *big.Int
+	if common.ValidShard(data.ShardNumber) {
 		ttBalance = h.accTbls[data.ShardNumber-1].totalBalance
 	}
 
@@ -219,13 +245,13 @@ func (h *AccountHandler) GetAccountByAddressImpl(address string) *RetDetailAccou
 //GetAccountByAddress get account detail info by address
 func (h *AccountHandler) GetAccountByAddress() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		address := c.Query("address")
-		// if len(address) != addressLength {
-		// 	responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
-		// 	return
-		// }
+		addr, err := getAddress(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
 
-		detailAccount := h.GetAccountByAddressImpl(address)
+		detailAccount := h.GetAccountByAddressImpl(addr)
 
 		c.JSON(http.StatusOK, gin.H{
 			"code":    apiOk,
@@ -235,3 +261,102 @@ func (h *AccountHandler) GetAccountByAddress() gin.HandlerFunc {
 
 	}
 }
+
+//GetAccountBalance get the balance of the account at a block height or a unix timestamp
+func (h *AccountHandler) GetAccountBalance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addr, err := getAddress(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		address := addr.Hex()
+
+		var change *database.DBBalanceChange
+		if c.Query("height") != "" {
+			height, perr := strconv.ParseUint(c.Query("height"), 10, 64)
+			if perr != nil {
+				responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+				return
+			}
+			change, err = h.DBClient.GetBalanceAtHeight(address, height)
+		} else if c.Query("time") != "" {
+			timestamp, perr := strconv.ParseInt(c.Query("time"), 10, 64)
+			if perr != nil {
+				responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+				return
+			}
+			change, err = h.DBClient.GetBalanceAtTime(address, timestamp)
+		} else {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		if err != nil {
+			responseError(c, errGetBalanceFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		//the account had not been touched by then
+		balance := big.NewInt(0)
+		var lastChange *RetBalanceChange
+		if change != nil {
+			lastChange = createRetBalanceChange(change)
+			balance = lastChange.Balance
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data": gin.H{
+				"address":    address,
+				"balance":    balance,
+				"lastChange": lastChange,
+			},
+		})
+	}
+}
+
+//GetBalanceHistory get the balance changes of the account in a time period for charting
+func (h *AccountHandler) GetBalanceHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addr, err := getAddress(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		address := addr.Hex()
+
+		end, _ := strconv.ParseInt(c.Query("end"), 10, 64)
+		if end <= 0 {
+			end = time.Now().Unix()
+		}
+
+		begin, _ := strconv.ParseInt(c.Query("begin"), 10, 64)
+		if begin <= 0 {
+			begin = end - balanceHistoryPeriod
+		}
+
+		if begin > end {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		dbChanges, err := h.DBClient.GetBalanceHistory(address, begin, end, maxBalanceHistoryItems)
+		if err != nil {
+			responseError(c, errGetBalanceFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		var changes []*RetBalanceChange
+		for i := 0; i < len(dbChanges); i++ {
+			changes = append(changes, createRetBalanceChange(dbChanges[i]))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    changes,
+		})
+	}
+}
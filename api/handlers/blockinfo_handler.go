@@ -14,6 +14,8 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/common"
+	"github.com/seeleteam/scan-api/database"
 )
 
 const (
@@ -26,6 +28,11 @@ const (
 	accTypeStr      = "account"
 	contractTypeStr = "contract"
 
+	txStatusPending = "pending"
+	txStatusSuccess = "success"
+	txStatusFailed  = "failed"
+	txStatusDropped = "dropped"
+
 	apiOk            = 0
 	apiParmaInvalid  = 1
 	apiInternalError = 2
@@ -33,8 +40,9 @@ const (
 
 	avgCountBlockNum = 5000
 	txHashLength     = 66
+	addressLength    = 2 + 2*common.AddressLen
 
-	maxAccountTxCnt = 1000000
+	reorgItemNums = 20
 )
 
 var (
@@ -55,6 +63,8 @@ var (
 	errGetTopMinerChartError            = errors.New("could not get top miner chart from db")
 	errGetNodeCountFromDB               = errors.New("could not get node count from db")
 	errGetNodeInfoFromDB                = errors.New("could not get node data from db")
+	errGetReorgFromDB                   = errors.New("could not get reorg data from db")
+	errGetBalanceFromDB                 = errors.New("could not get balance history from db")
 )
 
 func responseError(c *gin.Context, err error, httpCode, code int) {
@@ -255,7 +265,18 @@ func (h *BlockHandler) GetBlocks() gin.HandlerFunc {
 		}
 
 		shardNumber := int(s)
-		curBlockHeight, err := dbClinet.GetBlockHeight(shardNumber)
+		cursor, paged, err := getPageCursor(c, blocksList)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		if paged {
+			h.getBlocksByCursor(c, shardNumber, cursor, ps)
+			return
+		}
+
+		curBlockHeight, err := blockCnt(dbClinet, shardNumber)
 		if err != nil {
 			responseError(c, errGetBlockHeightFromDB, http.StatusInternalServerError, apiDBQueryError)
 			return
@@ -309,7 +330,7 @@ func (h *BlockHandler) GetBlockDetailByHash(c *gin.Context, hash string) {
 		return
 	}
 
-	maxHeight, _ := dbClinet.GetBlockHeight(data.ShardNumber)
+	maxHeight, _ := blockCnt(dbClinet, data.ShardNumber)
 
 	detailBlock := createRetDetailBlockInfo(data, maxHeight, 0)
 
@@ -330,7 +351,7 @@ func (h *BlockHandler) GetBlockDetailByHeight(c *gin.Context, height uint64, sha
 		return
 	}
 
-	maxHeight, _ := dbClinet.GetBlockHeight(shaderNumber)
+	maxHeight, _ := blockCnt(dbClinet, shaderNumber)
 	detailBlock := createRetDetailBlockInfo(data, maxHeight, 0)
 	c.JSON(http.StatusOK, gin.H{
 		"code":    apiOk,
@@ -344,14 +365,14 @@ func (h *BlockHandler) GetTxCnt() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		dbClinet := h.DBClient
 
-		transCnt, err := dbClinet.GetTxCnt()
+		stats, err := totalStats(dbClinet)
 		if err != nil {
 			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"code":    apiOk,
 				"message": "",
-				"data":    transCnt,
+				"data":    stats.Txs,
 			})
 		}
 	}
@@ -362,14 +383,14 @@ func (h *BlockHandler) GetBlockCnt() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		dbClinet := h.DBClient
 
-		transCnt, err := dbClinet.GetBlockCnt()
+		stats, err := totalStats(dbClinet)
 		if err != nil {
 			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"code":    apiOk,
 				"message": "",
-				"data":    transCnt,
+				"data":    stats.Blocks,
 			})
 		}
 	}
@@ -380,14 +401,14 @@ func (h *BlockHandler) GetAccountCnt() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		dbClinet := h.DBClient
 
-		accountCnt, err := dbClinet.GetAccountCnt()
+		stats, err := totalStats(dbClinet)
 		if err != nil {
 			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"code":    apiOk,
 				"message": "",
-				"data":    accountCnt,
+				"data":    stats.Accounts,
 			})
 		}
 	}
@@ -398,19 +419,58 @@ func (h *BlockHandler) GetContractCnt() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		dbClinet := h.DBClient
 
-		contractCnt, err := dbClinet.GetContractCnt()
+		stats, err := totalStats(dbClinet)
 		if err != nil {
 			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"code":    apiOk,
 				"message": "",
-				"data":    contractCnt,
+				"data":    stats.Contracts,
 			})
 		}
 	}
 }
 
+//GetReorgs get the latest chain reorganizations of the shard
+func (h *BlockHandler) GetReorgs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dbClinet := h.DBClient
+
+		s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
+		if s <= 0 {
+			s = 1
+		}
+		shardNumber := int(s)
+
+		dbReorgs, err := dbClinet.GetReorgs(shardNumber, reorgItemNums)
+		if err != nil {
+			responseError(c, errGetReorgFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		var reorgs []*RetReorgInfo
+		for i := 0; i < len(dbReorgs); i++ {
+			reorgs = append(reorgs, createRetReorgInfo(dbReorgs[i]))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    reorgs,
+		})
+	}
+}
+
+//getReceipt get the receipt of the transaction, it returns nil if the receipt is not stored
+func (h *BlockHandler) getReceipt(txHash string) *database.DBReceipt {
+	receipt, err := h.DBClient.GetReceiptByTxHash(txHash)
+	if err != nil {
+		return nil
+	}
+	return receipt
+}
+
 //GetTxByHash handler for get transaction by hash
 func (h *BlockHandler) GetTxByHash() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -423,7 +483,7 @@ func (h *BlockHandler) GetTxByHash() gin.HandlerFunc {
 		}
 		data, err := dbClinet.GetTxByHash(transHash)
 		if err == nil {
-			detailTx := createRetDetailTxInfo(data)
+			detailTx := createRetDetailTxInfo(data, h.getReceipt(transHash))
 
 			c.JSON(http.StatusOK, gin.H{
 				"code":    apiOk,
@@ -468,11 +528,12 @@ func (h *BlockHandler) getTxsByBeginAndEnd(shardNumber int, begin, end uint64) [
 	return txs
 }
 
-func (h *BlockHandler) getPendingTxsByBeginAndEnd(shardNumber int, begin, end uint64) []*RetSimpleTxInfo {
+//getPendingTxsByBeginAndEnd get the pool txs in the range, begin and end count from the earliest seen tx
+func (h *BlockHandler) getPendingTxsByBeginAndEnd(shardNumber int, total, begin, end uint64) []*RetSimpleTxInfo {
 	dbClinet := h.DBClient
 
 	var txs []*RetSimpleTxInfo
-	dbTrans, err := dbClinet.GetPendingTxsByIdx(shardNumber, begin, end)
+	dbTrans, err := dbClinet.GetPendingTxs(shardNumber, int(total-end), int(end-begin))
 	if err != nil {
 		return nil
 	}
@@ -528,7 +589,7 @@ func (h *BlockHandler) GetTxsInBlock(c *gin.Context, shardNumber int, height, p,
 			Age:    age,
 			From:   data.From,
 			To:     data.To,
-			Value:  data.Amount,
+			Value:  data.Amount.Big(),
 		}
 		retTxs = append(retTxs, simpleTransaction)
 	}
@@ -548,59 +609,59 @@ func (h *BlockHandler) GetTxsInBlock(c *gin.Context, shardNumber int, height, p,
 	})
 }
 
-//GetTxsInAccount get tx list from this account
-func (h *BlockHandler) GetTxsInAccount(c *gin.Context, address string, p, ps uint64) {
+//GetTxsInAccount get tx list from the tx history of the address selected by the filter,
+//the txs still in the tx pool come first
+func (h *BlockHandler) GetTxsInAccount(c *gin.Context, filter *database.AddressTxFilter, p, ps uint64) {
 	dbClinet := h.DBClient
 
-	txs, err := dbClinet.GetTxsByAddresss(address, maxAccountTxCnt)
+	pendingFilter := *filter
+	pendingFilter.Pending = true
+	pengdingTxs, err := dbClinet.GetAddressTxs(&pendingFilter, 0, 0)
 	if err != nil {
 		responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
 		return
 	}
 
-	pengdingTxs, err := dbClinet.GetPendingTxsByAddress(address)
+	txCnt, err := dbClinet.GetAddressTxCount(filter.Address)
 	if err != nil {
-		responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
+		responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 		return
 	}
 
-	txs = append(pengdingTxs, txs...)
-
-	txCntInAccount := len(txs)
-	page, begin, end := getBeginAndEndByPage(uint64(txCntInAccount), p, ps)
-	txs = txs[begin:end]
+	pendingCnt := uint64(len(pengdingTxs))
+	txCntInAccount := pendingCnt + uint64(txCnt.Of(filter.Direction))
+	page, begin, end := getBeginAndEndByPage(txCntInAccount, p, ps)
+	if end > txCntInAccount {
+		end = txCntInAccount
+	}
 
-	var retTxs []*RetDetailAccountTxInfo
-	for i := 0; i < len(txs); i++ {
-		data := txs[i]
+	//the page may cover the pending txs, the mined txs after them, or both
+	var txs []*database.DBAddressTx
+	if begin < pendingCnt {
+		pendingEnd := end
+		if pendingEnd > pendingCnt {
+			pendingEnd = pendingCnt
+		}
+		txs = append(txs, pengdingTxs[begin:pendingEnd]...)
+	}
 
-		timeStamp := big.NewInt(0)
-		var age string
-		if timeStamp.UnmarshalText([]byte(data.Timestamp)) == nil {
-			age = getElpasedTimeDesc(timeStamp.Div(timeStamp, big.NewInt(1e9)))
+	if end > pendingCnt {
+		skip := uint64(0)
+		if begin > pendingCnt {
+			skip = begin - pendingCnt
 		}
 
-		var inOrOut bool
-		if data.To == address {
-			inOrOut = true
-		} else {
-			inOrOut = false
-		}
-
-		simpleTransaction := &RetDetailAccountTxInfo{
-			ShardNumber: data.ShardNumber,
-			TxType:      data.TxType,
-			Hash:        data.Hash,
-			Block:       data.Block,
-			From:        data.From,
-			To:          data.To,
-			Value:       data.Amount,
-			Age:         age,
-			Fee:         data.Fee,
-			InOrOut:     inOrOut,
-			Pending:     data.Pending,
+		minedTxs, err := dbClinet.GetAddressTxs(filter, int(skip), int(end-pendingCnt-skip))
+		if err != nil {
+			responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
 		}
-		retTxs = append(retTxs, simpleTransaction)
+		txs = append(txs, minedTxs...)
+	}
+
+	var retTxs []*RetDetailAccountTxInfo
+	for i := 0; i < len(txs); i++ {
+		retTxs = append(retTxs, createRetAddressTxInfo(txs[i]))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -625,7 +686,6 @@ func (h *BlockHandler) GetTxs() gin.HandlerFunc {
 
 		p, _ := strconv.ParseUint(c.Query("p"), 10, 64)
 		ps, _ := strconv.ParseUint(c.Query("ps"), 10, 64)
-		s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
 		if ps == 0 {
 			ps = transItemNumsPrePage
 		} else if ps > maxItemNumsPrePage {
@@ -636,29 +696,59 @@ func (h *BlockHandler) GetTxs() gin.HandlerFunc {
 			p--
 		}
 
-		if s <= 0 {
-			s = 1
+		shardNumber, err := getShardNumber(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
 		}
-		shardNumber := int(s)
 
+		list := txsList
 		block, flag := c.GetQuery("block")
+		_, byAddress := c.GetQuery("address")
+		if flag {
+			list = blockTxsList
+		} else if byAddress {
+			list = addressTxsList
+		}
+
+		cursor, paged, err := getPageCursor(c, list)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
 		if flag {
 			height, err := strconv.ParseUint(block, 10, 64)
 			if err != nil {
 				responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			} else if paged {
+				h.getTxsInBlockByCursor(c, shardNumber, height, cursor, ps)
 			} else {
 				h.GetTxsInBlock(c, shardNumber, height, p, ps)
-				return
 			}
+			return
 		}
 
-		address, flag := c.GetQuery("address")
-		if flag {
-			h.GetTxsInAccount(c, address, p, ps)
+		if byAddress {
+			//the address is validated by getShardNumber
+			addr, _ := getAddress(c)
+			filter, err := getAddressTxFilter(c, addr.Hex())
+			if err != nil {
+				responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			} else if paged {
+				h.getTxsInAccountByCursor(c, filter, cursor, ps)
+			} else {
+				h.GetTxsInAccount(c, filter, p, ps)
+			}
+			return
+		}
+
+		if paged {
+			h.getTxsByCursor(c, shardNumber, cursor, ps)
 			return
 		}
 
-		txCnt, err := dbClinet.GetTxCntByShardNumber(shardNumber)
+		txCnt, err := txCnt(dbClinet, shardNumber)
 		if err != nil {
 			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 			return
@@ -690,7 +780,6 @@ func (h *BlockHandler) GetPendingTxs() gin.HandlerFunc {
 
 		p, _ := strconv.ParseUint(c.Query("p"), 10, 64)
 		ps, _ := strconv.ParseUint(c.Query("ps"), 10, 64)
-		s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
 		if ps == 0 {
 			ps = transItemNumsPrePage
 		} else if ps > maxItemNumsPrePage {
@@ -701,10 +790,11 @@ func (h *BlockHandler) GetPendingTxs() gin.HandlerFunc {
 			p--
 		}
 
-		if s <= 0 {
-			s = 1
+		shardNumber, err := getShardNumber(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
 		}
-		shardNumber := int(s)
 
 		txCnt, err := dbClinet.GetPendingTxCntByShardNumber(shardNumber)
 		if err != nil {
@@ -713,7 +803,7 @@ func (h *BlockHandler) GetPendingTxs() gin.HandlerFunc {
 		}
 
 		page, begin, end := getBeginAndEndByPage(txCnt, p, ps)
-		txs := h.getPendingTxsByBeginAndEnd(shardNumber, begin, end)
+		txs := h.getPendingTxsByBeginAndEnd(shardNumber, txCnt, begin, end)
 
 		c.JSON(http.StatusOK, gin.H{
 			"code":    apiOk,
@@ -742,10 +832,15 @@ func (h *BlockHandler) Search(accHandler *AccountHandler, contractHanlder *Contr
 			return
 		}
 
+		if len(content) == addressLength {
+			h.searchAddress(c, content, accHandler, contractHanlder)
+			return
+		}
+
 		dbBlock, err := dbClinet.GetBlockByHash(content)
 		if err == nil {
 			var maxHeight uint64
-			maxHeight, err = dbClinet.GetBlockHeight(dbBlock.ShardNumber)
+			maxHeight, err = blockCnt(dbClinet, dbBlock.ShardNumber)
 			if err != nil {
 				responseError(c, errGetBlockHeightFromDB, http.StatusInternalServerError, apiDBQueryError)
 				return
@@ -765,7 +860,7 @@ func (h *BlockHandler) Search(accHandler *AccountHandler, contractHanlder *Contr
 
 		dbTx, err := dbClinet.GetTxByHash(content)
 		if err == nil {
-			detailTx := createRetDetailTxInfo(dbTx)
+			detailTx := createRetDetailTxInfo(dbTx, h.getReceipt(content))
 
 			c.JSON(http.StatusOK, gin.H{
 				"code":    apiOk,
@@ -793,32 +888,43 @@ func (h *BlockHandler) Search(accHandler *AccountHandler, contractHanlder *Contr
 			return
 		}
 
-		dbAccount := accHandler.GetAccountByAddressImpl(content)
-		if dbAccount != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"code":    apiOk,
-				"message": "",
-				"data": gin.H{
-					"type": accTypeStr,
-					"info": dbAccount,
-				},
-			})
-			return
-		}
+		responseError(c, errParamInvalid, http.StatusOK, apiDBQueryError)
+	}
+}
 
-		dbContract := contractHanlder.GetContractByAddressImpl(content)
-		if dbContract != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"code":    apiOk,
-				"message": "",
-				"data": gin.H{
-					"type": contractTypeStr,
-					"info": dbContract,
-				},
-			})
-			return
-		}
+//searchAddress search the account or the contract of the address
+func (h *BlockHandler) searchAddress(c *gin.Context, content string, accHandler *AccountHandler, contractHanlder *ContractHandler) {
+	addr, err := common.ParseAddress(content)
+	if err != nil {
+		responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+		return
+	}
 
-		responseError(c, errParamInvalid, http.StatusOK, apiDBQueryError)
+	dbAccount := accHandler.GetAccountByAddressImpl(addr)
+	if dbAccount != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data": gin.H{
+				"type": accTypeStr,
+				"info": dbAccount,
+			},
+		})
+		return
 	}
+
+	dbContract := contractHanlder.GetContractByAddressImpl(addr)
+	if dbContract != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data": gin.H{
+				"type": contractTypeStr,
+				"info": dbContract,
+			},
+		})
+		return
+	}
+
+	responseError(c, errParamInvalid, http.StatusOK, apiDBQueryError)
 }
@@ -115,7 +115,7 @@ func addUpOneDayBlockInfo(oneDayBlocks []*database.DBOneDayBlockInfo) []*databas
 				info.TimeStamp = oneDayBlocks[j].TimeStamp
 				info.ShardNumber = 1
 				info.TotalBlocks += oneDayBlocks[j].TotalBlocks
-				info.Rewards += oneDayBlocks[j].Rewards
+				info.Rewards.Add(&info.Rewards.Int, &oneDayBlocks[j].Rewards.Int)
 			}
 		}
 
@@ -248,7 +248,7 @@ func (h *ChartHandler) GetTxHistory() gin.HandlerFunc {
 				retTx := RetOneDayTxInfo{
 					TotalTxs:      oneDayTrans[i].TotalTxs,
 					TotalBlocks:   int(oneDayBlocks[i].TotalBlocks),
-					Rewards:       oneDayBlocks[i].Rewards,
+					Rewards:       oneDayBlocks[i].Rewards.Big(),
 					TotalAddresss: oneDayAddresses[i].TotalAddresss,
 					TodayIncrease: oneDayAddresses[i].TodayIncrease,
 					TimeStamp:     oneDayTrans[i].TimeStamp,
@@ -317,7 +317,7 @@ func (h *ChartHandler) GetTxHistory() gin.HandlerFunc {
 					HashRate:      oneDayHashRates[i].HashRate,
 					Difficulty:    oneDayBlockDifficulties[i].Difficulty,
 					AvgTime:       oneDayBlockTimes[i].AvgTime,
-					Rewards:       oneDayBlocks[i].Rewards,
+					Rewards:       oneDayBlocks[i].Rewards.Big(),
 					TotalAddresss: oneDayAddresses[i].TotalAddresss,
 					TodayIncrease: oneDayAddresses[i].TodayIncrease,
 					TimeStamp:     oneDayTrans[i].TimeStamp,
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"math/big"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/common"
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
 )
@@ -17,7 +19,7 @@ type ContractTbl struct {
 	DBClient      BlockInfoDB
 	contractTbl   []*database.DBAccount
 	contractMutex sync.RWMutex
-	totalBalance  int64
+	totalBalance  *big.Int
 }
 
 //ProcessGContractTable process global account table
@@ -96,7 +98,7 @@ func (h *ContractHandler) updateImpl() {
 		h.contractTbls[i-1].ProcessGContractTable()
 	}
 
-	totalBalances, err := h.DBClient.GetTotalBalance()
+	totalBalances, err := shardBalances(h.DBClient)
 	if err != nil {
 		log.Error("[DB] err : %v", err)
 	}
@@ -105,7 +107,7 @@ func (h *ContractHandler) updateImpl() {
 		if v, exist := totalBalances[i+1]; exist != false {
 			h.contractTbls[i].totalBalance = v
 		} else {
-			h.contractTbls[i].totalBalance = remianTotalBalance
+			h.contractTbls[i].totalBalance = big.NewInt(remianTotalBalance)
 		}
 	}
 }
@@ -124,8 +126,9 @@ func (h *ContractHandler) Update() {
 }
 
 //GetContractByAddressImpl  use account info, account tx list and account pending tx list to assembly contract information
-func (h *ContractHandler) GetContractByAddressImpl(address string) *RetDetailAccountInfo {
+func (h *ContractHandler) GetContractByAddressImpl(addr common.Address) *RetDetailAccountInfo {
 	dbClinet := h.DBClient
+	address := addr.Hex()
 
 	data, err := dbClinet.GetAccountByAddress(address)
 	if err != nil {
@@ -136,18 +139,17 @@ func (h *ContractHandler) GetContractByAddressImpl(address string) *RetDetailAcc
 		return nil
 	}
 
-	txs, err := dbClinet.GetTxsByAddresss(address, txCount)
+	pengdingTxs, txs, err := getRecentAddressTxs(dbClinet, address)
 	if err != nil {
 		return nil
 	}
 
-	pengdingTxs, err := dbClinet.GetPendingTxsByAddress(address)
-	if err != nil {
-		return nil
+	if shardNumber := addr.Shard(); common.ValidShard(shardNumber) {
+		data.ShardNumber = shardNumber
 	}
 
-	var ttBalance int64
-	if data.ShardNumber >= 1 && data.ShardNumber <= shardCount {
+	var ttBalance *big.Int
+	if common.ValidShard(data.ShardNumber) {
 		ttBalance = h.contractTbls[data.ShardNumber-1].totalBalance
 	}
 
@@ -159,13 +161,13 @@ func (h *ContractHandler) GetContractByAddressImpl(address string) *RetDetailAcc
 //GetContractByAddress get contract detail info by address
 func (h *ContractHandler) GetContractByAddress() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		address := c.Query("address")
-		// if len(address) != addressLength {
-		// 	responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
-		// 	return
-		// }
+		addr, err := getAddress(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
 
-		detailAccount := h.GetContractByAddressImpl(address)
+		detailAccount := h.GetContractByAddressImpl(addr)
 
 		c.JSON(http.StatusOK, gin.H{
 			"code":    apiOk,
@@ -181,7 +183,6 @@ func (h *ContractHandler) GetContracts() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		p, _ := strconv.ParseUint(c.Query("p"), 10, 64)
 		ps, _ := strconv.ParseUint(c.Query("ps"), 10, 64)
-		s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
 		if ps == 0 {
 			ps = blockItemNumsPrePage
 		} else if ps > maxItemNumsPrePage {
@@ -192,12 +193,20 @@ func (h *ContractHandler) GetContracts() gin.HandlerFunc {
 			p--
 		}
 
-		if s <= 0 {
-			s = 1
+		shardNumber, err := getShardNumber(c)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
 		}
-		shardNumber := int(s)
-		if shardNumber < 1 || shardNumber > 20 {
-			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+
+		cursor, paged, err := getPageCursor(c, contractsList)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		if paged {
+			h.getContractsByCursor(c, shardNumber, cursor, ps)
 			return
 		}
 
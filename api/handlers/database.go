@@ -1,31 +1,36 @@
 package handlers
 
-import "github.com/seeleteam/scan-api/database"
+import (
+	"math/big"
+
+	"github.com/seeleteam/scan-api/database"
+)
 
 // BlockInfoDB Warpper for access mongodb.
 type BlockInfoDB interface {
-	GetBlockHeight(shardNumber int) (uint64, error)
 	GetBlockByHeight(shardNumber int, height uint64) (*database.DBBlock, error)
 	GetBlocksByHeight(shardNumber int, begin uint64, end uint64) ([]*database.DBBlock, error)
 	GetBlockByHash(hash string) (*database.DBBlock, error)
-	GetTxCnt() (uint64, error)
-	GetBlockCnt() (uint64, error)
-	GetAccountCnt() (uint64, error)
-	GetContractCnt() (uint64, error)
-	GetTxCntByShardNumber(shardNumber int) (uint64, error)
+	GetStats() ([]*database.DBShardStats, error)
+	GetShardStats(shardNumber int) (*database.DBShardStats, error)
 	GetPendingTxCntByShardNumber(shardNumber int) (uint64, error)
 	GetTxByHash(hash string) (*database.DBTx, error)
 	GetPendingTxByHash(hash string) (*database.DBTx, error)
+	GetReceiptByTxHash(txHash string) (*database.DBReceipt, error)
 	GetTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.DBTx, error)
-	GetPendingTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.DBTx, error)
-	GetTxsByAddresss(address string, max int) ([]*database.DBTx, error)
-	GetPendingTxsByAddress(address string) ([]*database.DBTx, error)
-	GetAccountCntByShardNumber(shardNumber int) (uint64, error)
+	GetPendingTxs(shardNumber int, skip, limit int) ([]*database.DBTx, error)
+	GetAddressTxs(filter *database.AddressTxFilter, skip, limit int) ([]*database.DBAddressTx, error)
+	GetAddressTxsPage(filter *database.AddressTxFilter, timestamp int64, hash string, older bool, limit int) ([]*database.DBAddressTx, error)
+	GetAddressTxCount(address string) (*database.DBAddressTxCount, error)
 	GetAccountByAddress(address string) (*database.DBAccount, error)
 	GetAccountsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error)
-	GetContractCntByShardNumber(shardNumber int) (uint64, error)
 	GetContractsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error)
-	GetTotalBalance() (map[int]int64, error)
+	GetAccountsPage(shardNumber int, balance *big.Int, address string, older bool, limit int) ([]*database.DBAccount, error)
+	GetContractsPage(shardNumber int, timestamp int64, address string, older bool, limit int) ([]*database.DBAccount, error)
+	GetReorgs(shardNumber int, max int) ([]*database.DBReorg, error)
+	GetBalanceAtHeight(address string, height uint64) (*database.DBBalanceChange, error)
+	GetBalanceAtTime(address string, timestamp int64) (*database.DBBalanceChange, error)
+	GetBalanceHistory(address string, begin, end int64, max int) ([]*database.DBBalanceChange, error)
 }
 
 // ChartInfoDB Warpper for access mongodb.
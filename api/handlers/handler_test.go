@@ -8,10 +8,22 @@ package handlers
 import (
 	"encoding/json"
 	"io/ioutil"
+	"math/big"
 	"net/http/httptest"
+	"os"
+	"strconv"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/database/memory"
+)
+
+const (
+	testTxHash  = "0x60209b76ce6869a18266c8ba8608ac97394addd3bcdd3de3410cc5678c47d0b0"
+	testAddress = "0x00000000000000000000000000000000000000a1"
+	testNodeID  = "99d08fe5c216335763277f26fdce972148f164ee5573afe1d59c50233754c53c083ba547767ebe71d82e291be5b6d077d17e20384fc8296430f71bb7b007f079"
+	testBlocks  = 3
 )
 
 var (
@@ -19,8 +31,139 @@ var (
 )
 
 type testResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type testPage struct {
+	PageInfo struct {
+		TotalCount uint64 `json:"totalCount"`
+	} `json:"pageInfo"`
+	List []map[string]interface{} `json:"list"`
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	db, err := newTestStore()
+	if err != nil {
+		panic(err)
+	}
+
+	router = newTestRouter(db)
+	os.Exit(m.Run())
+}
+
+//newTestStore return a store with a few blocks of shard 1, the last one has a transaction
+//from testAddress, and a node and the charts of one day
+func newTestStore() (*memory.Store, error) {
+	db := memory.NewStore()
+	for h := int64(0); h < testBlocks; h++ {
+		block := &database.DBBlock{
+			HeadHash:    "0xb" + strconv.FormatInt(h, 10),
+			PreHash:     "0xb" + strconv.FormatInt(h-1, 10),
+			Height:      h,
+			Timestamp:   100 + h,
+			Difficulty:  "10",
+			Creator:     testAddress,
+			ShardNumber: 1,
+		}
+		if err := db.AddBlock(block); err != nil {
+			return nil, err
+		}
+
+		//the stats of a height are applied once, so the tx of the last block is counted with it
+		delta := &database.DBStatsDelta{Blocks: 1}
+		if h == testBlocks-1 {
+			delta.Txs, delta.Accounts = 1, 1
+		}
+		if err := db.ApplyStats(1, h, delta); err != nil {
+			return nil, err
+		}
+	}
+
+	tx := &database.DBTx{
+		Hash:        testTxHash,
+		From:        testAddress,
+		To:          "0x00000000000000000000000000000000000000b1",
+		Amount:      database.NewBigInt(big.NewInt(5)),
+		Fee:         database.NewBigInt(big.NewInt(1)),
+		Timestamp:   "102",
+		Block:       "2",
+		ShardNumber: 1,
+	}
+	if err := db.AddTx(tx); err != nil {
+		return nil, err
+	}
+	if err := db.AddReceipt(&database.DBReceipt{TxHash: testTxHash, ShardNumber: 1, BlockHeight: 2}); err != nil {
+		return nil, err
+	}
+	if err := db.AddAddressTxs(1, 2, database.CreateDbAddressTxs(tx, "")); err != nil {
+		return nil, err
+	}
+
+	account := database.CreateEmptyAccount(testAddress, 1)
+	account.Balance = database.NewBigInt(big.NewInt(100))
+	account.TxCount = 1
+	if err := db.AddAccount(account); err != nil {
+		return nil, err
+	}
+
+	if err := db.AddNodeInfo(&database.DBNodeInfo{ShardNumber: 1, ID: testNodeID, Host: "127.0.0.1"}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AddOneDayTransInfo(1, &database.DBOneDayTxInfo{TotalTxs: 1, TotalBlocks: testBlocks, ShardNumber: 1}); err != nil {
+		return nil, err
+	}
+	if err := db.AddOneDayAddress(1, &database.DBOneDayAddressInfo{TotalAddresss: 1, ShardNumber: 1}); err != nil {
+		return nil, err
+	}
+	if err := db.AddOneDayBlock(1, &database.DBOneDayBlockInfo{TotalBlocks: testBlocks, ShardNumber: 1}); err != nil {
+		return nil, err
+	}
+	rank := &database.DBMinerRankInfo{
+		Rank:        []database.DBSingleMinerRankInfo{{Address: testAddress, Mined: testBlocks}},
+		ShardNumber: 1,
+	}
+	if err := db.AddTopMinerInfo(1, rank); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+//newTestRouter register the handlers as the api router does, without the cache updating loops
+func newTestRouter(db *memory.Store) *gin.Engine {
+	accHandler := NewAccHandler(db)
+	contractHandler := NewContractHandler(db)
+	nodeHandler := NewNodeHandler(db)
+	blockHandler := &BlockHandler{DBClient: db}
+	chartHandler := &ChartHandler{DBClient: db}
+
+	e := gin.New()
+	v1 := e.Group("/api/v1")
+	v1.GET("/block", blockHandler.GetBlock())
+	v1.GET("/blocks", blockHandler.GetBlocks())
+	v1.GET("/txcount", blockHandler.GetTxCnt())
+	v1.GET("/blockcount", blockHandler.GetBlockCnt())
+	v1.GET("/txs", blockHandler.GetTxs())
+	v1.GET("/tx", blockHandler.GetTxByHash())
+	v1.GET("/search", blockHandler.Search(accHandler, contractHandler))
+	v1.GET("/accounts", accHandler.GetAccounts())
+	v1.GET("/account", accHandler.GetAccountByAddress())
+	v1.GET("/nodes", nodeHandler.GetNodes())
+	v1.GET("/node", nodeHandler.GetNode())
+	v1.GET("/nodemap", nodeHandler.GetNodeMap())
+
+	chartGrp := v1.Group("/chart")
+	chartGrp.GET("/tx", chartHandler.GetTxHistory())
+	chartGrp.GET("/difficulty", chartHandler.GetEveryDayBlockDifficulty())
+	chartGrp.GET("/address", chartHandler.GetEveryDayAddress())
+	chartGrp.GET("/blocks", chartHandler.GetEveryDayBlock())
+	chartGrp.GET("/hashrate", chartHandler.GetEveryHashRate())
+	chartGrp.GET("/blocktime", chartHandler.GetEveryDayBlockTime())
+	chartGrp.GET("/miner", chartHandler.GetTopMiners())
+	return e
 }
 
 // Get According to the specific request uri, initiate a get request and return a response
@@ -42,10 +185,9 @@ func Get(uri string, router *gin.Engine) []byte {
 	return body
 }
 
-// TestOnGetLastBlockRequest test the get last block handler
-func TestOnGetLastBlockRequest(t *testing.T) {
-	uri := "/api/v1/lastblock"
-
+//getData request the uri and decode the data of the response, which must succeed
+func getData(t *testing.T, uri string, data interface{}) {
+	t.Helper()
 	body := Get(uri, router)
 
 	resp := new(testResponse)
@@ -53,506 +195,135 @@ func TestOnGetLastBlockRequest(t *testing.T) {
 		t.Fatalf("respond error，body:%v\n", string(body))
 	}
 
-	if resp.Code != 0 {
+	if resp.Code != apiOk {
 		t.Fatalf("respond error, error:%s\n", resp.Message)
 	}
-}
-
-func TestOnGetBestBlockRequest(t *testing.T) {
-	uri := "/api/v1/bestblock"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
-	}
 
-	if resp.Code != 0 {
-		t.Fatalf("respond error, error:%s\n", resp.Message)
+	if data != nil {
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			t.Fatalf("bad data of %s: %s", uri, resp.Data)
+		}
 	}
 }
 
-func TestOnGetAvgBlockTimeRequest(t *testing.T) {
-	uri := "/api/v1/avgblocktimes"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
+func TestOnGetBlockRequest(t *testing.T) {
+	block := new(RetDetailBlockInfo)
+	getData(t, "/api/v1/block?height=1&s=1", block)
+	if block.HeadHash != "0xb1" || block.Height != 1 || block.MaxHeight != testBlocks {
+		t.Fatalf("bad block %+v", block)
 	}
 
-	if resp.Code != 0 {
-		t.Fatalf("respond error, error:%s\n", resp.Message)
+	getData(t, "/api/v1/block?hash=0xb2", block)
+	if block.HeadHash != "0xb2" || block.PreHash != "0xb1" {
+		t.Fatalf("bad block %+v", block)
 	}
-}
-
-func TestOnGetBlockRequest(t *testing.T) {
-	uri := "/api/v1/block?height=1"
-
-	body := Get(uri, router)
 
 	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Fatalf("respond error, error:%s\n", resp.Message)
+	if err := json.Unmarshal(Get("/api/v1/block?height=10&s=1", router), resp); err != nil || resp.Code != apiDBQueryError {
+		t.Fatalf("missing block is found, %+v", resp)
 	}
 }
 
 func TestOnGetBlocksRequest(t *testing.T) {
-	uri := "/api/v1/blocks"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Fatalf("respond error, error:%s\n", resp.Message)
+	page := new(testPage)
+	getData(t, "/api/v1/blocks", page)
+	if len(page.List) != testBlocks || page.List[0]["height"] != float64(testBlocks-1) {
+		t.Fatalf("bad blocks %+v", page)
 	}
 }
 
 func TestOnGetTxCntRequest(t *testing.T) {
-	uri := "/api/v1/txcount"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Fatalf("respond error, error:%s\n", resp.Message)
+	var txs, blocks uint64
+	getData(t, "/api/v1/txcount", &txs)
+	getData(t, "/api/v1/blockcount", &blocks)
+	if txs != 1 || blocks != testBlocks {
+		t.Fatalf("bad count, txs %d blocks %d", txs, blocks)
 	}
 }
 
 func TestOnGetTxsRequest(t *testing.T) {
-	uri := "/api/v1/txs"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
+	page := new(testPage)
+	getData(t, "/api/v1/txs", page)
+	if page.PageInfo.TotalCount != 1 || len(page.List) != 1 || page.List[0]["txHash"] != testTxHash {
+		t.Fatalf("bad txs %+v", page)
 	}
 
-	if resp.Code != 0 {
-		t.Fatalf("respond error, error:%s\n", resp.Message)
+	getData(t, "/api/v1/txs?address="+testAddress, page)
+	if len(page.List) != 1 || page.List[0]["hash"] != testTxHash {
+		t.Fatalf("bad txs of the address %+v", page)
 	}
 }
 
 func TestOnGetTxByHashRequest(t *testing.T) {
-	uri := "/api/v1/tx?txhash=0x60209b76ce6869a18266c8ba8608ac97394addd3bcdd3de3410cc5678c47d0b0"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Fatalf("respond error, error:%s\n", resp.Message)
+	tx := new(RetDetailTxInfo)
+	getData(t, "/api/v1/tx?txhash="+testTxHash, tx)
+	if tx.TxHash != testTxHash || tx.From != testAddress || tx.Block != 2 || tx.Value.Int64() != 5 {
+		t.Fatalf("bad tx %+v", tx)
 	}
 }
 
 func TestOnSearchRequest(t *testing.T) {
-	uri := "/api/v1/search?content=0x60209b76ce6869a18266c8ba8608ac97394addd3bcdd3de3410cc5678c47d0b0"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Fatalf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
+	result := new(struct {
+		Type string          `json:"type"`
+		Info json.RawMessage `json:"info"`
+	})
+
+	for content, want := range map[string]string{
+		testTxHash:  transTypeStr,
+		"0xb1":      blockTypestr,
+		testAddress: accTypeStr,
+	} {
+		getData(t, "/api/v1/search?content="+content, result)
+		if result.Type != want {
+			t.Fatalf("search %s got %s, want %s", content, result.Type, want)
+		}
 	}
 }
 
 func TestOnGetAccountsRequest(t *testing.T) {
-	uri := "/api/v1/accounts"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
+	page := new(testPage)
+	getData(t, "/api/v1/accounts", page)
+	if page.PageInfo.TotalCount != 1 || len(page.List) != 1 || page.List[0]["address"] != testAddress {
+		t.Fatalf("bad accounts %+v", page)
 	}
 }
 
 func TestOnGetAccountByAddressRequest(t *testing.T) {
-	uri := "/api/v1/account?address=0x4dd6881d13ab5152127533c5954e4e062eb4bb2dcd93becf4f4e9b1d2d69f1363eea0395e8e76a2716b033d1e3cc8da2bf24811b1e31a86ac8bcacca4c4b29bd"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetDifficultyRequest(t *testing.T) {
-	uri := "/api/v1/difficulty"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetHashRateRequest(t *testing.T) {
-	uri := "/api/v1/hashrate"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
+	account := new(RetDetailAccountInfo)
+	getData(t, "/api/v1/account?address="+testAddress, account)
+	if account.Address != testAddress || account.Balance.Int64() != 100 || len(account.Txs) != 1 {
+		t.Fatalf("bad account %+v", account)
 	}
 }
 
 func TestOnGetNodesRequest(t *testing.T) {
-	uri := "/api/v1/nodes"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetNodeRequest(t *testing.T) {
-	uri := "/api/v1/node?id=99d08fe5c216335763277f26fdce972148f164ee5573afe1d59c50233754c53c083ba547767ebe71d82e291be5b6d077d17e20384fc8296430f71bb7b007f079"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetNodeMapRequest(t *testing.T) {
-	uri := "/api/v1/nodemap"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetChartTxRequest(t *testing.T) {
-	uri := "/api/v1/chart/tx"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetChartDifficultyRequest(t *testing.T) {
-	uri := "/api/v1/chart/difficulty"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetChartAddressRequest(t *testing.T) {
-	uri := "/api/v1/chart/address"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-func TestOnGetChartBlockRequest(t *testing.T) {
-	uri := "/api/v1/chart/blocks"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
-	}
-}
-
-func TestOnGetChartHashRateRequest(t *testing.T) {
-	uri := "/api/v1/chart/hashrate"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
-	}
-
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
+	page := new(testPage)
+	getData(t, "/api/v1/nodes?s=1", page)
+	if page.PageInfo.TotalCount != 1 {
+		t.Fatalf("bad nodes %+v", page)
 	}
-}
-
-func TestOnGetChartBlockTimeRequest(t *testing.T) {
-	uri := "/api/v1/chart/blocktime"
-
-	body := Get(uri, router)
 
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
+	node := new(database.DBNodeInfo)
+	getData(t, "/api/v1/node?id="+testNodeID, node)
+	if node.ID != testNodeID || node.ShardNumber != 1 {
+		t.Fatalf("bad node %+v", node)
 	}
 
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
+	var nodes []*database.DBNodeInfo
+	getData(t, "/api/v1/nodemap", &nodes)
+	if len(nodes) != 1 || nodes[0].ID != testNodeID {
+		t.Fatalf("bad node map %+v", nodes)
 	}
 }
 
-func TestOnGetChartTopMinersRequest(t *testing.T) {
-	uri := "/api/v1/chart/miner"
-
-	body := Get(uri, router)
-
-	resp := new(testResponse)
-	if err := json.Unmarshal(body, resp); err != nil {
-		t.Errorf("respond error，body:%v\n", string(body))
+func TestOnGetChartRequest(t *testing.T) {
+	for _, chart := range []string{"tx", "difficulty", "address", "blocks", "hashrate", "blocktime"} {
+		getData(t, "/api/v1/chart/"+chart, nil)
 	}
 
-	if resp.Code != 0 {
-		t.Errorf("respond error, error:%s\n", resp.Message)
+	var miners []*database.DBMinerRankInfo
+	getData(t, "/api/v1/chart/miner", &miners)
+	if len(miners) != 1 || len(miners[0].Rank) != 1 || miners[0].Rank[0].Address != testAddress {
+		t.Fatalf("bad top miners %+v", miners)
 	}
 }
-
-// type TestBlockInfoDB struct {
-// }
-
-// func (db *TestBlockInfoDB) GetBlockHeight(shardNumber int) (uint64, error) {
-// 	return 1, nil
-// }
-
-// func (db *TestBlockInfoDB) GetBlockByHeight(shardNumber int, height uint64) (*database.DBBlock, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetBlocksByHeight(shardNumber int, begin uint64, end uint64) ([]*database.DBBlock, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetBlockByHash(hash string) (*database.DBBlock, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetTxCnt() (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetBlockCnt() (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetAccountCnt() (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetContractCnt() (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetTxCntByShardNumber(shardNumber int) (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetPendingTxCntByShardNumber(shardNumber int) (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetTxByHash(hash string) (*database.DBTx, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetPendingTxByHash(hash string) (*database.DBTx, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.DBTx, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetPendingTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.DBTx, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetTxsByAddresss(address string, max int) ([]*database.DBTx, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetPendingTxsByAddress(address string) ([]*database.DBTx, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetAccountCntByShardNumber(shardNumber int) (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetAccountByAddress(address string) (*database.DBAccount, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetAccountsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetContractCntByShardNumber(shardNumber int) (uint64, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetContractsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error) {
-
-// }
-
-// func (db *TestBlockInfoDB) GetTotalBalance() (map[int]int64, error) {
-
-// }
-
-// // ChartInfoDB Warpper for access mongodb.
-// type TestChartInfoDB struct {
-// }
-
-// func (db *TestChartInfoDB) GetTransInfoChart() ([]*database.DBOneDayTxInfo, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayAddressesChart() ([]*database.DBOneDayAddressInfo, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayBlockDifficultyChart() ([]*database.DBOneDayBlockDifficulty, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayBlocksChart() ([]*database.DBOneDayBlockInfo, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetHashRateChart() ([]*database.DBOneDayHashRate, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayBlockAvgTimeChart() ([]*database.DBOneDayBlockAvgTime, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetTopMinerChart() ([]*database.DBMinerRankInfo, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetTransInfoChartByShardNumber(shardNumber int) ([]*database.DBOneDayTxInfo, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayAddressesChartByShardNumber(shardNumber int) ([]*database.DBOneDayAddressInfo, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayBlockDifficultyChartByShardNumber(shardNumber int) ([]*database.DBOneDayBlockDifficulty, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayBlocksChartByShardNumber(shardNumber int) ([]*database.DBOneDayBlockInfo, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetHashRateChartByShardNumber(shardNumber int) ([]*database.DBOneDayHashRate, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetOneDayBlockAvgTimeChartByShardNumber(shardNumber int) ([]*database.DBOneDayBlockAvgTime, error) {
-
-// }
-
-// func (db *TestChartInfoDB) GetTopMinerChartByShardNumber(shardNumber int) ([]*database.DBMinerRankInfo, error) {
-
-// }
-
-// type TestNodeInfoDB struct {
-// }
-
-// func (db *TestNodeInfoDB) GetNodeInfosByShardNumber(shardNumber int) ([]*database.DBNodeInfo, error) {
-
-// }
-
-// func (db *TestNodeInfoDB) GetNodeCntByShardNumber(shardNumber int) (uint64, error) {
-
-// }
-
-// func (db *TestNodeInfoDB) GetNodeInfoByID(id string) (*database.DBNodeInfo, error) {
-
-// }
-
-// func init() {
-
-// }
@@ -0,0 +1,429 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+)
+
+//lists paged by cursor, a cursor is only valid in the list it is issued for
+const (
+	blocksList     = "blocks"
+	txsList        = "txs"
+	blockTxsList   = "blocktxs"
+	addressTxsList = "addresstxs"
+	accountsList   = "accounts"
+	contractsList  = "contracts"
+)
+
+var (
+	errCursorInvalid = errors.New("cursor is invalid")
+)
+
+//pageCursor is the position of an item in a list, the items next to it make a page. Clients get it as
+//an opaque token, so the keys of a list can change without breaking them
+type pageCursor struct {
+	List  string `json:"l"`
+	Key   string `json:"k"`           //height, idx, position in the block, timestamp or balance of the item
+	ID    string `json:"i,omitempty"` //hash or address, which breaks the ties of the key
+	Rank  int    `json:"r,omitempty"` //rank of the item in the account list
+	Newer bool   `json:"n,omitempty"` //the page precedes the item
+}
+
+//String encode the cursor into a token
+func (p *pageCursor) String() string {
+	data, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+//older return whether the page of the cursor follows its item, the first page follows the newest item
+func (p *pageCursor) older() bool {
+	return p == nil || !p.Newer
+}
+
+//getPageCursor return the cursor in the query, paged is false if the request pages by number.
+//The cursor is nil for the first page
+func getPageCursor(c *gin.Context, list string) (cursor *pageCursor, paged bool, err error) {
+	token, exist := c.GetQuery("cursor")
+	if !exist {
+		return nil, false, nil
+	}
+
+	if token == "" {
+		return nil, true, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, true, errCursorInvalid
+	}
+
+	cursor = new(pageCursor)
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.List != list {
+		return nil, true, errCursorInvalid
+	}
+	return cursor, true, nil
+}
+
+//cursorInt return the key of the cursor as an integer
+func cursorInt(cursor *pageCursor) (uint64, error) {
+	key, err := strconv.ParseUint(cursor.Key, 10, 64)
+	if err != nil {
+		return 0, errCursorInvalid
+	}
+	return key, nil
+}
+
+//cursorRange return the range [begin, end) of the page next to the cursor in a list of contiguous
+//keys ordered descending, top is the key after the newest item
+func cursorRange(cursor *pageCursor, top, ps uint64) (begin, end uint64, err error) {
+	if cursor == nil {
+		end = top
+	} else {
+		key, err := cursorInt(cursor)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if cursor.Newer {
+			return key + 1, key + 1 + ps, nil
+		}
+		end = key
+	}
+
+	if end > ps {
+		begin = end - ps
+	}
+	return begin, end, nil
+}
+
+//cursorPageInfo return the tokens of the pages next to a page, first and last are the cursors of the
+//first and the last item of the page, nil if it is empty. A page shorter than ps is the last one in its direction
+func cursorPageInfo(cursor, first, last *pageCursor, size int, ps uint64) gin.H {
+	var next, prev string
+	full := uint64(size) >= ps
+
+	if last == nil && cursor != nil {
+		//the page is empty, the way back starts from the cursor itself
+		back := *cursor
+		back.Newer = !cursor.Newer
+		first, last = &back, &back
+	}
+
+	if last != nil && (full || !cursor.older()) {
+		last.Newer = false
+		next = last.String()
+	}
+
+	if first != nil && cursor != nil && (full || cursor.older()) {
+		first.Newer = true
+		prev = first.String()
+	}
+
+	return gin.H{
+		"next": next,
+		"prev": prev,
+	}
+}
+
+//cursorResponse send a page of a list paged by cursor
+func cursorResponse(c *gin.Context, pageInfo gin.H, list interface{}) {
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apiOk,
+		"message": "",
+		"data": gin.H{
+			"pageInfo": pageInfo,
+			"list":     list,
+		},
+	})
+}
+
+//getBlocksByCursor get the blocks of the shard next to the cursor, the latest comes first
+func (h *BlockHandler) getBlocksByCursor(c *gin.Context, shardNumber int, cursor *pageCursor, ps uint64) {
+	dbClinet := h.DBClient
+
+	var top uint64
+	if cursor == nil {
+		var err error
+		if top, err = blockCnt(dbClinet, shardNumber); err != nil {
+			responseError(c, errGetBlockHeightFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+	}
+
+	begin, end, err := cursorRange(cursor, top, ps)
+	if err != nil {
+		responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+		return
+	}
+
+	dbBlocks, err := dbClinet.GetBlocksByHeight(shardNumber, begin, end)
+	if err != nil {
+		responseError(c, errGetBlockFromDB, http.StatusInternalServerError, apiDBQueryError)
+		return
+	}
+
+	var first, last *pageCursor
+	var blocks []*RetSimpleBlockInfo
+	for i, data := range dbBlocks {
+		blocks = append(blocks, createRetSimpleBlockInfo(data))
+
+		item := &pageCursor{List: blocksList, Key: strconv.FormatInt(data.Height, 10)}
+		if i == 0 {
+			first = item
+		}
+		last = item
+	}
+
+	cursorResponse(c, cursorPageInfo(cursor, first, last, len(blocks), ps), blocks)
+}
+
+//getTxsByCursor get the txs of the shard next to the cursor, the latest comes first
+func (h *BlockHandler) getTxsByCursor(c *gin.Context, shardNumber int, cursor *pageCursor, ps uint64) {
+	dbClinet := h.DBClient
+
+	var top uint64
+	if cursor == nil {
+		cnt, err := txCnt(dbClinet, shardNumber)
+		if err != nil {
+			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+		//the idx of the txs starts from 1
+		top = cnt + 1
+	}
+
+	begin, end, err := cursorRange(cursor, top, ps)
+	if err != nil {
+		responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+		return
+	}
+
+	dbTrans, err := dbClinet.GetTxsByIdx(shardNumber, begin, end)
+	if err != nil {
+		responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
+		return
+	}
+
+	var first, last *pageCursor
+	var txs []*RetSimpleTxInfo
+	for i, data := range dbTrans {
+		txs = append(txs, createRetSimpleTxInfo(data))
+
+		item := &pageCursor{List: txsList, Key: strconv.FormatInt(data.Idx, 10)}
+		if i == 0 {
+			first = item
+		}
+		last = item
+	}
+
+	cursorResponse(c, cursorPageInfo(cursor, first, last, len(txs), ps), txs)
+}
+
+//getTxsInBlockByCursor get the txs of the block next to the cursor in the order of the block
+func (h *BlockHandler) getTxsInBlockByCursor(c *gin.Context, shardNumber int, height uint64, cursor *pageCursor, ps uint64) {
+	block, err := h.DBClient.GetBlockByHeight(shardNumber, height)
+	if err != nil {
+		cursorResponse(c, cursorPageInfo(nil, nil, nil, 0, ps), nil)
+		return
+	}
+
+	//the list is ordered by the position in the block, the first tx has the largest key
+	total := uint64(len(block.Txs))
+	var top uint64
+	if cursor == nil {
+		top = total
+	}
+
+	begin, end, err := cursorRange(cursor, top, ps)
+	if err != nil {
+		responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+		return
+	}
+
+	if end > total {
+		end = total
+	}
+
+	var first, last *pageCursor
+	var retTxs []*RetSimpleTxInfo
+	for key := end; key > begin; key-- {
+		data := block.Txs[total-key]
+
+		timeStamp := big.NewInt(0)
+		var age string
+		if timeStamp.UnmarshalText([]byte(data.Timestamp)) == nil {
+			age = getElpasedTimeDesc(timeStamp.Div(timeStamp, big.NewInt(1e9)))
+		}
+
+		retTxs = append(retTxs, &RetSimpleTxInfo{
+			TxHash: data.Hash,
+			Block:  height,
+			Age:    age,
+			From:   data.From,
+			To:     data.To,
+			Value:  data.Amount.Big(),
+		})
+
+		item := &pageCursor{List: blockTxsList, Key: strconv.FormatUint(key-1, 10)}
+		if first == nil {
+			first = item
+		}
+		last = item
+	}
+
+	cursorResponse(c, cursorPageInfo(cursor, first, last, len(retTxs), ps), retTxs)
+}
+
+//getTxsInAccountByCursor get the txs in the tx history of the address selected by the filter next to the cursor,
+//the latest comes first. The txs still in the tx pool come before them in the first page
+func (h *BlockHandler) getTxsInAccountByCursor(c *gin.Context, filter *database.AddressTxFilter, cursor *pageCursor, ps uint64) {
+	dbClinet := h.DBClient
+
+	var timestamp int64
+	var hash string
+	if cursor != nil {
+		var err error
+		if timestamp, err = strconv.ParseInt(cursor.Key, 10, 64); err != nil || cursor.ID == "" {
+			responseError(c, errCursorInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		hash = cursor.ID
+	}
+
+	dbTrans, err := dbClinet.GetAddressTxsPage(filter, timestamp, hash, cursor.older(), int(ps))
+	if err != nil {
+		responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
+		return
+	}
+
+	txCnt, err := dbClinet.GetAddressTxCount(filter.Address)
+	if err != nil {
+		responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
+		return
+	}
+
+	var first, last *pageCursor
+	var retTxs []*RetDetailAccountTxInfo
+	for i, data := range dbTrans {
+		retTxs = append(retTxs, createRetAddressTxInfo(data))
+
+		item := &pageCursor{List: addressTxsList, Key: strconv.FormatInt(data.Time, 10), ID: data.Hash}
+		if i == 0 {
+			first = item
+		}
+		last = item
+	}
+	pageInfo := cursorPageInfo(cursor, first, last, len(retTxs), ps)
+	pageInfo["totalCount"] = txCnt.Of(filter.Direction)
+
+	if cursor == nil {
+		pendingFilter := *filter
+		pendingFilter.Pending = true
+		pendingTxs, err := dbClinet.GetAddressTxs(&pendingFilter, 0, 0)
+		if err != nil {
+			responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		var retPendingTxs []*RetDetailAccountTxInfo
+		for _, data := range pendingTxs {
+			retPendingTxs = append(retPendingTxs, createRetAddressTxInfo(data))
+		}
+		retTxs = append(retPendingTxs, retTxs...)
+	}
+
+	cursorResponse(c, pageInfo, retTxs)
+}
+
+//getAccountsByCursor get the accounts of the shard next to the cursor, the richest comes first
+func (h *AccountHandler) getAccountsByCursor(c *gin.Context, shardNumber int, cursor *pageCursor, ps uint64) {
+	balance := new(big.Int)
+	var address string
+	rank := 0
+	if cursor != nil {
+		if _, ok := balance.SetString(cursor.Key, 10); !ok || cursor.ID == "" {
+			responseError(c, errCursorInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		address, rank = cursor.ID, cursor.Rank
+	}
+
+	dbAccounts, err := h.DBClient.GetAccountsPage(shardNumber, balance, address, cursor.older(), int(ps))
+	if err != nil {
+		responseError(c, errGetAccountFromDB, http.StatusInternalServerError, apiDBQueryError)
+		return
+	}
+
+	//the rank of the first account of the page
+	if cursor.older() {
+		rank++
+	} else {
+		rank -= len(dbAccounts)
+	}
+
+	accTbl := h.accTbls[shardNumber-1]
+	var first, last *pageCursor
+	var accounts []*RetSimpleAccountInfo
+	for i, data := range dbAccounts {
+		account := createRetSimpleAccountInfo(data, accTbl.totalBalance)
+		account.Rank = rank + i
+		accounts = append(accounts, account)
+
+		item := &pageCursor{List: accountsList, Key: data.Balance.String(), ID: data.Address, Rank: account.Rank}
+		if i == 0 {
+			first = item
+		}
+		last = item
+	}
+
+	pageInfo := cursorPageInfo(cursor, first, last, len(accounts), ps)
+	pageInfo["totalBalance"] = accTbl.totalBalance
+	cursorResponse(c, pageInfo, accounts)
+}
+
+//getContractsByCursor get the contracts of the shard next to the cursor, the latest comes first
+func (h *ContractHandler) getContractsByCursor(c *gin.Context, shardNumber int, cursor *pageCursor, ps uint64) {
+	var timestamp int64
+	var address string
+	if cursor != nil {
+		var err error
+		if timestamp, err = strconv.ParseInt(cursor.Key, 10, 64); err != nil || cursor.ID == "" {
+			responseError(c, errCursorInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		address = cursor.ID
+	}
+
+	dbAccounts, err := h.DBClient.GetContractsPage(shardNumber, timestamp, address, cursor.older(), int(ps))
+	if err != nil {
+		responseError(c, errGetAccountFromDB, http.StatusInternalServerError, apiDBQueryError)
+		return
+	}
+
+	contractTbl := h.contractTbls[shardNumber-1]
+	var first, last *pageCursor
+	var contracts []*RetSimpleAccountInfo
+	for i, data := range dbAccounts {
+		contracts = append(contracts, createRetSimpleAccountInfo(data, contractTbl.totalBalance))
+
+		item := &pageCursor{List: contractsList, Key: strconv.FormatInt(data.TimeStamp, 10), ID: data.Address}
+		if i == 0 {
+			first = item
+		}
+		last = item
+	}
+
+	cursorResponse(c, cursorPageInfo(cursor, first, last, len(contracts), ps), contracts)
+}
@@ -0,0 +1,229 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/database/memory"
+)
+
+type cursorPage struct {
+	Code int `json:"code"`
+	Data struct {
+		PageInfo struct {
+			Next string `json:"next"`
+			Prev string `json:"prev"`
+		} `json:"pageInfo"`
+		List []struct {
+			Height  uint64 `json:"height"`
+			Address string `json:"address"`
+			Rank    int    `json:"rank"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+func getCursorPage(t *testing.T, e *gin.Engine, uri string) *cursorPage {
+	t.Helper()
+	page := new(cursorPage)
+	body := Get(uri, e)
+	if err := json.Unmarshal(body, page); err != nil || page.Code != apiOk {
+		t.Fatalf("bad response of %s: %s", uri, body)
+	}
+	return page
+}
+
+func TestBlocksByCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := memory.NewStore()
+	for h := int64(0); h < 5; h++ {
+		if err := db.AddBlock(&database.DBBlock{HeadHash: "0xb" + strconv.FormatInt(h, 10), Height: h, ShardNumber: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.ApplyStats(1, h, &database.DBStatsDelta{Blocks: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := gin.New()
+	e.GET("/blocks", (&BlockHandler{DBClient: db}).GetBlocks())
+	heights := func(page *cursorPage) []uint64 {
+		var list []uint64
+		for _, b := range page.Data.List {
+			list = append(list, b.Height)
+		}
+		return list
+	}
+
+	first := getCursorPage(t, e, "/blocks?ps=2&cursor=")
+	if got := heights(first); len(got) != 2 || got[0] != 4 || got[1] != 3 || first.Data.PageInfo.Prev != "" {
+		t.Fatalf("bad first page %+v", first.Data)
+	}
+
+	//new blocks do not move the pages behind the first one
+	db.AddBlock(&database.DBBlock{HeadHash: "0xb5", Height: 5, ShardNumber: 1})
+	db.ApplyStats(1, 5, &database.DBStatsDelta{Blocks: 1})
+
+	second := getCursorPage(t, e, "/blocks?ps=2&cursor="+url.QueryEscape(first.Data.PageInfo.Next))
+	if got := heights(second); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("bad second page %+v", second.Data)
+	}
+
+	last := getCursorPage(t, e, "/blocks?ps=2&cursor="+url.QueryEscape(second.Data.PageInfo.Next))
+	if got := heights(last); len(got) != 1 || got[0] != 0 || last.Data.PageInfo.Next != "" {
+		t.Fatalf("bad last page %+v", last.Data)
+	}
+
+	back := getCursorPage(t, e, "/blocks?ps=2&cursor="+url.QueryEscape(last.Data.PageInfo.Prev))
+	if got := heights(back); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Fatalf("bad page back %+v", back.Data)
+	}
+
+	newer := getCursorPage(t, e, "/blocks?ps=2&cursor="+url.QueryEscape(back.Data.PageInfo.Prev))
+	if got := heights(newer); len(got) != 2 || got[0] != 4 || got[1] != 3 {
+		t.Fatalf("bad newer page %+v", newer.Data)
+	}
+
+	body := Get("/blocks?cursor="+url.QueryEscape((&pageCursor{List: txsList, Key: "1"}).String()), e)
+	resp := new(testResponse)
+	if err := json.Unmarshal(body, resp); err != nil || resp.Code != apiParmaInvalid {
+		t.Fatalf("cursor of another list is accepted: %s", body)
+	}
+}
+
+func TestAccountsByCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := memory.NewStore()
+	for i, balance := range []int64{10, 50, 50, 100, 7} {
+		account := database.CreateEmptyAccount("0xa"+strconv.Itoa(i), 1)
+		account.Balance = database.NewBigInt(big.NewInt(balance))
+		if err := db.AddAccount(account); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := gin.New()
+	e.GET("/accounts", NewAccHandler(db).GetAccounts())
+
+	var addresses []string
+	var ranks []int
+	token := ""
+	for {
+		page := getCursorPage(t, e, "/accounts?ps=2&cursor="+url.QueryEscape(token))
+		for _, a := range page.Data.List {
+			addresses = append(addresses, a.Address)
+			ranks = append(ranks, a.Rank)
+		}
+		if page.Data.PageInfo.Next == "" {
+			break
+		}
+		token = page.Data.PageInfo.Next
+	}
+
+	want := []string{"0xa3", "0xa2", "0xa1", "0xa0", "0xa4"}
+	if len(addresses) != len(want) {
+		t.Fatalf("bad accounts %v", addresses)
+	}
+	for i := range want {
+		if addresses[i] != want[i] || ranks[i] != i+1 {
+			t.Fatalf("bad accounts %v ranks %v", addresses, ranks)
+		}
+	}
+}
+
+func TestTxsInAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := memory.NewStore()
+	address := "0x00000000000000000000000000000000000000a1"
+	other := "0x00000000000000000000000000000000000000b1"
+
+	mined := []*database.DBTx{
+		{Hash: "0xt1", From: address, To: other, Timestamp: "100", Block: "1", ShardNumber: 1},
+		{Hash: "0xt2", From: other, To: address, Timestamp: "101", Block: "1", ShardNumber: 1},
+		{Hash: "0xt3", From: other, To: address, Timestamp: "102", Block: "1", ShardNumber: 1},
+	}
+	var entries []*database.DBAddressTx
+	for _, tx := range mined {
+		entries = append(entries, database.CreateDbAddressTxs(tx, "")...)
+	}
+	if err := db.AddAddressTxs(1, 1, entries); err != nil {
+		t.Fatal(err)
+	}
+	poolTx := &database.DBTx{Hash: "0xp1", From: address, To: other, Timestamp: "103", ShardNumber: 1, Pending: true}
+	if err := db.AddPendingAddressTxs(database.CreateDbAddressTxs(poolTx, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	e := gin.New()
+	e.GET("/txs", (&BlockHandler{DBClient: db}).GetTxs())
+	type txsPage struct {
+		Code int `json:"code"`
+		Data struct {
+			PageInfo struct {
+				TotalCount int    `json:"totalCount"`
+				Next       string `json:"next"`
+			} `json:"pageInfo"`
+			List []struct {
+				Hash      string `json:"hash"`
+				Direction string `json:"direction"`
+			} `json:"list"`
+		} `json:"data"`
+	}
+	getTxs := func(query string) (hashes []string, page *txsPage) {
+		page = new(txsPage)
+		body := Get("/txs?address="+address+"&"+query, e)
+		if err := json.Unmarshal(body, page); err != nil || page.Code != apiOk {
+			t.Fatalf("bad response of %s: %s", query, body)
+		}
+		for _, tx := range page.Data.List {
+			hashes = append(hashes, tx.Hash)
+		}
+		return hashes, page
+	}
+	checkHashes := func(what string, got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("bad %s %v, want %v", what, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("bad %s %v, want %v", what, got, want)
+			}
+		}
+	}
+
+	//the pages are numbered from the end of the list, the pending txs come first
+	hashes, page := getTxs("p=2&ps=2")
+	checkHashes("latest txs", hashes, "0xp1", "0xt3")
+	if page.Data.PageInfo.TotalCount != 4 {
+		t.Fatalf("bad total count %d", page.Data.PageInfo.TotalCount)
+	}
+	hashes, _ = getTxs("p=1&ps=2")
+	checkHashes("oldest txs", hashes, "0xt2", "0xt1")
+
+	hashes, page = getTxs("direction=in&cursor=")
+	checkHashes("received txs", hashes, "0xt3", "0xt2")
+	if page.Data.PageInfo.TotalCount != 2 || page.Data.List[0].Direction != database.DirectionIn {
+		t.Fatalf("bad page of received txs %+v", page.Data)
+	}
+
+	hashes, page = getTxs("ps=2&cursor=")
+	checkHashes("first page", hashes, "0xp1", "0xt3", "0xt2")
+	hashes, _ = getTxs("ps=2&cursor=" + url.QueryEscape(page.Data.PageInfo.Next))
+	checkHashes("second page", hashes, "0xt1")
+
+	body := Get("/txs?address="+address+"&direction=sideways", e)
+	resp := new(testResponse)
+	if err := json.Unmarshal(body, resp); err != nil || resp.Code != apiParmaInvalid {
+		t.Fatalf("invalid direction is accepted: %s", body)
+	}
+}
@@ -0,0 +1,66 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/common"
+	"github.com/seeleteam/scan-api/database"
+)
+
+//getAddress return the address in the query, the address is normalized to the stored format
+func getAddress(c *gin.Context) (common.Address, error) {
+	return common.ParseAddress(c.Query("address"))
+}
+
+//getShardNumber return the shard of a request: the shard of the address if it is given,
+//otherwise the shard s, which defaults to 1
+func getShardNumber(c *gin.Context) (int, error) {
+	s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
+	shardSpecified := s > 0
+	if !shardSpecified {
+		s = 1
+	}
+
+	shardNumber := int(s)
+	if !common.ValidShard(shardNumber) {
+		return 0, fmt.Errorf("invalid shard %d, it must be in [1, %d]", shardNumber, common.ShardCount)
+	}
+
+	if _, exist := c.GetQuery("address"); !exist {
+		return shardNumber, nil
+	}
+
+	addr, err := getAddress(c)
+	if err != nil {
+		return 0, err
+	}
+
+	addrShard := addr.Shard()
+	if addrShard == common.UndefinedShardNumber {
+		return shardNumber, nil
+	}
+
+	if shardSpecified && addrShard != shardNumber {
+		return 0, fmt.Errorf("address %s is in shard %d, not in shard %d", addr.Hex(), addrShard, shardNumber)
+	}
+	return addrShard, nil
+}
+
+//getAddressTxFilter return the filter of the tx history of the address, the direction is in, out or create,
+//all directions are selected without it
+func getAddressTxFilter(c *gin.Context, address string) (*database.AddressTxFilter, error) {
+	direction := c.Query("direction")
+	switch direction {
+	case "", database.DirectionIn, database.DirectionOut, database.DirectionCreate:
+	default:
+		return nil, fmt.Errorf("invalid direction %s, it must be in, out or create", direction)
+	}
+	return &database.AddressTxFilter{Address: address, Direction: direction}, nil
+}
@@ -0,0 +1,58 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"math/big"
+
+	"github.com/seeleteam/scan-api/database"
+)
+
+//totalStats return the sum of the stats of all shards
+func totalStats(db BlockInfoDB) (*database.DBStatsDelta, error) {
+	stats, err := db.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	total := new(database.DBStatsDelta)
+	for _, s := range stats {
+		total.Add(&s.DBStatsDelta)
+	}
+	return total, nil
+}
+
+//shardBalances return the total balance of each shard
+func shardBalances(db BlockInfoDB) (map[int]*big.Int, error) {
+	stats, err := db.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[int]*big.Int)
+	for _, s := range stats {
+		balances[s.ShardNumber] = s.TotalBalance.Big()
+	}
+	return balances, nil
+}
+
+//blockCnt return the number of blocks of the shard
+func blockCnt(db BlockInfoDB, shardNumber int) (uint64, error) {
+	stats, err := db.GetShardStats(shardNumber)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(stats.Blocks), nil
+}
+
+//txCnt return the number of txs of the shard
+func txCnt(db BlockInfoDB, shardNumber int) (uint64, error) {
+	stats, err := db.GetShardStats(shardNumber)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(stats.Txs), nil
+}
@@ -50,58 +50,79 @@ type RetDetailBlockInfo struct {
 
 //RetSimpleTxInfo describle the transaction info in the transaction detail page which send to the frontend
 type RetSimpleTxInfo struct {
-	TxType      int    `json:"txtype"`
-	ShardNumber int    `json:"shardnumber"`
-	TxHash      string `json:"txHash"`
-	Block       uint64 `json:"block"`
-	Age         string `json:"age"`
-	From        string `json:"from"`
-	To          string `json:"to"`
-	Value       int64  `json:"value"`
-	Pending     bool   `json:"pending"`
-	Fee         int64  `json:"fee"`
+	TxType      int      `json:"txtype"`
+	ShardNumber int      `json:"shardnumber"`
+	TxHash      string   `json:"txHash"`
+	Block       uint64   `json:"block"`
+	Age         string   `json:"age"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value"`
+	Pending     bool     `json:"pending"`
+	Fee         *big.Int `json:"fee"`
+	Status      string   `json:"status"`
+
+	//lifecycle in the tx pool, the times are unix seconds
+	FirstSeen     int64 `json:"firstSeen"`
+	LeftPoolTime  int64 `json:"leftPoolTime"`
+	InclusionTime int64 `json:"inclusionTime"`
 }
 
 //RetDetailTxInfo describle the transaction detail info in the transaction detail page which send to the frontend
 type RetDetailTxInfo struct {
-	TxType       int    `json:"txtype"`
-	ShardNumber  int    `json:"shardnumber"`
-	TxHash       string `json:"txHash"`
-	Block        uint64 `json:"block"`
-	Age          string `json:"age"`
-	From         string `json:"from"`
-	To           string `json:"to"`
-	Value        int64  `json:"value"`
-	Pending      bool   `json:"pending"`
-	Fee          int64  `json:"fee"`
-	AccountNonce string `json:"accountNonce"`
-	Payload      string `json:"payload"`
+	TxType       int      `json:"txtype"`
+	ShardNumber  int      `json:"shardnumber"`
+	TxHash       string   `json:"txHash"`
+	Block        uint64   `json:"block"`
+	Age          string   `json:"age"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Value        *big.Int `json:"value"`
+	Pending      bool     `json:"pending"`
+	Fee          *big.Int `json:"fee"`
+	AccountNonce string   `json:"accountNonce"`
+	Payload      string   `json:"payload"`
+	Status       string   `json:"status"`
+
+	//lifecycle in the tx pool, zero if the transaction was never seen in the tx pool
+	FirstSeen     int64 `json:"firstSeen"`
+	LeftPoolTime  int64 `json:"leftPoolTime"`
+	InclusionTime int64 `json:"inclusionTime"`
+
+	//receipt of the transaction, empty if the transaction has no receipt
+	Result          string   `json:"result"`
+	PostState       string   `json:"postState"`
+	UsedGas         int64    `json:"usedGas"`
+	TotalFee        *big.Int `json:"totalFee"`
+	ContractAddress string   `json:"contractAddress"`
 }
 
 //RetSimpleAccountInfo describle the account info in the account list page which send to the frontend
 type RetSimpleAccountInfo struct {
-	AccType     int     `json:"accType"`
-	ShardNumber int     `json:"shardnumber"`
-	Rank        int     `json:"rank"`
-	Address     string  `json:"address"`
-	Balance     int64   `json:"balance"`
-	Percentage  float64 `json:"percentage"`
-	TxCount     int64   `json:"txcount"`
+	AccType     int      `json:"accType"`
+	ShardNumber int      `json:"shardnumber"`
+	Rank        int      `json:"rank"`
+	Address     string   `json:"address"`
+	Balance     *big.Int `json:"balance"`
+	Percentage  float64  `json:"percentage"`
+	TxCount     int64    `json:"txcount"`
 }
 
 //RetDetailAccountTxInfo describle the tx info contained by the RetDetailAccountInfo
 type RetDetailAccountTxInfo struct {
-	ShardNumber int    `json:"shardnumber"`
-	TxType      int    `json:"txtype"`
-	Hash        string `json:"hash"`
-	Block       string `json:"block"`
-	From        string `json:"from"`
-	To          string `json:"to"`
-	Value       int64  `json:"value"`
-	Age         string `json:"age"`
-	Fee         int64  `json:"fee"`
-	InOrOut     bool   `json:"inorout"`
-	Pending     bool   `json:"pending"`
+	ShardNumber int      `json:"shardnumber"`
+	TxType      int      `json:"txtype"`
+	Hash        string   `json:"hash"`
+	Block       string   `json:"block"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value"`
+	Age         string   `json:"age"`
+	Fee         *big.Int `json:"fee"`
+	InOrOut     bool     `json:"inorout"`
+	Pending     bool     `json:"pending"`
+	Status      string   `json:"status"`
+	Direction   string   `json:"direction,omitempty"` //in, out, self or create in the tx history of the address
 }
 
 //RetDetailAccountInfo describle the detail account info which send to the frontend
@@ -109,13 +130,32 @@ type RetDetailAccountInfo struct {
 	AccType              int                      `json:"accType"`
 	ShardNumber          int                      `json:"shardnumber"`
 	Address              string                   `json:"address"`
-	Balance              int64                    `json:"balance"`
+	Balance              *big.Int                 `json:"balance"`
 	Percentage           float64                  `json:"percentage"`
 	TxCount              int64                    `json:"txcount"`
 	ContractCreationCode string                   `json:"contractCreationCode"`
 	Txs                  []RetDetailAccountTxInfo `json:"txs"`
 }
 
+//RetReorgInfo describle a chain reorganization which send to the frontend
+type RetReorgInfo struct {
+	ShardNumber int      `json:"shardnumber"`
+	Depth       int64    `json:"depth"`
+	ForkHeight  int64    `json:"forkHeight"`
+	ForkHash    string   `json:"forkHash"`
+	OldHashes   []string `json:"oldHashes"`
+	NewHashes   []string `json:"newHashes"`
+	Age         string   `json:"age"`
+}
+
+//RetBalanceChange describle the balance of an account after a block touched it which send to the frontend
+type RetBalanceChange struct {
+	Height    int64    `json:"height"`
+	Timestamp int64    `json:"timestamp"`
+	Balance   *big.Int `json:"balance"`
+	Delta     *big.Int `json:"delta"`
+}
+
 //createRetSimpleBlockInfo converts the given dbblock to the retsimpleblockinfo
 func createRetSimpleBlockInfo(blockInfo *database.DBBlock) *RetSimpleBlockInfo {
 	var ret RetSimpleBlockInfo
@@ -160,9 +200,13 @@ func createRetSimpleTxInfo(transaction *database.DBTx) *RetSimpleTxInfo {
 	ret.Block, _ = strconv.ParseUint(transaction.Block, 10, 64)
 	ret.From = transaction.From
 	ret.To = transaction.To
-	ret.Value = transaction.Amount
+	ret.Value = transaction.Amount.Big()
 	ret.Pending = transaction.Pending
-	ret.Fee = transaction.Fee
+	ret.Fee = transaction.Fee.Big()
+	ret.Status = getTxStatus(transaction)
+	ret.FirstSeen = transaction.FirstSeen
+	ret.LeftPoolTime = transaction.LeftPoolTime
+	ret.InclusionTime = transaction.InclusionTime
 	timeStamp := big.NewInt(0)
 	if timeStamp.UnmarshalText([]byte(transaction.Timestamp)) == nil {
 		ret.Age = getElpasedTimeDesc(timeStamp)
@@ -171,16 +215,17 @@ func createRetSimpleTxInfo(transaction *database.DBTx) *RetSimpleTxInfo {
 	return &ret
 }
 
-func createRetDetailTxInfo(transaction *database.DBTx) *RetDetailTxInfo {
+//createRetDetailTxInfo converts the given dbtx and its receipt to the retdetailtxinfo, the receipt could be nil
+func createRetDetailTxInfo(transaction *database.DBTx, receipt *database.DBReceipt) *RetDetailTxInfo {
 	var ret RetDetailTxInfo
 	ret.TxType = transaction.TxType
 	ret.TxHash = transaction.Hash
 	ret.Block, _ = strconv.ParseUint(transaction.Block, 10, 64)
 	ret.From = transaction.From
 	ret.To = transaction.To
-	ret.Value = transaction.Amount
+	ret.Value = transaction.Amount.Big()
 	ret.Pending = transaction.Pending
-	ret.Fee = transaction.Fee
+	ret.Fee = transaction.Fee.Big()
 	timeStamp := big.NewInt(0)
 	if timeStamp.UnmarshalText([]byte(transaction.Timestamp)) == nil {
 		ret.Age = getElpasedTimeDesc(timeStamp)
@@ -188,35 +233,61 @@ func createRetDetailTxInfo(transaction *database.DBTx) *RetDetailTxInfo {
 	ret.ShardNumber = transaction.ShardNumber
 	ret.AccountNonce = transaction.AccountNonce
 	ret.Payload = transaction.Payload
+	ret.Status = getTxStatus(transaction)
+	ret.FirstSeen = transaction.FirstSeen
+	ret.LeftPoolTime = transaction.LeftPoolTime
+	ret.InclusionTime = transaction.InclusionTime
+
+	if receipt != nil {
+		ret.Result = receipt.Result
+		ret.PostState = receipt.PostState
+		ret.UsedGas = receipt.UsedGas
+		ret.TotalFee = receipt.TotalFee.Big()
+		ret.ContractAddress = receipt.ContractAddress
+	}
 
 	return &ret
 }
 
+//getTxStatus return whether the transaction is pending, dropped, succeeded or failed
+func getTxStatus(transaction *database.DBTx) string {
+	switch {
+	case transaction.PoolState == database.PoolStateDropped:
+		return txStatusDropped
+	case transaction.Pending:
+		return txStatusPending
+	case transaction.Failed:
+		return txStatusFailed
+	default:
+		return txStatusSuccess
+	}
+}
+
 //createRetSimpleAccountInfo converts the given dbaccount to the retsimpleaccountinfo
-func createRetSimpleAccountInfo(account *database.DBAccount, ttBalance int64) *RetSimpleAccountInfo {
+func createRetSimpleAccountInfo(account *database.DBAccount, ttBalance *big.Int) *RetSimpleAccountInfo {
 	var ret RetSimpleAccountInfo
 	ret.AccType = account.AccType
 	ret.Address = account.Address
-	ret.Balance = account.Balance
+	ret.Balance = account.Balance.Big()
 	ret.TxCount = account.TxCount
-	ret.Percentage = (float64(ret.Balance) / float64(ttBalance))
+	ret.Percentage = percentage(ret.Balance, ttBalance)
 	ret.ShardNumber = account.ShardNumber
 	return &ret
 }
 
 //createRetDetailAccountInfo converts the given dbaccount to the tetdetailaccountInfo
-func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBTx, ttBalance int64) *RetDetailAccountInfo {
+func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBTx, ttBalance *big.Int) *RetDetailAccountInfo {
 	var ret RetDetailAccountInfo
 	ret.AccType = account.AccType
 	ret.Address = account.Address
-	ret.Balance = account.Balance
+	ret.Balance = account.Balance.Big()
 	ret.TxCount = account.TxCount
-	ret.Percentage = (float64(ret.Balance) / float64(ttBalance))
+	ret.Percentage = percentage(ret.Balance, ttBalance)
 
 	for i := 0; i < len(txs); i++ {
 		var tx RetDetailAccountTxInfo
 		tx.TxType = txs[i].TxType
-		tx.Value = txs[i].Amount
+		tx.Value = txs[i].Amount.Big()
 		tx.Block = txs[i].Block
 		tx.From = txs[i].From
 		tx.Hash = txs[i].Hash
@@ -232,8 +303,9 @@ func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBT
 			tx.Age = getElpasedTimeDesc(timeStamp)
 		}
 
-		tx.Fee = txs[i].Fee
+		tx.Fee = txs[i].Fee.Big()
 		tx.Pending = txs[i].Pending
+		tx.Status = getTxStatus(txs[i])
 		ret.Txs = append(ret.Txs, tx)
 
 		if txs[i].TxType == 1 {
@@ -245,6 +317,79 @@ func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBT
 	return &ret
 }
 
+//createRetAccountTxInfo converts the given dbtx to the retdetailaccounttxinfo in the tx list of the address
+func createRetAccountTxInfo(transaction *database.DBTx, address string) *RetDetailAccountTxInfo {
+	timeStamp := big.NewInt(0)
+	var age string
+	if timeStamp.UnmarshalText([]byte(transaction.Timestamp)) == nil {
+		age = getElpasedTimeDesc(timeStamp.Div(timeStamp, big.NewInt(1e9)))
+	}
+
+	return &RetDetailAccountTxInfo{
+		ShardNumber: transaction.ShardNumber,
+		TxType:      transaction.TxType,
+		Hash:        transaction.Hash,
+		Block:       transaction.Block,
+		From:        transaction.From,
+		To:          transaction.To,
+		Value:       transaction.Amount.Big(),
+		Age:         age,
+		Fee:         transaction.Fee.Big(),
+		InOrOut:     transaction.To == address,
+		Pending:     transaction.Pending,
+		Status:      getTxStatus(transaction),
+	}
+}
+
+//createRetAddressTxInfo converts the given dbaddresstx to the retdetailaccounttxinfo in the tx history of its address
+func createRetAddressTxInfo(entry *database.DBAddressTx) *RetDetailAccountTxInfo {
+	tx := createRetAccountTxInfo(&entry.DBTx, entry.Address)
+	tx.Direction = entry.Direction
+	return tx
+}
+
+//addressTxs return the transactions of the tx history entries
+func addressTxs(entries []*database.DBAddressTx) []*database.DBTx {
+	txs := make([]*database.DBTx, 0, len(entries))
+	for _, entry := range entries {
+		txs = append(txs, &entry.DBTx)
+	}
+	return txs
+}
+
+//createRetReorgInfo converts the given dbreorg to the retreorginfo
+func createRetReorgInfo(reorg *database.DBReorg) *RetReorgInfo {
+	return &RetReorgInfo{
+		ShardNumber: reorg.ShardNumber,
+		Depth:       reorg.Depth,
+		ForkHeight:  reorg.ForkHeight,
+		ForkHash:    reorg.ForkHash,
+		OldHashes:   reorg.OldHashes,
+		NewHashes:   reorg.NewHashes,
+		Age:         getElpasedTimeDesc(big.NewInt(reorg.Timestamp)),
+	}
+}
+
+//createRetBalanceChange converts the given dbbalancechange to the retbalancechange
+func createRetBalanceChange(change *database.DBBalanceChange) *RetBalanceChange {
+	return &RetBalanceChange{
+		Height:    change.Height,
+		Timestamp: change.Timestamp,
+		Balance:   change.Balance.Big(),
+		Delta:     change.Delta.Big(),
+	}
+}
+
+//percentage return the ratio of the balance to the total balance
+func percentage(balance, ttBalance *big.Int) float64 {
+	if ttBalance == nil || ttBalance.Sign() == 0 {
+		return 0
+	}
+
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(balance), new(big.Float).SetInt(ttBalance)).Float64()
+	return ratio
+}
+
 //getElpasedTimeDesc Get the elapsed time from then until now
 func getElpasedTimeDesc(t *big.Int) string {
 	curTimeStamp := time.Now().Unix()
@@ -283,7 +428,7 @@ type RetOneDayTxInfo struct {
 	HashRate      float64
 	Difficulty    float64
 	AvgTime       float64
-	Rewards       int64
+	Rewards       *big.Int
 	TotalAddresss int64
 	TodayIncrease int64
 	TimeStamp     int64
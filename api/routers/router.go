@@ -49,10 +49,12 @@ func (r *Router) Init(e *gin.Engine) {
 	v1.GET("/txs", r.BlockHandler.GetTxs())
 	v1.GET("/pendingtxs", r.BlockHandler.GetPendingTxs())
 	v1.GET("/tx", r.BlockHandler.GetTxByHash())
+	v1.GET("/reorgs", r.BlockHandler.GetReorgs())
 	//ugly fix this
 	v1.GET("/search", r.BlockHandler.Search(r.AccountHandler, r.ContractHandler))
 	v1.GET("/accounts", r.AccountHandler.GetAccounts())
 	v1.GET("/account", r.AccountHandler.GetAccountByAddress())
+	v1.GET("/account/balance", r.AccountHandler.GetAccountBalance())
 	v1.GET("/contracts", r.ContractHandler.GetContracts())
 	v1.GET("/contract", r.ContractHandler.GetContractByAddress())
 	//v1.GET("/difficulty", r.BlockHandler.GetDifficulty())
@@ -71,6 +73,7 @@ func (r *Router) Init(e *gin.Engine) {
 	chartGrp.GET("/blocktime", r.ChartHandler.GetEveryDayBlockTime())
 	chartGrp.GET("/miner", r.ChartHandler.GetTopMiners())
 	chartGrp.GET("/node", r.NodeHandler.GetNodeCntChart())
+	chartGrp.GET("/balance", r.AccountHandler.GetBalanceHistory())
 
 	go r.AccountHandler.Update()
 	go r.ContractHandler.Update()
@@ -141,4 +141,5 @@ func ProcessOneDayAddresses(shardNumber int, day time.Time) bool {
 
 func init() {
 	chart.RegisterProcessFunc(Process)
+	chart.RegisterDayFunc(ProcessOneDayAddresses)
 }
@@ -82,7 +82,7 @@ func ProcessOneDayBlocks(shardNumber int, day time.Time) bool {
 		txLen := len(dbBlocks[i].Txs)
 		if txLen > 0 {
 			tx := dbBlocks[i].Txs[txLen-1]
-			info.Rewards += tx.Amount
+			info.Rewards.Add(&info.Rewards.Int, &tx.Amount.Int)
 		}
 	}
 
@@ -93,4 +93,5 @@ func ProcessOneDayBlocks(shardNumber int, day time.Time) bool {
 
 func init() {
 	chart.RegisterProcessFunc(Process)
+	chart.RegisterDayFunc(ProcessOneDayBlocks)
 }
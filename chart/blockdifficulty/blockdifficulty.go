@@ -97,4 +97,5 @@ func ProcessOneDayBlockDifficulty(shardNumber int, day time.Time) bool {
 
 func init() {
 	chart.RegisterProcessFunc(Process)
+	chart.RegisterDayFunc(ProcessOneDayBlockDifficulty)
 }
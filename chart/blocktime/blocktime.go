@@ -92,4 +92,5 @@ func ProcessOneDayBlockAvgTime(shardNumber int, day time.Time) bool {
 
 func init() {
 	chart.RegisterProcessFunc(Process)
+	chart.RegisterDayFunc(ProcessOneDayBlockAvgTime)
 }
@@ -5,7 +5,10 @@
 
 package chart
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 //Config server config
 type Config struct {
@@ -21,9 +24,15 @@ type Config struct {
 //ProcessFunc ChartProcessFunc is entrance of the chart service needed to be start
 type ProcessFunc func(wg *sync.WaitGroup)
 
+//DayFunc count the one day chart of a shard of the day before day
+type DayFunc func(shardNumber int, day time.Time) bool
+
 var (
 	//ProcessFuncs chart processors
 	processFuncs []ProcessFunc
+
+	//dayFuncs one day chart processors, they are used to count the days again after a chain reorganization
+	dayFuncs []DayFunc
 )
 
 //RegisterProcessFunc register an process func into chart service
@@ -35,3 +44,8 @@ func RegisterProcessFunc(processFunc ProcessFunc) {
 func GetProcessFuncs() []ProcessFunc {
 	return processFuncs
 }
+
+//RegisterDayFunc register an one day chart process func, which counts the day again when it is reorganized
+func RegisterDayFunc(dayFunc DayFunc) {
+	dayFuncs = append(dayFuncs, dayFunc)
+}
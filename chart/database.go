@@ -22,6 +22,10 @@ type ChartDB interface {
 	AddTopMinerInfo(shardNumber int, rankInfo *database.DBMinerRankInfo) error
 	AddOneDayTransInfo(shardNumber int, t *database.DBOneDayTxInfo) error
 	GetOneDayTransInfo(shardNumber int, zeroTime int64) (*database.DBOneDayTxInfo, error)
+	GetSyncCursor(shardNumber int) (*database.DBSyncCursor, error)
+	GetChartRedo(shardNumber int) (*database.DBChartRedo, error)
+	RemoveChartRedo(redo *database.DBChartRedo) error
+	RemoveChartData(shardNumber int, beginTime int64) error
 }
 
 var (
@@ -97,4 +97,5 @@ func ProcessOneDayHashRate(shardNumber int, day time.Time) bool {
 
 func init() {
 	chart.RegisterProcessFunc(Process)
+	chart.RegisterDayFunc(ProcessOneDayHashRate)
 }
@@ -0,0 +1,64 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package chart
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seeleteam/scan-api/log"
+)
+
+const (
+	redoInterval = time.Minute
+)
+
+//ProcessRedo count the days marked by the syncer after a chain reorganization again
+func ProcessRedo(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		for i := 1; i <= ShardCount; i++ {
+			RedoDays(i, time.Now())
+		}
+		time.Sleep(redoInterval)
+	}
+}
+
+//RedoDays count the one day charts of the shard again from the marked day to the day before now. It waits
+//until the shard is synced to the marked height, so the days are counted with the blocks of the new branch
+func RedoDays(shardNumber int, now time.Time) bool {
+	redo, err := GChartDB.GetChartRedo(shardNumber)
+	if err != nil || redo == nil {
+		return false
+	}
+
+	cursor, err := GChartDB.GetSyncCursor(shardNumber)
+	if err != nil || cursor == nil || cursor.Height < redo.Height {
+		return false
+	}
+
+	if err := GChartDB.RemoveChartData(shardNumber, redo.ZeroTime); err != nil {
+		log.Error("[Chart] could not remove the charts of shard %d, %v", shardNumber, err)
+		return false
+	}
+
+	todayZeroTime := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for day := time.Unix(redo.ZeroTime, 0).Add(time.Hour * 24); !day.After(todayZeroTime); day = day.Add(time.Hour * 24) {
+		for _, dayFunc := range dayFuncs {
+			dayFunc(shardNumber, day)
+		}
+	}
+
+	log.Info("[Chart] counted the charts of shard %d since %d again", shardNumber, redo.ZeroTime)
+	if err := GChartDB.RemoveChartRedo(redo); err != nil {
+		log.Error(err)
+	}
+	return true
+}
+
+func init() {
+	RegisterProcessFunc(ProcessRedo)
+}
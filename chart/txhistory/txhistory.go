@@ -86,4 +86,5 @@ func ProcessOneDayTransaction(shardNumber int, day time.Time) bool {
 
 func init() {
 	chart.RegisterProcessFunc(Process)
+	chart.RegisterDayFunc(ProcessOneDayTransaction)
 }
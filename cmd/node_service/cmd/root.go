@@ -33,7 +33,7 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
-		dbClient := database.NewDBClient(config.DataBaseName, config.DataBaseConnURL, 1)
+		dbClient := database.NewDBClient(config.DataBaseName, config.DataBaseConnURL)
 		if dbClient == nil {
 			fmt.Printf("init database error")
 			return
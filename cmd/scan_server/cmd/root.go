@@ -38,7 +38,7 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
-		dbClient := database.NewDBClient(serverCfg.DataBaseName, serverCfg.DataBaseConnURL, 1)
+		dbClient := database.NewDBClient(serverCfg.DataBaseName, serverCfg.DataBaseConnURL)
 		if dbClient == nil {
 			fmt.Printf("init database error")
 			return
@@ -0,0 +1,30 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/seeleteam/scan-api/simulator"
+)
+
+var (
+	configFile *string
+)
+
+// LoadConfigFromFile unmarshal config from a file
+func LoadConfigFromFile(filepath string) (simulator.Config, error) {
+	var config simulator.Config
+	buff, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(buff, &config)
+
+	return config, err
+}
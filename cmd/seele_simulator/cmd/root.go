@@ -0,0 +1,78 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/simulator"
+
+	"github.com/spf13/cobra"
+)
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   "simulator command ",
+	Short: "start a simulated seele node",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := LoadConfigFromFile(*configFile)
+		if err != nil {
+			fmt.Printf("read config file failed %s", err.Error())
+			return
+		}
+
+		if log.NewLogger(config.LogFile, config.LogLevel, config.WriteLog) == nil {
+			fmt.Println("Log init failed")
+			return
+		}
+
+		node, err := simulator.NewNodeFromConfig(&config)
+		if err != nil {
+			fmt.Printf("init simulator failed %s", err.Error())
+			return
+		}
+
+		l, err := net.Listen("tcp", config.TCPAddr)
+		if err != nil {
+			fmt.Printf("listen %s failed %s", config.TCPAddr, err.Error())
+			return
+		}
+		go node.Serve(l)
+
+		if config.HTTPAddr != "" {
+			go func() {
+				if err := http.ListenAndServe(config.HTTPAddr, node); err != nil {
+					log.Error("[Simulator]http server stopped, %v", err)
+				}
+			}()
+		}
+
+		log.Info("[Simulator]serving shard %d at height %d on %s", config.Shard, node.Height(), config.TCPAddr)
+		var wg sync.WaitGroup
+		go node.Produce(&config, make(chan struct{}))
+		wg.Add(1)
+		wg.Wait()
+	},
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	configFile = rootCmd.Flags().StringP("config", "c", "", "config file (required)")
+	rootCmd.MarkFlagRequired("config")
+}
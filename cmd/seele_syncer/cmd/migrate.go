@@ -0,0 +1,56 @@
+/**
+*  @file
+*  @copyright defined in go-seele/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd creates the missing indexes and applies the pending schema migrations, migrate-amounts
+// is kept as an alias since the amounts are converted by the first migration
+var migrateCmd = &cobra.Command{
+	Use:     "migrate",
+	Aliases: []string{"migrate-amounts"},
+	Short:   "create the missing indexes and migrate the database to the latest schema version",
+	Run: func(cmd *cobra.Command, args []string) {
+		serverCfg, err := LoadConfigFromFile(*serverConfigFile)
+		if err != nil {
+			fmt.Printf("read config file failed %s", err.Error())
+			return
+		}
+
+		if log.NewLogger(serverCfg.LogFile, serverCfg.LogLevel, serverCfg.WriteLog) == nil {
+			fmt.Println("Log init failed")
+			return
+		}
+
+		dbClient := database.NewDBClient(serverCfg.DataBaseName, serverCfg.DataBaseConnURL)
+		if dbClient == nil {
+			fmt.Printf("init database error")
+			return
+		}
+
+		if err := dbClient.EnsureIndexes(); err != nil {
+			fmt.Printf("create indexes failed %s", err.Error())
+			return
+		}
+
+		if err := dbClient.Migrate(); err != nil {
+			fmt.Printf("migrate failed %s, run migrate again to resume", err.Error())
+			return
+		}
+		fmt.Printf("migrate done, schema version %d\n", database.LatestSchemaVersion())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -8,7 +8,8 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"sync"
+	"os/signal"
+	"syscall"
 
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
@@ -26,7 +27,6 @@ var rootCmd = &cobra.Command{
 	Use:   "syncer command ",
 	Short: "start server",
 	Run: func(cmd *cobra.Command, args []string) {
-		var g sync.WaitGroup
 		serverCfg, err := LoadConfigFromFile(*serverConfigFile)
 		if err != nil {
 			fmt.Printf("read config file failed %s", err.Error())
@@ -38,22 +38,46 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
-		dbClient := database.NewDBClient(serverCfg.DataBaseName, serverCfg.DataBaseConnURL, 1)
+		dbClient := database.NewDBClient(serverCfg.DataBaseName, serverCfg.DataBaseConnURL)
 		if dbClient == nil {
 			fmt.Printf("init database error")
 			return
 		}
 
-		syncer := syncer.NewSyncer(dbClient, serverCfg.RpcURL, serverCfg.ShardNumber)
-		if syncer == nil {
-			fmt.Printf("can not connect to node")
+		if err := dbClient.EnsureIndexes(); err != nil {
+			fmt.Printf("create indexes failed %s", err.Error())
 			return
 		}
 
-		syncer.StartSync(serverCfg.SyncInterval)
-		g.Add(1)
-		g.Wait()
+		if err := dbClient.CheckSchemaVersion(); err != nil {
+			fmt.Printf("check schema version failed %s", err.Error())
+			return
+		}
+
+		group, err := syncer.NewGroup(dbClient, serverCfg.GetShards(),
+			syncer.WithFetchConcurrency(serverCfg.FetchConcurrency),
+			syncer.WithFetchWindow(serverCfg.FetchWindow))
+		if err != nil {
+			fmt.Printf("init syncer group failed %s", err.Error())
+			return
+		}
+
+		group.Start()
+
+		sig := make(chan os.Signal, 2)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			s := <-sig
+			log.Info("[SyncGroup]received %v, stop the syncers after the running syncs, signal again to exit immediately", s)
+			go group.Stop()
+
+			s = <-sig
+			log.Warn("[SyncGroup]received %v, exit", s)
+			os.Exit(1)
+		}()
 
+		group.Wait()
+		log.Info("[SyncGroup]all the syncers are stopped")
 	},
 }
 
@@ -67,6 +91,6 @@ func Execute() {
 }
 
 func init() {
-	serverConfigFile = rootCmd.Flags().StringP("config", "c", "", "server config file (required)")
-	rootCmd.MarkFlagRequired("config")
+	serverConfigFile = rootCmd.PersistentFlags().StringP("config", "c", "", "server config file (required)")
+	rootCmd.MarkPersistentFlagRequired("config")
 }
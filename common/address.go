@@ -0,0 +1,110 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/seeleteam/go-seele/common/hexutil"
+)
+
+const (
+	//AddressLen the number of bytes of a seele address
+	AddressLen = 20
+
+	//ShardCount the number of shards of seele
+	ShardCount = 20
+
+	//UndefinedShardNumber the shard of the empty address and the reserved addresses
+	UndefinedShardNumber = 0
+)
+
+//AddressType is the type of a seele address, which is the last 4 bits of the address
+type AddressType byte
+
+const (
+	//AddressTypeExternal the address of an account owned by a private key
+	AddressTypeExternal AddressType = 1
+	//AddressTypeContract the address of a contract
+	AddressTypeContract AddressType = 2
+	//AddressTypeReserved the address of a system contract
+	AddressTypeReserved AddressType = 3
+)
+
+var (
+	//ErrAddressEmpty is returned when no address is given
+	ErrAddressEmpty = errors.New("address is empty")
+)
+
+//Address is a seele address
+type Address [AddressLen]byte
+
+//EmptyAddress the address of the coinbase transactions
+var EmptyAddress = Address{}
+
+//ParseAddress check the format of the 0x prefixed hex address and return it
+func ParseAddress(hex string) (Address, error) {
+	var addr Address
+	if hex == "" {
+		return addr, ErrAddressEmpty
+	}
+
+	b, err := hexutil.HexToBytes(hex)
+	if err != nil {
+		return addr, fmt.Errorf("invalid address %s, %v", hex, err)
+	}
+
+	if len(b) != AddressLen {
+		return addr, fmt.Errorf("invalid address %s, want %d bytes but got %d", hex, AddressLen, len(b))
+	}
+
+	copy(addr[:], b)
+	return addr, nil
+}
+
+//Hex return the address in lower case hex with the 0x prefix, which is the way it is stored
+func (a Address) Hex() string {
+	return hexutil.BytesToHex(a[:])
+}
+
+func (a Address) String() string {
+	return a.Hex()
+}
+
+//IsEmpty return whether it is the empty address
+func (a Address) IsEmpty() bool {
+	return a == EmptyAddress
+}
+
+//Type return the type of the address
+func (a Address) Type() AddressType {
+	return AddressType(a[AddressLen-1] & 0x0f)
+}
+
+//Shard return the shard of the address, it is UndefinedShardNumber for the empty address and
+//the reserved addresses which exist in every shard
+func (a Address) Shard() int {
+	if a.IsEmpty() || a.Type() == AddressTypeReserved {
+		return UndefinedShardNumber
+	}
+
+	var sum uint
+	for _, b := range a[:18] {
+		sum += uint(b)
+	}
+
+	//the last 4 bits are the address type
+	sum += uint(binary.BigEndian.Uint16(a[18:]) >> 4)
+
+	return int(sum%ShardCount) + 1
+}
+
+//ValidShard return whether the shard number is in [1, ShardCount]
+func ValidShard(shardNumber int) bool {
+	return shardNumber >= 1 && shardNumber <= ShardCount
+}
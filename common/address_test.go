@@ -0,0 +1,51 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package common
+
+import (
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	addr, err := ParseAddress("0x4C10F2cd2159bb432094e3be7e17904c2b4aeb21")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr.Hex() != "0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21" {
+		t.Fatalf("bad normalized address %s", addr.Hex())
+	}
+
+	if addr.Type() != AddressTypeExternal || addr.Shard() != 1 {
+		t.Fatalf("bad address type %d or shard %d", addr.Type(), addr.Shard())
+	}
+
+	for _, hex := range []string{"", "4c10f2cd2159bb432094e3be7e17904c2b4aeb21", "0x4c10f2cd2159bb432094e3be7e17904c2b4aeb", "0x4c10f2cd2159bb432094e3be7e17904c2b4aebzz"} {
+		if _, err := ParseAddress(hex); err == nil {
+			t.Fatalf("expected the error of the malformed address %s", hex)
+		}
+	}
+}
+
+func TestAddressShard(t *testing.T) {
+	cases := map[string]int{
+		"0x2a87b6504cd00af95a83b9887112016a2a991cf1": 1,
+		"0x91dbd8fd6b2f6e6a1e4bd40eec8bc01ac6a98a51": 8,
+		"0x0000000000000000000000000000000000000000": UndefinedShardNumber,
+		"0x0000000000000000000000000000000000000103": UndefinedShardNumber,
+	}
+
+	for hex, shardNumber := range cases {
+		addr, err := ParseAddress(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if addr.Shard() != shardNumber {
+			t.Fatalf("bad shard %d of %s, want %d", addr.Shard(), hex, shardNumber)
+		}
+	}
+}
@@ -0,0 +1,514 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"strconv"
+
+	"github.com/seeleteam/scan-api/log"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	nullAddress = "0x0000000000000000000000000000000000000000"
+
+	//number of entries written by one bulk operation when the tx history is built
+	addressTxBatch = 1000
+)
+
+//Add add the counters of another count to the count
+func (c *DBAddressTxCount) Add(other *DBAddressTxCount) {
+	c.Txs += other.Txs
+	c.In += other.In
+	c.Out += other.Out
+	c.Created += other.Created
+	c.Failed += other.Failed
+}
+
+//Neg return a count reverting the counters of the count
+func (c *DBAddressTxCount) Neg() *DBAddressTxCount {
+	return &DBAddressTxCount{
+		Address:     c.Address,
+		ShardNumber: c.ShardNumber,
+		Height:      c.Height,
+		Txs:         -c.Txs,
+		In:          -c.In,
+		Out:         -c.Out,
+		Created:     -c.Created,
+		Failed:      -c.Failed,
+	}
+}
+
+//Of return the counter of the txs in the direction, the counter of all txs if direction is empty
+func (c *DBAddressTxCount) Of(direction string) int64 {
+	switch direction {
+	case DirectionIn:
+		return c.In
+	case DirectionOut:
+		return c.Out
+	case DirectionCreate:
+		return c.Created
+	}
+	return c.Txs
+}
+
+//CountAddressTxs count the entries of the tx history written by the block at height, grouped by address
+func CountAddressTxs(shardNumber int, height int64, txs []*DBAddressTx) []*DBAddressTxCount {
+	var counts []*DBAddressTxCount
+	byAddress := make(map[string]*DBAddressTxCount)
+	for _, tx := range txs {
+		count, ok := byAddress[tx.Address]
+		if !ok {
+			count = &DBAddressTxCount{Address: tx.Address, ShardNumber: shardNumber, Height: height}
+			byAddress[tx.Address] = count
+			counts = append(counts, count)
+		}
+
+		count.Txs++
+		switch tx.Direction {
+		case DirectionIn:
+			count.In++
+		case DirectionOut, DirectionSelf:
+			if tx.Direction == DirectionSelf {
+				count.In++
+			}
+			count.Out++
+			if tx.Failed {
+				count.Failed++
+			}
+		case DirectionCreate:
+			count.Created++
+		}
+	}
+	return counts
+}
+
+//addressTxFilter return the query of the entries selected by the filter
+func addressTxFilter(filter *AddressTxFilter) bson.M {
+	query := bson.M{"address": filter.Address, "pending": filter.Pending}
+	switch filter.Direction {
+	case "":
+	case DirectionIn, DirectionOut:
+		query["direction"] = bson.M{"$in": []string{filter.Direction, DirectionSelf}}
+	default:
+		query["direction"] = filter.Direction
+	}
+	return query
+}
+
+//AddAddressTxs write the tx history entries of the mined txs in the block at height and count them,
+//the entries of the same address and tx are replaced, and the counters which already count the block are left unchanged
+func (c *Client) AddAddressTxs(shardNumber int, height int64, txs []*DBAddressTx) error {
+	if err := c.upsertAddressTxs(txs); err != nil {
+		return err
+	}
+
+	for _, count := range CountAddressTxs(shardNumber, height, txs) {
+		if err := c.applyAddressTxCount(count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//upsertAddressTxs write the entries with bulk operations, the entries of the same address and tx are replaced
+func (c *Client) upsertAddressTxs(txs []*DBAddressTx) error {
+	for begin := 0; begin < len(txs); begin += addressTxBatch {
+		end := begin + addressTxBatch
+		if end > len(txs) {
+			end = len(txs)
+		}
+
+		query := func(c *mgo.Collection) error {
+			bulk := c.Bulk()
+			bulk.Unordered()
+			for _, tx := range txs[begin:end] {
+				bulk.Upsert(bson.M{"address": tx.Address, "hash": tx.Hash}, tx)
+			}
+			_, err := bulk.Run()
+			return err
+		}
+		if err := c.withCollection(addressTxTbl, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//applyAddressTxCount add the count of the block at its height to the counters of the address in the shard
+func (c *Client) applyAddressTxCount(count *DBAddressTxCount) error {
+	query := func(c *mgo.Collection) error {
+		err := c.Update(bson.M{"address": count.Address, "shardNumber": count.ShardNumber, "height": bson.M{"$lt": count.Height}},
+			addressTxCountUpdate(count.Height, count))
+		if err != mgo.ErrNotFound {
+			return err
+		}
+
+		cnt, err := c.Find(bson.M{"address": count.Address, "shardNumber": count.ShardNumber}).Count()
+		if err != nil || cnt > 0 {
+			return err
+		}
+		return c.Insert(count)
+	}
+	err := c.withCollection(addressTxCountTbl, query)
+	return err
+}
+
+//addressTxCountUpdate return the update adding the count to the counters and moving them to height
+func addressTxCountUpdate(height int64, count *DBAddressTxCount) bson.M {
+	return bson.M{
+		"$inc": bson.M{
+			"txs":     count.Txs,
+			"in":      count.In,
+			"out":     count.Out,
+			"created": count.Created,
+			"failed":  count.Failed,
+		},
+		"$set": bson.M{"height": height},
+	}
+}
+
+//RemoveAddressTxs subtract the tx history entries of the block from the counters and remove them,
+//the counters which do not count the block are left unchanged
+func (c *Client) RemoveAddressTxs(shardNumber int, height uint64) error {
+	filter := bson.M{"shardNumber": shardNumber, "block": strconv.FormatUint(height, 10), "pending": false}
+
+	var txs []*DBAddressTx
+	find := func(c *mgo.Collection) error {
+		return c.Find(filter).All(&txs)
+	}
+	if err := c.withCollection(addressTxTbl, find); err != nil {
+		return err
+	}
+
+	for _, count := range CountAddressTxs(shardNumber, int64(height), txs) {
+		revert := func(c *mgo.Collection) error {
+			err := c.Update(bson.M{"address": count.Address, "shardNumber": shardNumber, "height": bson.M{"$gte": count.Height}},
+				addressTxCountUpdate(count.Height-1, count.Neg()))
+			if err == mgo.ErrNotFound {
+				return nil
+			}
+			return err
+		}
+		if err := c.withCollection(addressTxCountTbl, revert); err != nil {
+			return err
+		}
+	}
+
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(filter)
+		return err
+	}
+	return c.withCollection(addressTxTbl, query)
+}
+
+//AddPendingAddressTxs write the tx history entries of a tx in the tx pool, they are not counted
+//and an entry of the mined tx is left unchanged
+func (c *Client) AddPendingAddressTxs(txs []*DBAddressTx) error {
+	query := func(c *mgo.Collection) error {
+		for _, tx := range txs {
+			if _, err := c.Upsert(bson.M{"address": tx.Address, "hash": tx.Hash}, bson.M{"$setOnInsert": tx}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := c.withCollection(addressTxTbl, query)
+	return err
+}
+
+//RemovePendingAddressTxs remove the tx history entries of a tx which left the tx pool
+func (c *Client) RemovePendingAddressTxs(hash string) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{"hash": hash, "pending": true})
+		return err
+	}
+	err := c.withCollection(addressTxTbl, query)
+	return err
+}
+
+//GetAddressTxs get the tx history entries selected by the filter, the latest comes first
+func (c *Client) GetAddressTxs(filter *AddressTxFilter, skip, limit int) ([]*DBAddressTx, error) {
+	var txs []*DBAddressTx
+	query := func(c *mgo.Collection) error {
+		return c.Find(addressTxFilter(filter)).Sort("-time", "-hash").Skip(skip).Limit(limit).All(&txs)
+	}
+	err := c.withCollection(addressTxTbl, query)
+	return txs, err
+}
+
+//GetAddressTxsPage get a page of the tx history entries selected by the filter next to the tx with the timestamp
+//and the hash, the latest comes first
+func (c *Client) GetAddressTxsPage(filter *AddressTxFilter, timestamp int64, hash string, older bool, limit int) ([]*DBAddressTx, error) {
+	var txs []*DBAddressTx
+	page, sort := pageFilter(addressTxFilter(filter), "time", timestamp, "hash", hash, older)
+	query := func(c *mgo.Collection) error {
+		return c.Find(page).Sort(sort...).Limit(limit).All(&txs)
+	}
+	err := c.withCollection(addressTxTbl, query)
+
+	if !older {
+		reverseAddressTxs(txs)
+	}
+	return txs, err
+}
+
+//GetAddressTxCount get the counters of the tx history of the address, the counters of all shards are added up
+func (c *Client) GetAddressTxCount(address string) (*DBAddressTxCount, error) {
+	var counts []*DBAddressTxCount
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"address": address}).All(&counts)
+	}
+	err := c.withCollection(addressTxCountTbl, query)
+
+	total := &DBAddressTxCount{Address: address}
+	for _, count := range counts {
+		total.Add(count)
+	}
+	return total, err
+}
+
+//migrateAddressTxs build the tx history of the addresses from the stored txs, their receipts and the txs
+//in the tx pool, and count it. Existing entries are replaced and the counters are recounted, so it can run again
+func (c *Client) migrateAddressTxs() error {
+	contracts := make(map[string]string)
+	findContracts := func(c *mgo.Collection) error {
+		var receipt DBReceipt
+		iter := c.Find(bson.M{"contractAddress": bson.M{"$nin": []interface{}{"", nil}}}).Select(bson.M{"txHash": 1, "contractAddress": 1}).Iter()
+		for iter.Next(&receipt) {
+			contracts[receipt.TxHash] = receipt.ContractAddress
+		}
+		return iter.Close()
+	}
+	if err := c.withCollection(receiptTbl, findContracts); err != nil {
+		return err
+	}
+
+	var entries []*DBAddressTx
+	total := 0
+	flush := func() error {
+		err := c.upsertAddressTxs(entries)
+		total += len(entries)
+		entries = entries[:0]
+		return err
+	}
+
+	var iterErr error
+	buildEntries := func(c *mgo.Collection) error {
+		var tx DBTx
+		iter := c.Find(nil).Iter()
+		for iter.Next(&tx) {
+			tx.Pending = false
+			entries = append(entries, CreateDbAddressTxs(&tx, contracts[tx.Hash])...)
+			if len(entries) >= addressTxBatch {
+				if iterErr = flush(); iterErr != nil {
+					break
+				}
+			}
+			tx = DBTx{}
+		}
+		return iter.Close()
+	}
+	if err := c.withCollection(txTbl, buildEntries); err != nil {
+		return err
+	}
+	if iterErr != nil {
+		return iterErr
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	log.Info("[DB] wrote %d entries of the tx history", total)
+
+	var poolTxs []*DBTx
+	findPoolTxs := func(c *mgo.Collection) error {
+		return c.Find(pendingTxFilter(bson.M{})).All(&poolTxs)
+	}
+	if err := c.withCollection(pendingTxTbl, findPoolTxs); err != nil {
+		return err
+	}
+	for _, tx := range poolTxs {
+		tx.Pending = true
+		if err := c.AddPendingAddressTxs(CreateDbAddressTxs(tx, "")); err != nil {
+			return err
+		}
+	}
+
+	return c.recountAddressTxs()
+}
+
+//migrateAddressTxTime set the numeric timestamp of the tx history entries written before it existed,
+//and drop the indexes which ordered the entries by the text of the timestamp
+func (c *Client) migrateAddressTxTime() error {
+	total := 0
+	setTime := func(c *mgo.Collection) error {
+		var entry struct {
+			ID        interface{} `bson:"_id"`
+			Timestamp string      `bson:"timestamp"`
+		}
+
+		bulk, queued := c.Bulk(), 0
+		iter := c.Find(bson.M{"time": bson.M{"$exists": false}}).Select(bson.M{"timestamp": 1}).Iter()
+		for iter.Next(&entry) {
+			timestamp, _ := strconv.ParseInt(entry.Timestamp, 10, 64)
+			bulk.Update(bson.M{"_id": entry.ID}, bson.M{"$set": bson.M{"time": timestamp}})
+			if queued++; queued >= addressTxBatch {
+				if _, err := bulk.Run(); err != nil {
+					iter.Close()
+					return err
+				}
+				total += queued
+				bulk, queued = c.Bulk(), 0
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return err
+		}
+
+		if queued > 0 {
+			if _, err := bulk.Run(); err != nil {
+				return err
+			}
+			total += queued
+		}
+		return nil
+	}
+	if err := c.withCollection(addressTxTbl, setTime); err != nil {
+		return err
+	}
+	log.Info("[DB] set the time of %d entries of the tx history", total)
+
+	dropIndexes := func(c *mgo.Collection) error {
+		indexes, err := c.Indexes()
+		if err != nil {
+			return err
+		}
+
+		for _, index := range indexes {
+			for _, key := range index.Key {
+				if key != "-timestamp" {
+					continue
+				}
+
+				if err := c.DropIndexName(index.Name); err != nil {
+					return err
+				}
+				log.Info("[DB] dropped the index %s of %s", index.Name, addressTxTbl)
+				break
+			}
+		}
+		return nil
+	}
+	return c.withCollection(addressTxTbl, dropIndexes)
+}
+
+//recountAddressTxs count the mined tx history entries of every address in every shard with the
+//aggregation framework and replace the maintained counters
+func (c *Client) recountAddressTxs() error {
+	stats, err := c.GetStats()
+	if err != nil {
+		return err
+	}
+	heights := make(map[int]int64)
+	for _, s := range stats {
+		heights[s.ShardNumber] = s.Height
+	}
+
+	is := func(directions ...string) bson.M {
+		var cond []bson.M
+		for _, direction := range directions {
+			cond = append(cond, bson.M{"$eq": []interface{}{"$direction", direction}})
+		}
+		return bson.M{"$cond": []interface{}{bson.M{"$or": cond}, 1, 0}}
+	}
+
+	var counts []*DBAddressTxCount
+	total := 0
+	flush := func() error {
+		query := func(c *mgo.Collection) error {
+			bulk := c.Bulk()
+			bulk.Unordered()
+			for _, count := range counts {
+				bulk.Insert(count)
+			}
+			_, err := bulk.Run()
+			return err
+		}
+		total += len(counts)
+		err := c.withCollection(addressTxCountTbl, query)
+		counts = counts[:0]
+		return err
+	}
+
+	removeCounts := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(nil)
+		return err
+	}
+	if err := c.withCollection(addressTxCountTbl, removeCounts); err != nil {
+		return err
+	}
+
+	var flushErr error
+	countEntries := func(c *mgo.Collection) error {
+		var item struct {
+			ID struct {
+				Address     string `bson:"address"`
+				ShardNumber int    `bson:"shardNumber"`
+			} `bson:"_id"`
+			Txs     int64 `bson:"txs"`
+			In      int64 `bson:"in"`
+			Out     int64 `bson:"out"`
+			Created int64 `bson:"created"`
+			Failed  int64 `bson:"failed"`
+		}
+		iter := c.Pipe([]bson.M{
+			{"$match": bson.M{"pending": false}},
+			{"$group": bson.M{
+				"_id":     bson.M{"address": "$address", "shardNumber": "$shardNumber"},
+				"txs":     bson.M{"$sum": 1},
+				"in":      bson.M{"$sum": is(DirectionIn, DirectionSelf)},
+				"out":     bson.M{"$sum": is(DirectionOut, DirectionSelf)},
+				"created": bson.M{"$sum": is(DirectionCreate)},
+				"failed": bson.M{"$sum": bson.M{"$cond": []interface{}{
+					bson.M{"$and": []interface{}{"$failed", bson.M{"$ne": []interface{}{"$direction", DirectionIn}}, bson.M{"$ne": []interface{}{"$direction", DirectionCreate}}}}, 1, 0}}},
+			}},
+		}).AllowDiskUse().Iter()
+		for iter.Next(&item) {
+			counts = append(counts, &DBAddressTxCount{
+				Address:     item.ID.Address,
+				ShardNumber: item.ID.ShardNumber,
+				Height:      heights[item.ID.ShardNumber],
+				Txs:         item.Txs,
+				In:          item.In,
+				Out:         item.Out,
+				Created:     item.Created,
+				Failed:      item.Failed,
+			})
+			if len(counts) >= addressTxBatch {
+				if flushErr = flush(); flushErr != nil {
+					break
+				}
+			}
+		}
+		return iter.Close()
+	}
+	if err := c.withCollection(addressTxTbl, countEntries); err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return flushErr
+	}
+	if len(counts) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	log.Info("[DB] counted the tx history of %d addresses", total)
+	return nil
+}
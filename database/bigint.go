@@ -0,0 +1,88 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"fmt"
+	"math/big"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+//BigInt is an arbitrary-precision amount, it is stored as Decimal128 in mongo.
+//Amounts written as int64, double or decimal string before are still readable
+type BigInt struct {
+	big.Int
+}
+
+//NewBigInt return a BigInt with the value of x, nil is treated as zero
+func NewBigInt(x *big.Int) BigInt {
+	var b BigInt
+	if x != nil {
+		b.Set(x)
+	}
+	return b
+}
+
+//Big return a copy of the value as big.Int
+func (b *BigInt) Big() *big.Int {
+	return new(big.Int).Set(&b.Int)
+}
+
+//GetBSON implements bson.Getter
+func (b BigInt) GetBSON() (interface{}, error) {
+	d, err := bson.ParseDecimal128(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("amount %s is out of the range of decimal128", b.String())
+	}
+	return d, nil
+}
+
+//SetBSON implements bson.Setter
+func (b *BigInt) SetBSON(raw bson.Raw) error {
+	var v interface{}
+	if err := raw.Unmarshal(&v); err != nil {
+		return err
+	}
+
+	switch value := v.(type) {
+	case nil:
+		b.SetInt64(0)
+	case int:
+		b.SetInt64(int64(value))
+	case int64:
+		b.SetInt64(value)
+	case float64:
+		big.NewFloat(value).Int(&b.Int)
+	case bson.Decimal128:
+		return b.setString(value.String())
+	case string:
+		return b.setString(value)
+	default:
+		return fmt.Errorf("could not convert %T to amount", v)
+	}
+
+	return nil
+}
+
+//MarshalJSON encode the amount as a json number
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	return b.Int.MarshalJSON()
+}
+
+//setString parse an integer, or a decimal in exponent format such as 1.5E+20
+func (b *BigInt) setString(s string) error {
+	if _, ok := b.SetString(s, 10); ok {
+		return nil
+	}
+
+	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return fmt.Errorf("could not convert %q to amount", s)
+	}
+	f.Int(&b.Int)
+	return nil
+}
@@ -0,0 +1,62 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+package database
+
+import (
+	"math/big"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestBigIntRoundTrip(t *testing.T) {
+	amount, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	data, err := bson.Marshal(DBAccount{Address: "0x01", Balance: NewBigInt(amount)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw bson.M
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["balance"].(bson.Decimal128); !ok {
+		t.Errorf("balance stored as %T, want Decimal128", raw["balance"])
+	}
+
+	var account DBAccount
+	if err := bson.Unmarshal(data, &account); err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance.Cmp(amount) != 0 {
+		t.Errorf("balance %s, want %s", account.Balance.String(), amount)
+	}
+}
+
+func TestBigIntLegacyValues(t *testing.T) {
+	legacy := []interface{}{int64(1000), 1000, float64(1000), "1000"}
+	for _, v := range legacy {
+		data, err := bson.Marshal(bson.M{"balance": v})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var account DBAccount
+		if err := bson.Unmarshal(data, &account); err != nil {
+			t.Fatalf("decode %T: %v", v, err)
+		}
+		if account.Balance.Int64() != 1000 {
+			t.Errorf("decode %T got %s, want 1000", v, account.Balance.String())
+		}
+	}
+}
+
+func TestBigIntOutOfRange(t *testing.T) {
+	amount := new(big.Int).Exp(big.NewInt(10), big.NewInt(40), nil)
+	amount.Add(amount, big.NewInt(1))
+	if _, err := bson.Marshal(DBAccount{Balance: NewBigInt(amount)}); err == nil {
+		t.Error("amount with more than 34 digits should not be stored")
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"errors"
+	"math/big"
 	"strconv"
 
 	"github.com/seeleteam/scan-api/log"
@@ -11,10 +12,19 @@ import (
 )
 
 const (
-	blockTbl     = "block"
-	txTbl        = "transaction"
-	accTbl       = "account"
-	pendingTxTbl = "pendingtx"
+	blockTbl      = "block"
+	txTbl         = "transaction"
+	accTbl        = "account"
+	pendingTxTbl  = "pendingtx"
+	blockUndoTbl  = "block_undo"
+	reorgTbl      = "reorg"
+	syncCursorTbl = "sync_cursor"
+	receiptTbl    = "receipt"
+
+	balanceHistoryTbl = "balance_history"
+	statsTbl          = "stats"
+	addressTxTbl      = "address_txs"
+	addressTxCountTbl = "address_tx_counts"
 
 	chartTxTbl              = "chart_transhistory"
 	chartHashRateTbl        = "chart_hashrate"
@@ -24,8 +34,13 @@ const (
 	chartAddressTbl         = "chart_address"
 	chartSingleAddressTbl   = "chart_single_address"
 	chartTopMinerRankTbl    = "chart_topminer"
+	chartRedoTbl            = "chart_redo"
 
 	nodeInfoTbl = "nodeinfo"
+
+	//MaxReorgDepth the deepest chain reorganization the syncer rolls back, the undo journals
+	//of the blocks deeper than it are removed
+	MaxReorgDepth = 1000
 )
 
 var (
@@ -36,24 +51,22 @@ var (
 
 //Client warpper for mongodb interactive
 type Client struct {
-	mgo         *mgo.Session
-	dbName      string
-	connUrl     string
-	shardNumber int
+	mgo     *mgo.Session
+	dbName  string
+	connUrl string
 }
 
 //NewDBClient reuturn an DB client
-func NewDBClient(dbName, connUrl string, shardNumber int) *Client {
+func NewDBClient(dbName, connUrl string) *Client {
 	mgo := getSession(connUrl)
 	if mgo == nil {
 		return nil
 	}
 
 	return &Client{
-		mgo:         mgo,
-		dbName:      dbName,
-		connUrl:     connUrl,
-		shardNumber: shardNumber,
+		mgo:     mgo,
+		dbName:  dbName,
+		connUrl: connUrl,
 	}
 }
 
@@ -106,19 +119,21 @@ func (c *Client) dropCollection(tbl string) error {
 	return errDBConnect
 }
 
-//AddBlock insert a block into database
+//AddBlock insert a block into database, the block with the same hash is replaced
 func (c *Client) AddBlock(b *DBBlock) error {
 	query := func(c *mgo.Collection) error {
-		return c.Insert(b)
+		_, err := c.Upsert(bson.M{"headHash": b.HeadHash}, b)
+		return err
 	}
 	err := c.withCollection(blockTbl, query)
 	return err
 }
 
-//RemoveBlock test use  remove block by height from database
-func (c *Client) RemoveBlock(height uint64) error {
+//RemoveBlock remove block by height from database
+func (c *Client) RemoveBlock(shardNumber int, height uint64) error {
 	query := func(c *mgo.Collection) error {
-		return c.Remove(bson.M{"height": height})
+		_, err := c.RemoveAll(bson.M{"height": height, "shardNumber": shardNumber})
+		return err
 	}
 	err := c.withCollection(blockTbl, query)
 	return err
@@ -183,19 +198,21 @@ func (c *Client) GetBlockHeight(shardNumber int) (uint64, error) {
 	return blockCnt, err
 }
 
-//AddTx insert a transaction into mongo
+//AddTx insert a transaction into mongo, the transaction with the same hash is replaced
 func (c *Client) AddTx(tx *DBTx) error {
 	query := func(c *mgo.Collection) error {
-		return c.Insert(tx)
+		_, err := c.Upsert(bson.M{"hash": tx.Hash}, tx)
+		return err
 	}
 	err := c.withCollection(txTbl, query)
 	return err
 }
 
-//AddPendingTx insert a pending transaction into mongo
+//AddPendingTx insert a pending transaction into mongo, the transaction with the same hash is replaced
 func (c *Client) AddPendingTx(tx *DBTx) error {
 	query := func(c *mgo.Collection) error {
-		return c.Insert(tx)
+		_, err := c.Upsert(bson.M{"hash": tx.Hash}, tx)
+		return err
 	}
 	err := c.withCollection(pendingTxTbl, query)
 	return err
@@ -220,15 +237,115 @@ func (c *Client) removeTx(idx uint64) error {
 	return err
 }
 
-//RemoveTxs Txs by block height
-func (c *Client) RemoveTxs(blockHeight uint64) error {
+//RemoveTxs remove all txs in the block
+func (c *Client) RemoveTxs(shardNumber int, blockHeight uint64) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{"block": strconv.FormatUint(blockHeight, 10), "shardNumber": shardNumber})
+		return err
+	}
+	err := c.withCollection(txTbl, query)
+	return err
+}
+
+//GetTxsByBlock get all txs in the block
+func (c *Client) GetTxsByBlock(shardNumber int, blockHeight uint64) ([]*DBTx, error) {
+	var trans []*DBTx
 	query := func(c *mgo.Collection) error {
-		return c.Remove(bson.M{"block": strconv.FormatUint(blockHeight, 10)})
+		return c.Find(bson.M{"block": strconv.FormatUint(blockHeight, 10), "shardNumber": shardNumber}).Sort("idx").All(&trans)
 	}
 	err := c.withCollection(txTbl, query)
+	return trans, err
+}
+
+//AddReceipt insert a transaction receipt into mongo, the receipt of the same tx is replaced
+func (c *Client) AddReceipt(receipt *DBReceipt) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.Upsert(bson.M{"txHash": receipt.TxHash}, receipt)
+		return err
+	}
+	err := c.withCollection(receiptTbl, query)
 	return err
 }
 
+//GetReceiptByTxHash get the receipt of a transaction
+func (c *Client) GetReceiptByTxHash(txHash string) (*DBReceipt, error) {
+	receipt := new(DBReceipt)
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"txHash": txHash}).One(receipt)
+	}
+	err := c.withCollection(receiptTbl, query)
+	return receipt, err
+}
+
+//RemoveReceipts remove the receipts of all txs in the block
+func (c *Client) RemoveReceipts(shardNumber int, blockHeight uint64) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{"shardNumber": shardNumber, "blockHeight": blockHeight})
+		return err
+	}
+	err := c.withCollection(receiptTbl, query)
+	return err
+}
+
+//AddBalanceChange insert or replace the balance change of the account in the block
+func (c *Client) AddBalanceChange(change *DBBalanceChange) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.Upsert(bson.M{"address": change.Address, "height": change.Height}, change)
+		return err
+	}
+	err := c.withCollection(balanceHistoryTbl, query)
+	return err
+}
+
+//RemoveBalanceChanges remove the balance changes made by the block
+func (c *Client) RemoveBalanceChanges(shardNumber int, height uint64) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{"shardNumber": shardNumber, "height": height})
+		return err
+	}
+	err := c.withCollection(balanceHistoryTbl, query)
+	return err
+}
+
+//GetBalanceAtHeight get the last balance change of the account at or before the height,
+//it returns nil if the account had not been touched by then
+func (c *Client) GetBalanceAtHeight(address string, height uint64) (*DBBalanceChange, error) {
+	change := new(DBBalanceChange)
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"address": address, "height": bson.M{"$lte": height}}).Sort("-height").One(change)
+	}
+	err := c.withCollection(balanceHistoryTbl, query)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return change, err
+}
+
+//GetBalanceAtTime get the last balance change of the account at or before the timestamp,
+//it returns nil if the account had not been touched by then
+func (c *Client) GetBalanceAtTime(address string, timestamp int64) (*DBBalanceChange, error) {
+	change := new(DBBalanceChange)
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"address": address, "timestamp": bson.M{"$lte": timestamp}}).Sort("-height").One(change)
+	}
+	err := c.withCollection(balanceHistoryTbl, query)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return change, err
+}
+
+//GetBalanceHistory get the balance changes of the account between the begin and end timestamp (both included),
+//at most max changes are returned in height order
+func (c *Client) GetBalanceHistory(address string, begin, end int64, max int) ([]*DBBalanceChange, error) {
+	var changes []*DBBalanceChange
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"address": address, "timestamp": bson.M{"$gte": begin, "$lte": end}}).Sort("height").Limit(max).All(&changes)
+	}
+	err := c.withCollection(balanceHistoryTbl, query)
+	return changes, err
+}
+
 //GetTxByIdx get transaction from mongo by idx
 func (c *Client) GetTxByIdx(idx uint64) (*DBTx, error) {
 	tx := new(DBTx)
@@ -249,16 +366,62 @@ func (c *Client) GetTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*DBTx
 	return trans, err
 }
 
-//GetPendingTxsByIdx get a transaction list from mongo by time period
-func (c *Client) GetPendingTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*DBTx, error) {
+//GetPendingTxs get the txs still in the tx pool, the latest seen comes first
+func (c *Client) GetPendingTxs(shardNumber int, skip, limit int) ([]*DBTx, error) {
 	var trans []*DBTx
 	query := func(c *mgo.Collection) error {
-		return c.Find(bson.M{"shardNumber": shardNumber, "idx": bson.M{"$gte": begin, "$lt": end}}).Sort("-idx").All(&trans)
+		return c.Find(pendingTxFilter(bson.M{"shardNumber": shardNumber})).Sort("-firstSeen", "-idx").Skip(skip).Limit(limit).All(&trans)
+	}
+	err := c.withCollection(pendingTxTbl, query)
+	return trans, err
+}
+
+//GetAllPendingTxs get all the txs still in the tx pool of the shard
+func (c *Client) GetAllPendingTxs(shardNumber int) ([]*DBTx, error) {
+	var trans []*DBTx
+	query := func(c *mgo.Collection) error {
+		return c.Find(pendingTxFilter(bson.M{"shardNumber": shardNumber})).All(&trans)
 	}
 	err := c.withCollection(pendingTxTbl, query)
 	return trans, err
 }
 
+//UpdatePendingTxState move the pool tx to the given state, the tx already in that state is left unchanged
+func (c *Client) UpdatePendingTxState(hash string, state string, leftPoolTime, inclusionTime int64) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.UpdateAll(bson.M{"hash": hash, "poolState": bson.M{"$ne": state}},
+			bson.M{"$set": bson.M{
+				"poolState":     state,
+				"leftPoolTime":  leftPoolTime,
+				"inclusionTime": inclusionTime,
+			}})
+		return err
+	}
+	err := c.withCollection(pendingTxTbl, query)
+	return err
+}
+
+//RemovePoolTxsBefore remove the mined and dropped pool txs which left the pool before the given time
+func (c *Client) RemovePoolTxsBefore(shardNumber int, before int64) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{
+			"shardNumber":  shardNumber,
+			"poolState":    bson.M{"$in": []string{PoolStateMined, PoolStateDropped}},
+			"leftPoolTime": bson.M{"$lt": before},
+		})
+		return err
+	}
+	err := c.withCollection(pendingTxTbl, query)
+	return err
+}
+
+//pendingTxFilter add the condition of the txs still in the tx pool, txs written
+//before the pool state existed have no state and are treated as pending
+func pendingTxFilter(filter bson.M) bson.M {
+	filter["poolState"] = bson.M{"$nin": []string{PoolStateMined, PoolStateDropped}}
+	return filter
+}
+
 //GetTxByHash get transaction info by hash from mongo
 func (c *Client) GetTxByHash(hash string) (*DBTx, error) {
 	tx := new(DBTx)
@@ -269,7 +432,7 @@ func (c *Client) GetTxByHash(hash string) (*DBTx, error) {
 	return tx, err
 }
 
-//GetPendingTxByHash
+//GetPendingTxByHash get a tx seen in the tx pool by hash, it may have been mined or dropped
 func (c *Client) GetPendingTxByHash(hash string) (*DBTx, error) {
 	tx := new(DBTx)
 	query := func(c *mgo.Collection) error {
@@ -339,14 +502,14 @@ func (c *Client) GetContractCnt() (uint64, error) {
 	return txCnt, err
 }
 
-//GetAccountCntByShardNumber get contract count
+//GetAccountCntByShardNumber get account count
 func (c *Client) GetAccountCntByShardNumber(shardNumber int) (uint64, error) {
 	var txCnt uint64
 	query := func(c *mgo.Collection) error {
 		var err error
 		//TODO: fix this overflow
 		var temp int
-		temp, err = c.Find(bson.M{"accType": 0, "shardnumber": shardNumber}).Count()
+		temp, err = c.Find(bson.M{"accType": 0, "shardNumber": shardNumber}).Count()
 		txCnt = uint64(temp)
 		return err
 	}
@@ -361,7 +524,7 @@ func (c *Client) GetContractCntByShardNumber(shardNumber int) (uint64, error) {
 		var err error
 		//TODO: fix this overflow
 		var temp int
-		temp, err = c.Find(bson.M{"accType": 1, "shardnumber": shardNumber}).Count()
+		temp, err = c.Find(bson.M{"accType": 1, "shardNumber": shardNumber}).Count()
 		txCnt = uint64(temp)
 		return err
 	}
@@ -384,13 +547,14 @@ func (c *Client) GetTxCntByShardNumber(shardNumber int) (uint64, error) {
 	return txCnt, err
 }
 
+//GetPendingTxCntByShardNumber get the count of txs still in the tx pool
 func (c *Client) GetPendingTxCntByShardNumber(shardNumber int) (uint64, error) {
 	var txCnt uint64
 	query := func(c *mgo.Collection) error {
 		var err error
 		//TODO: fix this overflow
 		var temp int
-		temp, err = c.Find(bson.M{"shardNumber": shardNumber}).Count()
+		temp, err = c.Find(pendingTxFilter(bson.M{"shardNumber": shardNumber})).Count()
 		txCnt = uint64(temp)
 		return err
 	}
@@ -430,8 +594,8 @@ func (c *Client) GetMinedBlocksCntByShardNumberAndAddress(shardNumber int, addre
 	return blockCnt, err
 }
 
-//removeAccount test use  remove account by address from database
-func (c *Client) removeAccount(address string) error {
+//RemoveAccount remove account by address from database
+func (c *Client) RemoveAccount(address string) error {
 	query := func(c *mgo.Collection) error {
 		return c.Remove(bson.M{"address": address})
 	}
@@ -453,7 +617,7 @@ func (c *Client) GetTxsByAddresss(address string, max int) ([]*DBTx, error) {
 func (c *Client) GetPendingTxsByAddress(address string) ([]*DBTx, error) {
 	var trans []*DBTx
 	query := func(c *mgo.Collection) error {
-		return c.Find(bson.M{"$or": []bson.M{bson.M{"from": address}, bson.M{"to": address}, bson.M{"contractAddress": address}}}).Sort("-timestamp").All(&trans)
+		return c.Find(pendingTxFilter(bson.M{"$or": []bson.M{bson.M{"from": address}, bson.M{"to": address}, bson.M{"contractAddress": address}}})).Sort("-timestamp").All(&trans)
 	}
 	err := c.withCollection(pendingTxTbl, query)
 	return trans, err
@@ -524,35 +688,208 @@ func (c *Client) GetContractsByShardNumber(shardNumber int, max int) ([]*DBAccou
 	return accounts, err
 }
 
-//GetTotalBalance return the sum of all account
-func (c *Client) GetTotalBalance() (map[int]int64, error) {
-	totalBalance := make(map[int]int64)
+//GetTotalBalance return the sum of all account balances of each shard
+func (c *Client) GetTotalBalance() (map[int]*big.Int, error) {
+	totalBalance := make(map[int]*big.Int)
 	query := func(c *mgo.Collection) error {
-
-		job := &mgo.MapReduce{
-			Map: "function() { emit(this.shardNumber, this.balance) }",
-			Reduce: `function(key, values) {
-						return Array.sum(values)
-					}`,
+		pipeline := []bson.M{
+			{"$group": bson.M{
+				"_id":   "$shardNumber",
+				"total": bson.M{"$sum": "$balance"},
+			}},
 		}
+
 		var result []struct {
-			Id    int "_id"
-			Value int64
+			ShardNumber int    `bson:"_id"`
+			Total       BigInt `bson:"total"`
 		}
-		_, err := c.Find(nil).MapReduce(job, &result)
-		if err != nil {
+		if err := c.Pipe(pipeline).All(&result); err != nil {
 			return err
 		}
+
 		for _, item := range result {
-			totalBalance[item.Id] = item.Value
+			totalBalance[item.ShardNumber] = item.Total.Big()
 		}
-
-		return err
+		return nil
 	}
 	err := c.withCollection(accTbl, query)
 	return totalBalance, err
 }
 
+//AddBlockUndo insert the undo journal of a block into database
+func (c *Client) AddBlockUndo(undo *DBBlockUndo) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": undo.ShardNumber, "height": undo.Height}, undo)
+		return err
+	}
+	err := c.withCollection(blockUndoTbl, query)
+	return err
+}
+
+//GetBlockUndo get the undo journal of the block by height
+func (c *Client) GetBlockUndo(shardNumber int, height uint64) (*DBBlockUndo, error) {
+	undo := new(DBBlockUndo)
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber, "height": height}).One(undo)
+	}
+	err := c.withCollection(blockUndoTbl, query)
+	return undo, err
+}
+
+//RemoveBlockUndo remove the undo journal of the block by height
+func (c *Client) RemoveBlockUndo(shardNumber int, height uint64) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{"shardNumber": shardNumber, "height": height})
+		return err
+	}
+	err := c.withCollection(blockUndoTbl, query)
+	return err
+}
+
+//RemoveBlockUndosBefore remove the undo journals of the blocks lower than height
+func (c *Client) RemoveBlockUndosBefore(shardNumber int, height uint64) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{"shardNumber": shardNumber, "height": bson.M{"$lt": height}})
+		return err
+	}
+	err := c.withCollection(blockUndoTbl, query)
+	return err
+}
+
+//pruneBlockUndos remove the undo journals deeper than MaxReorgDepth below the highest one of each shard,
+//they were kept for every block before the syncer removed them
+func (c *Client) pruneBlockUndos() error {
+	var result []struct {
+		ShardNumber int   `bson:"_id"`
+		Height      int64 `bson:"height"`
+	}
+	query := func(c *mgo.Collection) error {
+		return c.Pipe([]bson.M{
+			{"$group": bson.M{"_id": "$shardNumber", "height": bson.M{"$max": "$height"}}},
+		}).All(&result)
+	}
+	if err := c.withCollection(blockUndoTbl, query); err != nil {
+		return err
+	}
+
+	for _, item := range result {
+		if item.Height <= MaxReorgDepth {
+			continue
+		}
+
+		if err := c.RemoveBlockUndosBefore(item.ShardNumber, uint64(item.Height-MaxReorgDepth)); err != nil {
+			return err
+		}
+		log.Info("[DB] removed the block undos of shard %d lower than %d", item.ShardNumber, item.Height-MaxReorgDepth)
+	}
+	return nil
+}
+
+//GetSyncCursor get the sync cursor of the shard, it returns nil if the shard has no cursor yet
+func (c *Client) GetSyncCursor(shardNumber int) (*DBSyncCursor, error) {
+	cursor := new(DBSyncCursor)
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber}).One(cursor)
+	}
+	err := c.withCollection(syncCursorTbl, query)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return cursor, err
+}
+
+//SetSyncCursor update the sync cursor of the shard
+func (c *Client) SetSyncCursor(cursor *DBSyncCursor) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": cursor.ShardNumber}, cursor)
+		return err
+	}
+	err := c.withCollection(syncCursorTbl, query)
+	return err
+}
+
+//AddReorg insert a chain reorganization event into database
+func (c *Client) AddReorg(reorg *DBReorg) error {
+	query := func(c *mgo.Collection) error {
+		return c.Insert(reorg)
+	}
+	err := c.withCollection(reorgTbl, query)
+	return err
+}
+
+//GetReorgs get the latest chain reorganization events of the shard
+func (c *Client) GetReorgs(shardNumber int, max int) ([]*DBReorg, error) {
+	var reorgs []*DBReorg
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber}).Sort("-timestamp").Limit(max).All(&reorgs)
+	}
+	err := c.withCollection(reorgTbl, query)
+	return reorgs, err
+}
+
+//AddChartRedo mark the one day charts of the shard since redo.ZeroTime to be counted again, an existing mark
+//of the shard is extended to the earlier day and the higher height
+func (c *Client) AddChartRedo(redo *DBChartRedo) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": redo.ShardNumber}, bson.M{
+			"$min": bson.M{"zeroTime": redo.ZeroTime},
+			"$max": bson.M{"height": redo.Height},
+		})
+		return err
+	}
+	err := c.withCollection(chartRedoTbl, query)
+	return err
+}
+
+//GetChartRedo get the one day charts of the shard to be counted again, it returns nil if there is none
+func (c *Client) GetChartRedo(shardNumber int) (*DBChartRedo, error) {
+	redo := new(DBChartRedo)
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber}).One(redo)
+	}
+	err := c.withCollection(chartRedoTbl, query)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return redo, err
+}
+
+//RemoveChartRedo remove the mark after the charts are counted again, a mark changed by a later
+//reorganization in the meantime is kept
+func (c *Client) RemoveChartRedo(redo *DBChartRedo) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.RemoveAll(bson.M{"shardNumber": redo.ShardNumber, "zeroTime": redo.ZeroTime, "height": redo.Height})
+		return err
+	}
+	err := c.withCollection(chartRedoTbl, query)
+	return err
+}
+
+//RemoveChartData remove the one day chart rows of the shard since beginTime, so that they will be counted again
+func (c *Client) RemoveChartData(shardNumber int, beginTime int64) error {
+	tbls := []string{
+		chartTxTbl,
+		chartHashRateTbl,
+		chartBlockDifficultyTbl,
+		chartBlockAvgTimeTbl,
+		chartBlockTbl,
+		chartAddressTbl,
+		chartSingleAddressTbl,
+	}
+
+	for _, tbl := range tbls {
+		query := func(c *mgo.Collection) error {
+			_, err := c.RemoveAll(bson.M{"shardnumber": shardNumber, "timestamp": bson.M{"$gte": beginTime}})
+			return err
+		}
+		if err := c.withCollection(tbl, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 //processDataBaseError shutdown database connection and log it
 func processDataBaseError(err error) {
 	if err == nil || err == mgo.ErrNotFound || err == mgo.ErrCursor {
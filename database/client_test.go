@@ -6,6 +6,7 @@ package database
 
 import (
 	"fmt"
+	"math/big"
 	"math/rand"
 	"strconv"
 	"testing"
@@ -941,7 +942,7 @@ func TestSpeed(t *testing.T) {
 		return
 	}
 
-	dbClient := NewDBClient("seele", "127.0.0.1:27017", 1)
+	dbClient := NewDBClient("seele", "127.0.0.1:27017")
 	if dbClient == nil {
 		fmt.Printf("init database error")
 		return
@@ -960,7 +961,7 @@ func TestSpeed(t *testing.T) {
 			Hash:         "0x2919c60a1c1d98cac0d33d336761571f2724fc15e2d6ced5b002e35c80bbbbbb",
 			From:         strconv.Itoa(from),
 			To:           strconv.Itoa(to),
-			Amount:       1000,
+			Amount:       NewBigInt(big.NewInt(1000)),
 			AccountNonce: "0",
 			Timestamp:    "1526867474273961984",
 			Payload:      "",
@@ -980,7 +981,7 @@ func TestSearch(t *testing.T) {
 		return
 	}
 
-	dbClient := NewDBClient("seele", "127.0.0.1:27017", 1)
+	dbClient := NewDBClient("seele", "127.0.0.1:27017")
 	if dbClient == nil {
 		fmt.Printf("init database error")
 		return
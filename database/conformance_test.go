@@ -0,0 +1,47 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/database/dbtest"
+	mgo "gopkg.in/mgo.v2"
+)
+
+//TestConformance run the conformance tests against the mongodb of SCAN_TEST_MONGO (127.0.0.1:27017 by default),
+//every test runs in a new database which is dropped after the test. It is skipped if the mongodb is not reachable
+func TestConformance(t *testing.T) {
+	connURL := os.Getenv("SCAN_TEST_MONGO")
+	if connURL == "" {
+		connURL = "127.0.0.1:27017"
+	}
+
+	session, err := mgo.DialWithTimeout(connURL, time.Second)
+	if err != nil {
+		t.Skipf("mongodb %s is not reachable, %v", connURL, err)
+	}
+	defer session.Close()
+
+	dbtest.Run(t, func(t *testing.T) dbtest.Database {
+		dbName := fmt.Sprintf("scan_conformance_%d", time.Now().UnixNano())
+		t.Cleanup(func() {
+			if err := session.DB(dbName).DropDatabase(); err != nil {
+				t.Logf("could not drop database %s, %v", dbName, err)
+			}
+		})
+
+		client := database.NewDBClient(dbName, connURL)
+		if client == nil {
+			t.Fatalf("could not connect to mongodb %s", connURL)
+		}
+		return client
+	})
+}
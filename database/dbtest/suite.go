@@ -0,0 +1,957 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+//Package dbtest is the conformance test suite of the storage backends, every backend must pass it
+//so that the syncer, the api handlers and the chart and node services behave the same on them
+package dbtest
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/chart"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/node"
+	"github.com/seeleteam/scan-api/syncer"
+	mgo "gopkg.in/mgo.v2"
+)
+
+//Database is a storage backend implementing all the repository interfaces
+type Database interface {
+	syncer.Database
+	handlers.BlockInfoDB
+	handlers.ChartInfoDB
+	handlers.NodeInfoDB
+	chart.ChartDB
+	node.NodeDB
+
+	GetTxCnt() (uint64, error)
+	GetBlockCnt() (uint64, error)
+	GetAccountCnt() (uint64, error)
+	GetContractCnt() (uint64, error)
+	GetAccountCntByShardNumber(shardNumber int) (uint64, error)
+	GetContractCntByShardNumber(shardNumber int) (uint64, error)
+	GetTotalBalance() (map[int]*big.Int, error)
+	GetTxsByAddresss(address string, max int) ([]*database.DBTx, error)
+	GetPendingTxsByAddress(address string) ([]*database.DBTx, error)
+	GetTxCntByShardNumberAndAddress(shardNumber int, address string) (int64, error)
+	SetStats(stats *database.DBShardStats) error
+}
+
+//Run run the conformance tests, newDB must return an empty database for every test
+func Run(t *testing.T, newDB func(t *testing.T) Database) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, db Database)
+	}{
+		{"Blocks", testBlocks},
+		{"Txs", testTxs},
+		{"PendingTxs", testPendingTxs},
+		{"Receipts", testReceipts},
+		{"BalanceChanges", testBalanceChanges},
+		{"Accounts", testAccounts},
+		{"Pages", testPages},
+		{"AddressTxs", testAddressTxs},
+		{"BlockUndos", testBlockUndos},
+		{"Stats", testStats},
+		{"SyncCursor", testSyncCursor},
+		{"Reorgs", testReorgs},
+		{"Charts", testCharts},
+		{"ChartRedos", testChartRedos},
+		{"TopMiners", testTopMiners},
+		{"NodeInfos", testNodeInfos},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newDB(t))
+		})
+	}
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkNotFound(t *testing.T, err error) {
+	t.Helper()
+	if err != mgo.ErrNotFound {
+		t.Fatalf("expected %v, got %v", mgo.ErrNotFound, err)
+	}
+}
+
+func checkCount(t *testing.T, what string, got interface{}, err error, want interface{}) {
+	t.Helper()
+	check(t, err)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("bad %s, got %v, want %v", what, got, want)
+	}
+}
+
+func amount(s string) database.BigInt {
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("bad amount " + s)
+	}
+	return database.NewBigInt(x)
+}
+
+func checkAmount(t *testing.T, what string, got *big.Int, want string) {
+	t.Helper()
+	if got == nil || got.String() != want {
+		t.Fatalf("bad %s, got %v, want %s", what, got, want)
+	}
+}
+
+func blockHeights(blocks []*database.DBBlock) []int64 {
+	heights := []int64{}
+	for _, b := range blocks {
+		heights = append(heights, b.Height)
+	}
+	return heights
+}
+
+func txHashes(txs []*database.DBTx) []string {
+	hashes := []string{}
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	return hashes
+}
+
+func testBlocks(t *testing.T, db Database) {
+	for shard := 1; shard <= 2; shard++ {
+		for h := int64(0); h < 5; h++ {
+			check(t, db.AddBlock(&database.DBBlock{
+				HeadHash:    "0xb" + strconv.Itoa(shard) + strconv.FormatInt(h, 10),
+				Height:      h,
+				Timestamp:   1000 + h*10,
+				Creator:     "0xminer" + strconv.FormatInt(h%2, 10),
+				Reward:      amount("123456789012345678901234567890"),
+				Txs:         []database.DBSimpleTxInBlock{{Hash: "0xt" + strconv.FormatInt(h, 10), Amount: amount("1")}},
+				ShardNumber: shard,
+			}))
+		}
+	}
+
+	//the block with the same hash is replaced
+	check(t, db.AddBlock(&database.DBBlock{HeadHash: "0xb14", Height: 4, Timestamp: 1040, Creator: "0xminer2", ShardNumber: 1}))
+
+	height, err := db.GetBlockHeight(1)
+	checkCount(t, "block height", height, err, uint64(5))
+	cnt, err := db.GetBlockCnt()
+	checkCount(t, "block count", cnt, err, uint64(10))
+
+	b, err := db.GetBlockByHeight(1, 2)
+	check(t, err)
+	if b.HeadHash != "0xb12" || b.Reward.String() != "123456789012345678901234567890" || len(b.Txs) != 1 || b.Txs[0].Hash != "0xt2" {
+		t.Fatalf("bad block %+v", b)
+	}
+
+	b, err = db.GetBlockByHash("0xb14")
+	check(t, err)
+	if b.Creator != "0xminer2" || b.ShardNumber != 1 {
+		t.Fatalf("bad replaced block %+v", b)
+	}
+
+	_, err = db.GetBlockByHeight(3, 0)
+	checkNotFound(t, err)
+	_, err = db.GetBlockByHash("0xmissing")
+	checkNotFound(t, err)
+
+	blocks, err := db.GetBlocksByHeight(1, 1, 4)
+	checkCount(t, "blocks by height", blockHeights(blocks), err, []int64{3, 2, 1})
+
+	blocks, err = db.GetBlocksByTime(2, 1010, 1030)
+	check(t, err)
+	if len(blocks) != 3 {
+		t.Fatalf("bad blocks by time %v", blockHeights(blocks))
+	}
+
+	mined, err := db.GetMinedBlocksCntByShardNumberAndAddress(1, "0xminer0")
+	checkCount(t, "mined blocks", mined, err, int64(2))
+
+	check(t, db.RemoveBlock(1, 4))
+	height, err = db.GetBlockHeight(1)
+	checkCount(t, "block height after remove", height, err, uint64(4))
+	_, err = db.GetBlockByHash("0xb14")
+	checkNotFound(t, err)
+}
+
+func testTxs(t *testing.T, db Database) {
+	for i := int64(0); i < 6; i++ {
+		check(t, db.AddTx(&database.DBTx{
+			Hash:        "0xt" + strconv.FormatInt(i, 10),
+			From:        "0xa" + strconv.FormatInt(i%2, 10),
+			To:          "0xb",
+			Amount:      amount("1000000000000000000000"),
+			Timestamp:   strconv.FormatInt(100+i, 10),
+			Block:       strconv.FormatInt(i/2, 10),
+			Idx:         i,
+			ShardNumber: 1,
+		}))
+	}
+	check(t, db.AddTx(&database.DBTx{Hash: "0xs2", From: "0xa0", To: "0xc", Timestamp: "103", Block: "0", Idx: 0, ShardNumber: 2}))
+
+	//the tx with the same hash is replaced
+	check(t, db.AddTx(&database.DBTx{Hash: "0xt5", From: "0xa1", To: "0xd", Timestamp: "105", Block: "2", Idx: 5, ShardNumber: 1}))
+
+	cnt, err := db.GetTxCnt()
+	checkCount(t, "tx count", cnt, err, uint64(7))
+	cnt, err = db.GetTxCntByShardNumber(1)
+	checkCount(t, "tx count of the shard", cnt, err, uint64(6))
+
+	addrCnt, err := db.GetTxCntByShardNumberAndAddress(1, "0xb")
+	checkCount(t, "tx count of the address", addrCnt, err, int64(5))
+
+	tx, err := db.GetTxByHash("0xt3")
+	check(t, err)
+	if tx.Idx != 3 || tx.Amount.String() != "1000000000000000000000" {
+		t.Fatalf("bad tx %+v", tx)
+	}
+	tx, err = db.GetTxByHash("0xt5")
+	check(t, err)
+	if tx.To != "0xd" {
+		t.Fatalf("bad replaced tx %+v", tx)
+	}
+	_, err = db.GetTxByHash("0xmissing")
+	checkNotFound(t, err)
+
+	txs, err := db.GetTxsByIdx(1, 1, 5)
+	checkCount(t, "txs by idx", txHashes(txs), err, []string{"0xt4", "0xt3", "0xt2", "0xt1"})
+
+	txs, err = db.GetTxsByBlock(1, 1)
+	checkCount(t, "txs of the block", txHashes(txs), err, []string{"0xt2", "0xt3"})
+
+	txs, err = db.GetTxsByAddresss("0xa0", 0)
+	checkCount(t, "txs of the address", txHashes(txs), err, []string{"0xt4", "0xs2", "0xt2", "0xt0"})
+	txs, err = db.GetTxsByAddresss("0xb", 2)
+	checkCount(t, "limited txs of the address", txHashes(txs), err, []string{"0xt4", "0xt3"})
+
+	check(t, db.RemoveTxs(1, 1))
+	cnt, err = db.GetTxCntByShardNumber(1)
+	checkCount(t, "tx count after remove", cnt, err, uint64(4))
+	cnt, err = db.GetTxCntByShardNumber(2)
+	checkCount(t, "tx count of the other shard after remove", cnt, err, uint64(1))
+}
+
+func testPendingTxs(t *testing.T, db Database) {
+	states := []string{"", database.PoolStatePending, database.PoolStatePending, database.PoolStateMined, database.PoolStateDropped}
+	for i, state := range states {
+		check(t, db.AddPendingTx(&database.DBTx{
+			Hash:        "0xp" + strconv.Itoa(i),
+			From:        "0xa",
+			To:          "0xb" + strconv.Itoa(i),
+			Timestamp:   strconv.Itoa(200 + i),
+			Idx:         int64(i),
+			ShardNumber: 1,
+			Pending:     true,
+			PoolState:   state,
+			FirstSeen:   int64(10 + i/2),
+		}))
+	}
+
+	cnt, err := db.GetPendingTxCntByShardNumber(1)
+	checkCount(t, "pending tx count", cnt, err, uint64(3))
+
+	txs, err := db.GetPendingTxs(1, 0, 2)
+	checkCount(t, "pending txs", txHashes(txs), err, []string{"0xp2", "0xp1"})
+	txs, err = db.GetPendingTxs(1, 2, 2)
+	checkCount(t, "pending txs of the second page", txHashes(txs), err, []string{"0xp0"})
+
+	txs, err = db.GetAllPendingTxs(1)
+	check(t, err)
+	if len(txs) != 3 {
+		t.Fatalf("bad pending txs %v", txHashes(txs))
+	}
+
+	txs, err = db.GetPendingTxsByAddress("0xa")
+	checkCount(t, "pending txs of the address", txHashes(txs), err, []string{"0xp2", "0xp1", "0xp0"})
+
+	//a tx which has left the pool is still found by hash
+	tx, err := db.GetPendingTxByHash("0xp3")
+	check(t, err)
+	if tx.PoolState != database.PoolStateMined {
+		t.Fatalf("bad pool tx %+v", tx)
+	}
+	_, err = db.GetPendingTxByHash("0xmissing")
+	checkNotFound(t, err)
+
+	check(t, db.UpdatePendingTxState("0xp1", database.PoolStateMined, 30, 20))
+	tx, err = db.GetPendingTxByHash("0xp1")
+	check(t, err)
+	if tx.PoolState != database.PoolStateMined || tx.LeftPoolTime != 30 || tx.InclusionTime != 20 {
+		t.Fatalf("bad mined pool tx %+v", tx)
+	}
+
+	//the tx already in the state is left unchanged
+	check(t, db.UpdatePendingTxState("0xp1", database.PoolStateMined, 40, 30))
+	tx, err = db.GetPendingTxByHash("0xp1")
+	check(t, err)
+	if tx.LeftPoolTime != 30 {
+		t.Fatalf("the mined pool tx is changed %+v", tx)
+	}
+
+	check(t, db.RemovePoolTxsBefore(1, 35))
+	_, err = db.GetPendingTxByHash("0xp1")
+	checkNotFound(t, err)
+	_, err = db.GetPendingTxByHash("0xp0")
+	check(t, err)
+}
+
+func testReceipts(t *testing.T, db Database) {
+	check(t, db.AddReceipt(&database.DBReceipt{TxHash: "0xt0", ShardNumber: 1, BlockHeight: 1, UsedGas: 1, TotalFee: amount("10")}))
+	check(t, db.AddReceipt(&database.DBReceipt{TxHash: "0xt1", ShardNumber: 1, BlockHeight: 2}))
+	check(t, db.AddReceipt(&database.DBReceipt{TxHash: "0xt0", ShardNumber: 1, BlockHeight: 1, UsedGas: 2, Failed: true, TotalFee: amount("20")}))
+
+	r, err := db.GetReceiptByTxHash("0xt0")
+	check(t, err)
+	if r.UsedGas != 2 || !r.Failed || r.TotalFee.String() != "20" {
+		t.Fatalf("bad receipt %+v", r)
+	}
+
+	check(t, db.RemoveReceipts(1, 1))
+	_, err = db.GetReceiptByTxHash("0xt0")
+	checkNotFound(t, err)
+	_, err = db.GetReceiptByTxHash("0xt1")
+	check(t, err)
+}
+
+func testBalanceChanges(t *testing.T, db Database) {
+	for h := int64(1); h <= 4; h++ {
+		check(t, db.AddBalanceChange(&database.DBBalanceChange{
+			ShardNumber: 1,
+			Address:     "0xa",
+			Height:      h * 10,
+			Timestamp:   h * 100,
+			Balance:     amount(strconv.FormatInt(h, 10) + "000000000000000000000"),
+			Delta:       amount("1000000000000000000000"),
+		}))
+	}
+
+	//the change of the same block is replaced
+	check(t, db.AddBalanceChange(&database.DBBalanceChange{ShardNumber: 1, Address: "0xa", Height: 20, Timestamp: 200, Balance: amount("5")}))
+
+	change, err := db.GetBalanceAtHeight("0xa", 25)
+	check(t, err)
+	if change == nil || change.Height != 20 || change.Balance.String() != "5" {
+		t.Fatalf("bad balance at height %+v", change)
+	}
+
+	change, err = db.GetBalanceAtTime("0xa", 399)
+	check(t, err)
+	if change == nil || change.Height != 30 || change.Balance.String() != "3000000000000000000000" {
+		t.Fatalf("bad balance at time %+v", change)
+	}
+
+	change, err = db.GetBalanceAtHeight("0xa", 5)
+	if err != nil || change != nil {
+		t.Fatalf("expected no balance before the first change, got %+v, %v", change, err)
+	}
+
+	changes, err := db.GetBalanceHistory("0xa", 200, 400, 2)
+	check(t, err)
+	if len(changes) != 2 || changes[0].Height != 20 || changes[1].Height != 30 {
+		t.Fatalf("bad balance history %+v", changes)
+	}
+
+	check(t, db.RemoveBalanceChanges(1, 40))
+	change, err = db.GetBalanceAtHeight("0xa", 100)
+	check(t, err)
+	if change == nil || change.Height != 30 {
+		t.Fatalf("bad balance after remove %+v", change)
+	}
+}
+
+func testAccounts(t *testing.T, db Database) {
+	accounts := []*database.DBAccount{
+		{AccType: 0, Address: "0xa1", Balance: amount("100000000000000000000000"), ShardNumber: 1, TimeStamp: 1},
+		{AccType: 0, Address: "0xa2", Balance: amount("9"), ShardNumber: 1, TimeStamp: 2},
+		{AccType: 0, Address: "0xa3", Balance: amount("20"), ShardNumber: 1, TimeStamp: 3},
+		{AccType: 0, Address: "0xa4", Balance: amount("7"), ShardNumber: 2, TimeStamp: 4},
+		{AccType: 1, Address: "0xc1", Balance: amount("1"), ShardNumber: 1, TimeStamp: 5},
+		{AccType: 1, Address: "0xc2", Balance: amount("2"), ShardNumber: 1, TimeStamp: 6},
+	}
+	for _, a := range accounts {
+		check(t, db.AddAccount(a))
+	}
+
+	a, err := db.GetAccountByAddress("0xa1")
+	check(t, err)
+	if a.Balance.String() != "100000000000000000000000" || a.ShardNumber != 1 {
+		t.Fatalf("bad account %+v", a)
+	}
+	_, err = db.GetAccountByAddress("0xmissing")
+	checkNotFound(t, err)
+
+	cnt, err := db.GetAccountCnt()
+	checkCount(t, "account count", cnt, err, uint64(4))
+	cnt, err = db.GetContractCnt()
+	checkCount(t, "contract count", cnt, err, uint64(2))
+	cnt, err = db.GetAccountCntByShardNumber(1)
+	checkCount(t, "account count of the shard", cnt, err, uint64(3))
+	cnt, err = db.GetContractCntByShardNumber(2)
+	checkCount(t, "contract count of the shard", cnt, err, uint64(0))
+
+	list, err := db.GetAccountsByShardNumber(1, 2)
+	check(t, err)
+	if len(list) != 2 || list[0].Address != "0xa1" || list[1].Address != "0xa3" {
+		t.Fatalf("bad accounts by balance %+v", list)
+	}
+
+	list, err = db.GetContractsByShardNumber(1, 0)
+	check(t, err)
+	if len(list) != 2 || list[0].Address != "0xc2" || list[1].Address != "0xc1" {
+		t.Fatalf("bad contracts %+v", list)
+	}
+
+	total, err := db.GetTotalBalance()
+	check(t, err)
+	checkAmount(t, "total balance of shard 1", total[1], "100000000000000000000032")
+	checkAmount(t, "total balance of shard 2", total[2], "7")
+
+	//update replaces the account, and inserts it if it is missing
+	check(t, db.UpdateAccount(&database.DBAccount{Address: "0xa2", Balance: amount("50"), ShardNumber: 1, TxCount: 3}))
+	check(t, db.UpdateAccount(&database.DBAccount{Address: "0xa5", Balance: amount("1"), ShardNumber: 2}))
+	a, err = db.GetAccountByAddress("0xa2")
+	check(t, err)
+	if a.Balance.String() != "50" || a.TxCount != 3 {
+		t.Fatalf("bad updated account %+v", a)
+	}
+	cnt, err = db.GetAccountCntByShardNumber(2)
+	checkCount(t, "account count after upsert", cnt, err, uint64(2))
+
+	check(t, db.RemoveAccount("0xa2"))
+	_, err = db.GetAccountByAddress("0xa2")
+	checkNotFound(t, err)
+	checkNotFound(t, db.RemoveAccount("0xa2"))
+}
+
+func accountAddresses(accounts []*database.DBAccount) []string {
+	addresses := []string{}
+	for _, a := range accounts {
+		addresses = append(addresses, a.Address)
+	}
+	return addresses
+}
+
+func testPages(t *testing.T, db Database) {
+	accounts := []*database.DBAccount{
+		{AccType: 0, Address: "0xa1", Balance: amount("100"), ShardNumber: 1},
+		{AccType: 0, Address: "0xa2", Balance: amount("50"), ShardNumber: 1},
+		{AccType: 0, Address: "0xa3", Balance: amount("50"), ShardNumber: 1},
+		{AccType: 0, Address: "0xa4", Balance: amount("10"), ShardNumber: 1},
+		{AccType: 0, Address: "0xa5", Balance: amount("100000000000000000000000"), ShardNumber: 1},
+		{AccType: 0, Address: "0xa6", Balance: amount("70"), ShardNumber: 2},
+		{AccType: 1, Address: "0xc1", Balance: amount("80"), ShardNumber: 1, TimeStamp: 5},
+		{AccType: 1, Address: "0xc2", Balance: amount("0"), ShardNumber: 1, TimeStamp: 6},
+		{AccType: 1, Address: "0xc3", Balance: amount("0"), ShardNumber: 1, TimeStamp: 6},
+	}
+	for _, a := range accounts {
+		check(t, db.AddAccount(a))
+	}
+
+	//the accounts are ordered by balance and then by address
+	accountPages := []struct {
+		balance string
+		address string
+		older   bool
+		limit   int
+		want    []string
+	}{
+		{"0", "", true, 2, []string{"0xa5", "0xa1"}},
+		{"100", "0xa1", true, 2, []string{"0xa3", "0xa2"}},
+		{"50", "0xa2", true, 2, []string{"0xa4"}},
+		{"10", "0xa4", true, 2, []string{}},
+		{"50", "0xa3", false, 2, []string{"0xa5", "0xa1"}},
+		{"10", "0xa4", false, 1, []string{"0xa2"}},
+		{"0", "", false, 2, []string{"0xa2", "0xa4"}},
+		{"0", "", true, 0, []string{"0xa5", "0xa1", "0xa3", "0xa2", "0xa4"}},
+	}
+	for _, p := range accountPages {
+		balance := amount(p.balance)
+		list, err := db.GetAccountsPage(1, &balance.Int, p.address, p.older, p.limit)
+		checkCount(t, "account page next to "+p.address, accountAddresses(list), err, p.want)
+	}
+
+	list, err := db.GetContractsPage(1, 0, "", true, 2)
+	checkCount(t, "first contract page", accountAddresses(list), err, []string{"0xc3", "0xc2"})
+	list, err = db.GetContractsPage(1, 6, "0xc2", true, 2)
+	checkCount(t, "older contract page", accountAddresses(list), err, []string{"0xc1"})
+	list, err = db.GetContractsPage(1, 5, "0xc1", false, 5)
+	checkCount(t, "newer contract page", accountAddresses(list), err, []string{"0xc3", "0xc2"})
+}
+
+func addressTxHashes(txs []*database.DBAddressTx) []string {
+	hashes := []string{}
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	return hashes
+}
+
+//addressTxs return the tx history entries of the txs
+func addressTxs(txs []*database.DBTx, contracts map[string]string) []*database.DBAddressTx {
+	var entries []*database.DBAddressTx
+	for _, tx := range txs {
+		entries = append(entries, database.CreateDbAddressTxs(tx, contracts[tx.Hash])...)
+	}
+	return entries
+}
+
+func checkAddressTxCount(t *testing.T, db Database, address string, txs, in, out, created, failed int64) {
+	t.Helper()
+	count, err := db.GetAddressTxCount(address)
+	check(t, err)
+	if count.Txs != txs || count.In != in || count.Out != out || count.Created != created || count.Failed != failed {
+		t.Fatalf("bad tx count of %s, got %+v", address, count)
+	}
+}
+
+func testAddressTxs(t *testing.T, db Database) {
+	shard1Block1 := []*database.DBTx{
+		{Hash: "0xt0", From: "0x0000000000000000000000000000000000000000", To: "0xa1", Timestamp: "99", Block: "1", ShardNumber: 1},
+		{Hash: "0xt1", From: "0xa1", To: "0xa2", Timestamp: "100", Block: "1", ShardNumber: 1},
+		{Hash: "0xt2", From: "0xa2", To: "0xa1", Timestamp: "101", Block: "1", ShardNumber: 1, Failed: true},
+	}
+	shard1Block2 := []*database.DBTx{
+		{Hash: "0xt3", From: "0xa1", To: "0xa1", Timestamp: "101", Block: "2", ShardNumber: 1},
+		{Hash: "0xt4", From: "0xa1", Timestamp: "102", Block: "2", ShardNumber: 1, TxType: 1, Payload: "0x60"},
+	}
+	shard2Block1 := []*database.DBTx{
+		{Hash: "0xt5", From: "0xa6", To: "0xa1", Timestamp: "103", Block: "1", ShardNumber: 2},
+	}
+	contracts := map[string]string{"0xt4": "0xc1"}
+
+	check(t, db.AddAddressTxs(1, 1, addressTxs(shard1Block1, contracts)))
+	check(t, db.AddAddressTxs(1, 2, addressTxs(shard1Block2, contracts)))
+	check(t, db.AddAddressTxs(2, 1, addressTxs(shard2Block1, contracts)))
+
+	//the block written again is not counted twice
+	check(t, db.AddAddressTxs(1, 1, addressTxs(shard1Block1, contracts)))
+
+	checkAddressTxCount(t, db, "0xa1", 6, 4, 3, 0, 0)
+	checkAddressTxCount(t, db, "0xa2", 2, 1, 1, 0, 1)
+	checkAddressTxCount(t, db, "0xc1", 1, 0, 0, 1, 0)
+	checkAddressTxCount(t, db, "0x0000000000000000000000000000000000000000", 0, 0, 0, 0, 0)
+
+	//the entries are ordered by the numeric timestamp and then by hash, 99 is older than 100
+	txs, err := db.GetAddressTxs(&database.AddressTxFilter{Address: "0xa1"}, 0, 0)
+	checkCount(t, "tx history", addressTxHashes(txs), err, []string{"0xt5", "0xt4", "0xt3", "0xt2", "0xt1", "0xt0"})
+	if txs[2].Direction != database.DirectionSelf || txs[1].Payload != "" {
+		t.Fatalf("bad entry %+v", txs[1])
+	}
+	if txs[5].Time != 99 {
+		t.Fatalf("bad time of the entry %+v", txs[5])
+	}
+	txs, err = db.GetAddressTxs(&database.AddressTxFilter{Address: "0xa1", Direction: database.DirectionIn}, 0, 0)
+	checkCount(t, "received txs", addressTxHashes(txs), err, []string{"0xt5", "0xt3", "0xt2", "0xt0"})
+	txs, err = db.GetAddressTxs(&database.AddressTxFilter{Address: "0xa1", Direction: database.DirectionOut}, 0, 0)
+	checkCount(t, "sent txs", addressTxHashes(txs), err, []string{"0xt4", "0xt3", "0xt1"})
+	txs, err = db.GetAddressTxs(&database.AddressTxFilter{Address: "0xa1"}, 1, 2)
+	checkCount(t, "tx history page", addressTxHashes(txs), err, []string{"0xt4", "0xt3"})
+	txs, err = db.GetAddressTxs(&database.AddressTxFilter{Address: "0xc1", Direction: database.DirectionCreate}, 0, 0)
+	checkCount(t, "creating tx", addressTxHashes(txs), err, []string{"0xt4"})
+	if txs[0].Payload != "0x60" {
+		t.Fatalf("creation code is not kept, %+v", txs[0])
+	}
+
+	filter := &database.AddressTxFilter{Address: "0xa1"}
+	txs, err = db.GetAddressTxsPage(filter, 0, "", true, 2)
+	checkCount(t, "first tx page", addressTxHashes(txs), err, []string{"0xt5", "0xt4"})
+	txs, err = db.GetAddressTxsPage(filter, 102, "0xt4", true, 2)
+	checkCount(t, "older tx page", addressTxHashes(txs), err, []string{"0xt3", "0xt2"})
+	txs, err = db.GetAddressTxsPage(filter, 101, "0xt2", false, 1)
+	checkCount(t, "newer tx page", addressTxHashes(txs), err, []string{"0xt3"})
+	txs, err = db.GetAddressTxsPage(filter, 99, "0xt0", true, 2)
+	checkCount(t, "last tx page", addressTxHashes(txs), err, []string{})
+
+	//the txs in the tx pool are not counted, and never replace the mined ones
+	poolTx := &database.DBTx{Hash: "0xp1", From: "0xa1", To: "0xa3", Timestamp: "104", ShardNumber: 1, Pending: true}
+	check(t, db.AddPendingAddressTxs(database.CreateDbAddressTxs(poolTx, "")))
+	minedTx := *shard1Block1[1]
+	minedTx.Pending = true
+	check(t, db.AddPendingAddressTxs(database.CreateDbAddressTxs(&minedTx, "")))
+
+	pending := &database.AddressTxFilter{Address: "0xa1", Pending: true}
+	txs, err = db.GetAddressTxs(pending, 0, 0)
+	checkCount(t, "pending txs", addressTxHashes(txs), err, []string{"0xp1"})
+	txs, err = db.GetAddressTxs(filter, 0, 0)
+	checkCount(t, "mined txs", len(txs), err, 6)
+	checkAddressTxCount(t, db, "0xa1", 6, 4, 3, 0, 0)
+
+	//the mined tx replaces its pool entries
+	poolTx.Pending = false
+	poolTx.Block = "3"
+	check(t, db.AddAddressTxs(1, 3, addressTxs([]*database.DBTx{poolTx}, nil)))
+	check(t, db.RemovePendingAddressTxs("0xp1"))
+	txs, err = db.GetAddressTxs(pending, 0, 0)
+	checkCount(t, "pending txs after mined", addressTxHashes(txs), err, []string{})
+	checkAddressTxCount(t, db, "0xa1", 7, 4, 4, 0, 0)
+	checkAddressTxCount(t, db, "0xa3", 1, 1, 0, 0, 0)
+
+	droppedTx := &database.DBTx{Hash: "0xp2", From: "0xa3", To: "0xa1", Timestamp: "105", ShardNumber: 1, Pending: true}
+	check(t, db.AddPendingAddressTxs(database.CreateDbAddressTxs(droppedTx, "")))
+	check(t, db.RemovePendingAddressTxs("0xp2"))
+	txs, err = db.GetAddressTxs(&database.AddressTxFilter{Address: "0xa3", Pending: true}, 0, 0)
+	checkCount(t, "dropped txs", addressTxHashes(txs), err, []string{})
+
+	//removing a block reverts its counts once
+	check(t, db.RemoveAddressTxs(1, 3))
+	check(t, db.RemoveAddressTxs(1, 3))
+	checkAddressTxCount(t, db, "0xa1", 6, 4, 3, 0, 0)
+	checkAddressTxCount(t, db, "0xa3", 0, 0, 0, 0, 0)
+
+	check(t, db.RemoveAddressTxs(1, 2))
+	checkAddressTxCount(t, db, "0xa1", 4, 3, 1, 0, 0)
+	checkAddressTxCount(t, db, "0xc1", 0, 0, 0, 0, 0)
+	txs, err = db.GetAddressTxs(filter, 0, 0)
+	checkCount(t, "tx history after removing a block", addressTxHashes(txs), err, []string{"0xt5", "0xt2", "0xt1", "0xt0"})
+
+	//the block synced again is counted again
+	check(t, db.AddAddressTxs(1, 2, addressTxs(shard1Block2, contracts)))
+	checkAddressTxCount(t, db, "0xa1", 6, 4, 3, 0, 0)
+}
+
+func testBlockUndos(t *testing.T, db Database) {
+	undo := &database.DBBlockUndo{
+		ShardNumber: 1,
+		Height:      5,
+		HeadHash:    "0xb5",
+		TxIdx:       10,
+		Accounts:    []database.DBAccountUndo{{Address: "0xa", TxCount: 1, Created: true, Balance: amount("-1000000000000000000000")}},
+		Stats:       &database.DBStatsDelta{Blocks: 1, Txs: 2, Accounts: 1, TotalBalance: amount("-1")},
+	}
+	check(t, db.AddBlockUndo(undo))
+
+	undo.HeadHash = "0xb5x"
+	check(t, db.AddBlockUndo(undo))
+
+	got, err := db.GetBlockUndo(1, 5)
+	check(t, err)
+	if got.HeadHash != "0xb5x" || len(got.Accounts) != 1 || got.Accounts[0].Balance.String() != "-1000000000000000000000" {
+		t.Fatalf("bad block undo %+v", got)
+	}
+	if !got.Accounts[0].Created || got.Stats == nil || got.Stats.Txs != 2 || got.Stats.TotalBalance.String() != "-1" {
+		t.Fatalf("bad block undo stats %+v", got)
+	}
+
+	_, err = db.GetBlockUndo(2, 5)
+	checkNotFound(t, err)
+
+	check(t, db.RemoveBlockUndo(1, 5))
+	_, err = db.GetBlockUndo(1, 5)
+	checkNotFound(t, err)
+
+	for h := int64(1); h <= 4; h++ {
+		check(t, db.AddBlockUndo(&database.DBBlockUndo{ShardNumber: 1, Height: h}))
+		check(t, db.AddBlockUndo(&database.DBBlockUndo{ShardNumber: 2, Height: h}))
+	}
+	check(t, db.RemoveBlockUndosBefore(1, 3))
+	_, err = db.GetBlockUndo(1, 2)
+	checkNotFound(t, err)
+	_, err = db.GetBlockUndo(1, 3)
+	check(t, err)
+	_, err = db.GetBlockUndo(2, 1)
+	check(t, err)
+}
+
+func checkStats(t *testing.T, db Database, shardNumber int, height, blocks, txs, accounts, contracts int64, total string) {
+	t.Helper()
+	stats, err := db.GetShardStats(shardNumber)
+	check(t, err)
+	if stats.ShardNumber != shardNumber || stats.Height != height || stats.Blocks != blocks || stats.Txs != txs ||
+		stats.Accounts != accounts || stats.Contracts != contracts || stats.TotalBalance.String() != total {
+		t.Fatalf("bad stats of shard %d %+v", shardNumber, stats)
+	}
+}
+
+func testStats(t *testing.T, db Database) {
+	checkStats(t, db, 1, -1, 0, 0, 0, 0, "0")
+
+	genesis := &database.DBStatsDelta{Blocks: 1, Txs: 2, Accounts: 1, TotalBalance: amount("100000000000000000000000")}
+	block1 := &database.DBStatsDelta{Blocks: 1, Txs: 1, Contracts: 1, TotalBalance: amount("-5")}
+	check(t, db.ApplyStats(1, 0, genesis))
+	check(t, db.ApplyStats(1, 1, block1))
+	check(t, db.ApplyStats(2, 0, &database.DBStatsDelta{Blocks: 1, Txs: 1, Accounts: 1, TotalBalance: amount("7")}))
+	checkStats(t, db, 1, 1, 2, 3, 1, 1, "99999999999999999999995")
+
+	//a block which is already counted is skipped
+	check(t, db.ApplyStats(1, 1, block1))
+	check(t, db.ApplyStats(1, 0, genesis))
+	checkStats(t, db, 1, 1, 2, 3, 1, 1, "99999999999999999999995")
+
+	stats, err := db.GetStats()
+	check(t, err)
+	if len(stats) != 2 || stats[0].ShardNumber != 1 || stats[1].ShardNumber != 2 || stats[1].TotalBalance.String() != "7" {
+		t.Fatalf("bad stats %+v", stats)
+	}
+
+	check(t, db.RevertStats(1, 1, block1))
+	checkStats(t, db, 1, 0, 1, 2, 1, 0, "100000000000000000000000")
+
+	//a block which is not counted is not reverted
+	check(t, db.RevertStats(1, 1, block1))
+	check(t, db.RevertStats(3, 0, genesis))
+	checkStats(t, db, 1, 0, 1, 2, 1, 0, "100000000000000000000000")
+	checkStats(t, db, 3, -1, 0, 0, 0, 0, "0")
+
+	for h := int64(0); h < 3; h++ {
+		check(t, db.AddBlock(&database.DBBlock{HeadHash: "0xb3" + strconv.FormatInt(h, 10), Height: h, ShardNumber: 3}))
+	}
+	check(t, db.AddBlock(&database.DBBlock{HeadHash: "0xb40", Height: 0, ShardNumber: 4}))
+	check(t, db.AddTx(&database.DBTx{Hash: "0xt1", Block: "1", ShardNumber: 3}))
+	check(t, db.AddTx(&database.DBTx{Hash: "0xt2", Block: "2", ShardNumber: 3}))
+	check(t, db.AddAccount(&database.DBAccount{AccType: 0, Address: "0xa1", Balance: amount("100000000000000000000000"), ShardNumber: 3}))
+	check(t, db.AddAccount(&database.DBAccount{AccType: 0, Address: "0xa2", Balance: amount("3"), ShardNumber: 3}))
+	check(t, db.AddAccount(&database.DBAccount{AccType: 1, Address: "0xc1", Balance: amount("2"), ShardNumber: 3}))
+	check(t, db.AddAccount(&database.DBAccount{AccType: 0, Address: "0xa3", Balance: amount("1"), ShardNumber: 4}))
+
+	check(t, db.RecountStats(3))
+	checkStats(t, db, 3, 2, 3, 2, 2, 1, "100000000000000000000005")
+	checkStats(t, db, 4, -1, 0, 0, 0, 0, "0")
+
+	check(t, db.SetStats(&database.DBShardStats{ShardNumber: 3, Height: 7}))
+	checkStats(t, db, 3, 7, 0, 0, 0, 0, "0")
+}
+
+func testSyncCursor(t *testing.T, db Database) {
+	cursor, err := db.GetSyncCursor(1)
+	if err != nil || cursor != nil {
+		t.Fatalf("expected no cursor, got %+v, %v", cursor, err)
+	}
+
+	check(t, db.SetSyncCursor(&database.DBSyncCursor{ShardNumber: 1, Height: 3, HeadHash: "0xb3", TxIdx: 7}))
+	check(t, db.SetSyncCursor(&database.DBSyncCursor{ShardNumber: 1, Height: 4, HeadHash: "0xb4", TxIdx: 9}))
+	check(t, db.SetSyncCursor(&database.DBSyncCursor{ShardNumber: 2, Height: -1}))
+
+	cursor, err = db.GetSyncCursor(1)
+	check(t, err)
+	if cursor == nil || cursor.Height != 4 || cursor.HeadHash != "0xb4" || cursor.TxIdx != 9 {
+		t.Fatalf("bad cursor %+v", cursor)
+	}
+}
+
+func testReorgs(t *testing.T, db Database) {
+	for i := int64(1); i <= 3; i++ {
+		check(t, db.AddReorg(&database.DBReorg{ShardNumber: 1, Depth: i, Timestamp: i * 100, OldHashes: []string{"0xo"}, NewHashes: []string{"0xn"}}))
+	}
+	check(t, db.AddReorg(&database.DBReorg{ShardNumber: 2, Depth: 9, Timestamp: 900}))
+
+	reorgs, err := db.GetReorgs(1, 2)
+	check(t, err)
+	if len(reorgs) != 2 || reorgs[0].Depth != 3 || reorgs[1].Depth != 2 || len(reorgs[0].OldHashes) != 1 {
+		t.Fatalf("bad reorgs %+v", reorgs)
+	}
+}
+
+func testChartRedos(t *testing.T, db Database) {
+	redo, err := db.GetChartRedo(1)
+	if err != nil || redo != nil {
+		t.Fatalf("expected no chart redo, got %+v %v", redo, err)
+	}
+
+	//a later mark extends the days and the height
+	check(t, db.AddChartRedo(&database.DBChartRedo{ShardNumber: 1, ZeroTime: 86400 * 2, Height: 10}))
+	check(t, db.AddChartRedo(&database.DBChartRedo{ShardNumber: 1, ZeroTime: 86400 * 3, Height: 12}))
+	check(t, db.AddChartRedo(&database.DBChartRedo{ShardNumber: 2, ZeroTime: 86400, Height: 5}))
+	redo, err = db.GetChartRedo(1)
+	check(t, err)
+	if redo.ZeroTime != 86400*2 || redo.Height != 12 {
+		t.Fatalf("bad chart redo %+v", redo)
+	}
+
+	//the mark is kept if it is changed after it is read
+	check(t, db.RemoveChartRedo(&database.DBChartRedo{ShardNumber: 1, ZeroTime: 86400 * 2, Height: 10}))
+	redo, err = db.GetChartRedo(1)
+	check(t, err)
+	if redo == nil {
+		t.Fatal("the changed chart redo is removed")
+	}
+
+	check(t, db.RemoveChartRedo(redo))
+	redo, err = db.GetChartRedo(1)
+	if err != nil || redo != nil {
+		t.Fatalf("expected no chart redo after remove, got %+v %v", redo, err)
+	}
+	redo, err = db.GetChartRedo(2)
+	check(t, err)
+	if redo == nil || redo.Height != 5 {
+		t.Fatalf("the chart redo of the other shard is changed %+v", redo)
+	}
+}
+
+func testCharts(t *testing.T, db Database) {
+	for day := int64(3); day >= 1; day-- {
+		for shard := 1; shard <= 2; shard++ {
+			zeroTime := day*86400 + int64(shard)
+			check(t, db.AddOneDayTransInfo(shard, &database.DBOneDayTxInfo{TotalTxs: int(day), TimeStamp: zeroTime}))
+			check(t, db.AddOneDayHashRate(shard, &database.DBOneDayHashRate{HashRate: float64(day), TimeStamp: zeroTime}))
+			check(t, db.AddOneDayBlockDifficulty(shard, &database.DBOneDayBlockDifficulty{Difficulty: float64(day), TimeStamp: zeroTime}))
+			check(t, db.AddOneDayBlockAvgTime(shard, &database.DBOneDayBlockAvgTime{AvgTime: float64(day), TimeStamp: zeroTime}))
+			check(t, db.AddOneDayBlock(shard, &database.DBOneDayBlockInfo{TotalBlocks: day, Rewards: amount("1000000000000000000000"), TimeStamp: zeroTime}))
+			check(t, db.AddOneDayAddress(shard, &database.DBOneDayAddressInfo{TotalAddresss: day, TimeStamp: zeroTime}))
+			check(t, db.AddOneDaySingleAddressInfo(shard, &database.DBOneDaySingleAddressInfo{Address: "0xa" + strconv.FormatInt(day, 10), TimeStamp: zeroTime}))
+		}
+	}
+
+	txInfo, err := db.GetOneDayTransInfo(2, 86400*2+2)
+	check(t, err)
+	if txInfo.TotalTxs != 2 || txInfo.ShardNumber != 2 {
+		t.Fatalf("bad one day tx info %+v", txInfo)
+	}
+	_, err = db.GetOneDayTransInfo(3, 86400*2+2)
+	checkNotFound(t, err)
+
+	blockInfo, err := db.GetOneDayBlock(1, 86400+1)
+	check(t, err)
+	if blockInfo.TotalBlocks != 1 || blockInfo.Rewards.String() != "1000000000000000000000" {
+		t.Fatalf("bad one day block info %+v", blockInfo)
+	}
+
+	_, err = db.GetOneDayHashRate(1, 86400+1)
+	check(t, err)
+	_, err = db.GetOneDayBlockDifficulty(1, 86400+1)
+	check(t, err)
+	_, err = db.GetOneDayBlockAvgTime(1, 86400+1)
+	check(t, err)
+	_, err = db.GetOneDayAddress(1, 86400+1)
+	check(t, err)
+
+	single, err := db.GetOneDaySingleAddressInfo(2, "0xa3")
+	check(t, err)
+	if single.TimeStamp != 86400*3+2 {
+		t.Fatalf("bad single address info %+v", single)
+	}
+	_, err = db.GetOneDaySingleAddressInfo(3, "0xa3")
+	checkNotFound(t, err)
+
+	txChart, err := db.GetTransInfoChart()
+	check(t, err)
+	if len(txChart) != 6 || txChart[0].TimeStamp != 86400+1 || txChart[5].TimeStamp != 86400*3+2 {
+		t.Fatalf("bad tx chart %+v", txChart)
+	}
+
+	txChart, err = db.GetTransInfoChartByShardNumber(1)
+	check(t, err)
+	if len(txChart) != 3 || txChart[0].TotalTxs != 1 || txChart[2].TotalTxs != 3 {
+		t.Fatalf("bad tx chart of the shard %+v", txChart)
+	}
+
+	hashRates, err := db.GetHashRateChartByShardNumber(2)
+	check(t, err)
+	if len(hashRates) != 3 || hashRates[0].HashRate != 1 {
+		t.Fatalf("bad hashrate chart %+v", hashRates)
+	}
+	difficulties, err := db.GetOneDayBlockDifficultyChart()
+	check(t, err)
+	if len(difficulties) != 6 {
+		t.Fatalf("bad difficulty chart %+v", difficulties)
+	}
+	avgTimes, err := db.GetOneDayBlockAvgTimeChartByShardNumber(1)
+	check(t, err)
+	if len(avgTimes) != 3 || avgTimes[2].AvgTime != 3 {
+		t.Fatalf("bad block time chart %+v", avgTimes)
+	}
+	blocks, err := db.GetOneDayBlocksChart()
+	check(t, err)
+	if len(blocks) != 6 || blocks[0].TotalBlocks != 1 {
+		t.Fatalf("bad block chart %+v", blocks)
+	}
+	addresses, err := db.GetOneDayAddressesChartByShardNumber(2)
+	check(t, err)
+	if len(addresses) != 3 || addresses[1].TotalAddresss != 2 {
+		t.Fatalf("bad address chart %+v", addresses)
+	}
+
+	//the rows of the shard since the day are removed
+	check(t, db.RemoveChartData(1, 86400*2))
+	txChart, err = db.GetTransInfoChartByShardNumber(1)
+	check(t, err)
+	if len(txChart) != 1 || txChart[0].TotalTxs != 1 {
+		t.Fatalf("bad tx chart after remove %+v", txChart)
+	}
+	txChart, err = db.GetTransInfoChartByShardNumber(2)
+	check(t, err)
+	if len(txChart) != 3 {
+		t.Fatalf("the tx chart of the other shard is changed %+v", txChart)
+	}
+	_, err = db.GetOneDaySingleAddressInfo(1, "0xa2")
+	checkNotFound(t, err)
+	_, err = db.GetOneDaySingleAddressInfo(1, "0xa1")
+	check(t, err)
+}
+
+func testTopMiners(t *testing.T, db Database) {
+	for shard := 1; shard <= 2; shard++ {
+		check(t, db.AddTopMinerInfo(shard, &database.DBMinerRankInfo{
+			Rank: []database.DBSingleMinerRankInfo{{Address: "0xm" + strconv.Itoa(shard), Mined: shard, Percentage: 0.5}},
+		}))
+	}
+
+	ranks, err := db.GetTopMinerChart()
+	check(t, err)
+	if len(ranks) != 2 {
+		t.Fatalf("bad top miner chart %+v", ranks)
+	}
+
+	ranks, err = db.GetTopMinerChartByShardNumber(2)
+	check(t, err)
+	if len(ranks) != 1 || len(ranks[0].Rank) != 1 || ranks[0].Rank[0].Address != "0xm2" {
+		t.Fatalf("bad top miner chart of the shard %+v", ranks)
+	}
+
+	check(t, db.RemoveTopMinerInfo())
+	ranks, err = db.GetTopMinerChart()
+	check(t, err)
+	if len(ranks) != 0 {
+		t.Fatalf("bad top miner chart after remove %+v", ranks)
+	}
+}
+
+func testNodeInfos(t *testing.T, db Database) {
+	for i := 0; i < 3; i++ {
+		check(t, db.AddNodeInfo(&database.DBNodeInfo{
+			ShardNumber: 1 + i%2,
+			ID:          "node" + strconv.Itoa(i),
+			Host:        "10.0.0." + strconv.Itoa(i),
+			Port:        "8057",
+			LastSeen:    int64(i),
+		}))
+	}
+
+	info, err := db.GetNodeInfoByID("node1")
+	check(t, err)
+	if info.Host != "10.0.0.1" || info.ShardNumber != 2 {
+		t.Fatalf("bad node info %+v", info)
+	}
+	_, err = db.GetNodeInfoByID("missing")
+	checkNotFound(t, err)
+
+	cnt, err := db.GetNodeCntByShardNumber(1)
+	checkCount(t, "node count", cnt, err, uint64(2))
+
+	infos, err := db.GetNodeInfosByShardNumber(2)
+	check(t, err)
+	if len(infos) != 1 || infos[0].ID != "node1" {
+		t.Fatalf("bad node infos of the shard %+v", infos)
+	}
+
+	check(t, db.DeleteNodeInfo(&database.DBNodeInfo{ID: "node0"}))
+	checkNotFound(t, db.DeleteNodeInfo(&database.DBNodeInfo{ID: "node0"}))
+
+	infos, err = db.GetNodeInfos()
+	check(t, err)
+	if len(infos) != 2 {
+		t.Fatalf("bad node infos %+v", infos)
+	}
+}
@@ -0,0 +1,130 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"strings"
+
+	"github.com/seeleteam/scan-api/log"
+
+	mgo "gopkg.in/mgo.v2"
+)
+
+//collectionIndexes the indexes of every collection, they serve the queries of Client. A key
+//prefixed with - is in descending order
+var collectionIndexes = map[string][][]string{
+	blockTbl: {
+		{"headHash"},
+		{"shardNumber", "-height"},
+		{"shardNumber", "timestamp"},
+		{"shardNumber", "creator"},
+	},
+	txTbl: {
+		{"hash"},
+		{"shardNumber", "-idx"},
+		{"shardNumber", "block"},
+		{"from", "-timestamp", "-hash"},
+		{"to", "-timestamp", "-hash"},
+	},
+	pendingTxTbl: {
+		{"hash"},
+		{"shardNumber", "-firstSeen", "-idx"},
+		{"shardNumber", "poolState", "leftPoolTime"},
+		{"from", "-timestamp"},
+		{"to", "-timestamp"},
+	},
+	accTbl: {
+		{"address"},
+		{"shardNumber", "accType", "-balance", "-address"},
+		{"shardNumber", "accType", "-timestamp", "-address"},
+	},
+	receiptTbl: {
+		{"txHash"},
+		{"shardNumber", "blockHeight"},
+	},
+	balanceHistoryTbl: {
+		{"address", "-height"},
+		{"address", "timestamp"},
+		{"shardNumber", "height"},
+	},
+	blockUndoTbl: {
+		{"shardNumber", "height"},
+	},
+	reorgTbl: {
+		{"shardNumber", "-timestamp"},
+	},
+	syncCursorTbl: {
+		{"shardNumber"},
+	},
+	statsTbl: {
+		{"shardNumber"},
+	},
+	addressTxTbl: {
+		{"address", "hash"},
+		{"address", "pending", "-time", "-hash"},
+		{"address", "pending", "direction", "-time", "-hash"},
+		{"shardNumber", "block"},
+		{"hash"},
+	},
+	addressTxCountTbl: {
+		{"address", "shardNumber"},
+	},
+	chartTxTbl: {
+		{"shardnumber", "timestamp"},
+	},
+	chartHashRateTbl: {
+		{"shardnumber", "timestamp"},
+	},
+	chartBlockDifficultyTbl: {
+		{"shardnumber", "timestamp"},
+	},
+	chartBlockAvgTimeTbl: {
+		{"shardnumber", "timestamp"},
+	},
+	chartBlockTbl: {
+		{"shardnumber", "timestamp"},
+	},
+	chartAddressTbl: {
+		{"shardnumber", "timestamp"},
+	},
+	chartSingleAddressTbl: {
+		{"shardnumber", "address"},
+		{"shardnumber", "timestamp"},
+	},
+	chartTopMinerRankTbl: {
+		{"shardnumber"},
+	},
+	chartRedoTbl: {
+		{"shardNumber"},
+	},
+	nodeInfoTbl: {
+		{"id"},
+		{"host"},
+		{"shardNumber"},
+	},
+}
+
+//EnsureIndexes create the missing indexes of all collections in the background,
+//the existing ones are left unchanged, so it is cheap to call at startup
+func (c *Client) EnsureIndexes() error {
+	for tbl, indexes := range collectionIndexes {
+		for _, key := range indexes {
+			key := key
+			query := func(c *mgo.Collection) error {
+				return c.EnsureIndex(mgo.Index{
+					Key:        key,
+					Background: true,
+				})
+			}
+			if err := c.withCollection(tbl, query); err != nil {
+				log.Error("[DB] could not create index %s of %s, %v", strings.Join(key, ","), tbl, err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
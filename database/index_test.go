@@ -0,0 +1,80 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//TestIndexKeysExist check every index key is a field of the stored document, a misspelled key
+//creates an index no query uses
+func TestIndexKeysExist(t *testing.T) {
+	docs := map[string]interface{}{
+		blockTbl:                DBBlock{},
+		txTbl:                   DBTx{},
+		pendingTxTbl:            DBTx{},
+		accTbl:                  DBAccount{},
+		receiptTbl:              DBReceipt{},
+		balanceHistoryTbl:       DBBalanceChange{},
+		blockUndoTbl:            DBBlockUndo{},
+		reorgTbl:                DBReorg{},
+		syncCursorTbl:           DBSyncCursor{},
+		statsTbl:                DBShardStats{},
+		addressTxTbl:            DBAddressTx{},
+		addressTxCountTbl:       DBAddressTxCount{},
+		chartTxTbl:              DBOneDayTxInfo{},
+		chartHashRateTbl:        DBOneDayHashRate{},
+		chartBlockDifficultyTbl: DBOneDayBlockDifficulty{},
+		chartBlockAvgTimeTbl:    DBOneDayBlockAvgTime{},
+		chartBlockTbl:           DBOneDayBlockInfo{},
+		chartAddressTbl:         DBOneDayAddressInfo{},
+		chartSingleAddressTbl:   DBOneDaySingleAddressInfo{},
+		chartTopMinerRankTbl:    DBMinerRankInfo{},
+		chartRedoTbl:            DBChartRedo{},
+		nodeInfoTbl:             DBNodeInfo{},
+	}
+
+	for tbl, indexes := range collectionIndexes {
+		doc, ok := docs[tbl]
+		if !ok {
+			t.Errorf("no document type for %s", tbl)
+			continue
+		}
+
+		fields := make(map[string]bool)
+		typ := reflect.TypeOf(doc)
+		bsonFields(typ, fields)
+
+		for _, key := range indexes {
+			for _, k := range key {
+				if !fields[strings.TrimPrefix(k, "-")] {
+					t.Errorf("index key %s of %s is not a field of %s", k, tbl, typ.Name())
+				}
+			}
+		}
+	}
+}
+
+//bsonFields collect the bson names of the fields of the struct type, including the ones of the inlined structs
+func bsonFields(typ reflect.Type, fields map[string]bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		tag := strings.Split(typ.Field(i).Tag.Get("bson"), ",")
+		if len(tag) > 1 && tag[1] == "inline" {
+			bsonFields(typ.Field(i).Type, fields)
+			continue
+		}
+		fields[tag[0]] = true
+	}
+}
+
+func TestMigrationsOrdered(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, m.version, i+1)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package memory
+
+import (
+	"strconv"
+
+	"github.com/seeleteam/scan-api/database"
+)
+
+//matchAddressTx return whether the tx history entry is selected by the filter
+func matchAddressTx(t *database.DBAddressTx, filter *database.AddressTxFilter) bool {
+	if t.Address != filter.Address || t.Pending != filter.Pending {
+		return false
+	}
+
+	switch filter.Direction {
+	case "":
+		return true
+	case database.DirectionIn, database.DirectionOut:
+		return t.Direction == filter.Direction || t.Direction == database.DirectionSelf
+	}
+	return t.Direction == filter.Direction
+}
+
+//latestAddressTx order the tx history entries by time and then by hash, the latest comes first
+func latestAddressTx(a, b interface{}) bool {
+	return compareKey(compareInt(addressTx(a).Time, addressTx(b).Time), addressTx(a).Hash, addressTx(b).Hash) > 0
+}
+
+//AddAddressTxs write the tx history entries of the mined txs in the block at height and count them,
+//the entries of the same address and tx are replaced, and the counters which already count the block are left unchanged
+func (s *Store) AddAddressTxs(shardNumber int, height int64, txs []*database.DBAddressTx) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, t := range txs {
+		err := s.addressTxs.upsert(func(d interface{}) bool {
+			return addressTx(d).Address == t.Address && addressTx(d).Hash == t.Hash
+		}, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, count := range database.CountAddressTxs(shardNumber, height, txs) {
+		exists := false
+		s.addressTxCounts.update(func(d interface{}) bool {
+			c := addressTxCount(d)
+			return c.Address == count.Address && c.ShardNumber == shardNumber
+		}, func(d interface{}) {
+			exists = true
+			c := addressTxCount(d)
+			if c.Height < height {
+				c.Add(count)
+				c.Height = height
+			}
+		})
+
+		if !exists {
+			if err := s.addressTxCounts.insert(count); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//RemoveAddressTxs subtract the tx history entries of the block from the counters and remove them,
+//the counters which do not count the block are left unchanged
+func (s *Store) RemoveAddressTxs(shardNumber int, height uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	block := strconv.FormatUint(height, 10)
+	inBlock := func(d interface{}) bool {
+		t := addressTx(d)
+		return t.ShardNumber == shardNumber && t.Block == block && !t.Pending
+	}
+
+	var txs []*database.DBAddressTx
+	if err := s.addressTxs.find(inBlock).all(&txs); err != nil {
+		return err
+	}
+
+	for _, count := range database.CountAddressTxs(shardNumber, int64(height), txs) {
+		s.addressTxCounts.update(func(d interface{}) bool {
+			c := addressTxCount(d)
+			return c.Address == count.Address && c.ShardNumber == shardNumber && c.Height >= count.Height
+		}, func(d interface{}) {
+			c := addressTxCount(d)
+			c.Add(count.Neg())
+			c.Height = count.Height - 1
+		})
+	}
+
+	s.addressTxs.removeAll(inBlock)
+	return nil
+}
+
+//AddPendingAddressTxs write the tx history entries of a tx in the tx pool, they are not counted
+//and an entry of the mined tx is left unchanged
+func (s *Store) AddPendingAddressTxs(txs []*database.DBAddressTx) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, t := range txs {
+		cnt := s.addressTxs.find(func(d interface{}) bool {
+			return addressTx(d).Address == t.Address && addressTx(d).Hash == t.Hash
+		}).count()
+		if cnt > 0 {
+			continue
+		}
+
+		if err := s.addressTxs.insert(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//RemovePendingAddressTxs remove the tx history entries of a tx which left the tx pool
+func (s *Store) RemovePendingAddressTxs(hash string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.addressTxs.removeAll(func(d interface{}) bool {
+		return addressTx(d).Hash == hash && addressTx(d).Pending
+	})
+	return nil
+}
+
+//GetAddressTxs get the tx history entries selected by the filter, the latest comes first
+func (s *Store) GetAddressTxs(filter *database.AddressTxFilter, skip, limit int) ([]*database.DBAddressTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var txs []*database.DBAddressTx
+	err := s.addressTxs.find(func(d interface{}) bool {
+		return matchAddressTx(addressTx(d), filter)
+	}).sort(latestAddressTx).skipN(skip).limitN(limit).all(&txs)
+	return txs, err
+}
+
+//GetAddressTxsPage get a page of the tx history entries selected by the filter next to the tx with the timestamp
+//and the hash, the latest comes first
+func (s *Store) GetAddressTxsPage(filter *database.AddressTxFilter, timestamp int64, hash string, older bool, limit int) ([]*database.DBAddressTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var txs []*database.DBAddressTx
+	err := s.addressTxs.find(func(d interface{}) bool {
+		return matchAddressTx(addressTx(d), filter)
+	}).sort(latestAddressTx).page(func(d interface{}) int {
+		return compareKey(compareInt(addressTx(d).Time, timestamp), addressTx(d).Hash, hash)
+	}, hash != "", older, limit).all(&txs)
+	return txs, err
+}
+
+//GetAddressTxCount get the counters of the tx history of the address, the counters of all shards are added up
+func (s *Store) GetAddressTxCount(address string) (*database.DBAddressTxCount, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var counts []*database.DBAddressTxCount
+	err := s.addressTxCounts.find(func(d interface{}) bool {
+		return addressTxCount(d).Address == address
+	}).all(&counts)
+
+	total := &database.DBAddressTxCount{Address: address}
+	for _, count := range counts {
+		total.Add(count)
+	}
+	return total, err
+}
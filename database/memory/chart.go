@@ -0,0 +1,440 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package memory
+
+import (
+	"fmt"
+
+	"github.com/seeleteam/scan-api/database"
+	mgo "gopkg.in/mgo.v2"
+)
+
+//chartKey return the shard and the zero hour timestamp of a one day chart row
+func chartKey(d interface{}) (int, int64) {
+	switch row := d.(type) {
+	case *database.DBOneDayTxInfo:
+		return row.ShardNumber, row.TimeStamp
+	case *database.DBOneDayHashRate:
+		return row.ShardNumber, row.TimeStamp
+	case *database.DBOneDayBlockDifficulty:
+		return row.ShardNumber, row.TimeStamp
+	case *database.DBOneDayBlockAvgTime:
+		return row.ShardNumber, row.TimeStamp
+	case *database.DBOneDayBlockInfo:
+		return row.ShardNumber, row.TimeStamp
+	case *database.DBOneDayAddressInfo:
+		return row.ShardNumber, row.TimeStamp
+	case *database.DBOneDaySingleAddressInfo:
+		return row.ShardNumber, row.TimeStamp
+	}
+	panic(fmt.Sprintf("%T is not a one day chart row", d))
+}
+
+func inShard(shardNumber int) func(interface{}) bool {
+	return func(d interface{}) bool {
+		shard, _ := chartKey(d)
+		return shard == shardNumber
+	}
+}
+
+func byChartTime(a, b interface{}) bool {
+	_, ta := chartKey(a)
+	_, tb := chartKey(b)
+	return ta < tb
+}
+
+func (s *Store) addChartRow(c *collection, row interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return c.insert(row)
+}
+
+//getChartRow get the row of the shard by zero hour timestamp
+func (s *Store) getChartRow(c *collection, shardNumber int, zeroTime int64, out interface{}) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return c.find(func(d interface{}) bool {
+		shard, t := chartKey(d)
+		return shard == shardNumber && t == zeroTime
+	}).one(out)
+}
+
+//getChart get the rows in time order, match nil means the rows of all shards
+func (s *Store) getChart(c *collection, match func(interface{}) bool, out interface{}) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return c.find(match).sort(byChartTime).all(out)
+}
+
+//AddChartRedo mark the one day charts of the shard since redo.ZeroTime to be counted again, an existing mark
+//of the shard is extended to the earlier day and the higher height
+func (s *Store) AddChartRedo(redo *database.DBChartRedo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	updated := s.chartRedos.update(func(d interface{}) bool {
+		return chartRedo(d).ShardNumber == redo.ShardNumber
+	}, func(d interface{}) {
+		r := chartRedo(d)
+		if redo.ZeroTime < r.ZeroTime {
+			r.ZeroTime = redo.ZeroTime
+		}
+		if redo.Height > r.Height {
+			r.Height = redo.Height
+		}
+	})
+	if updated > 0 {
+		return nil
+	}
+	return s.chartRedos.insert(redo)
+}
+
+//GetChartRedo get the one day charts of the shard to be counted again, it returns nil if there is none
+func (s *Store) GetChartRedo(shardNumber int) (*database.DBChartRedo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	redo := new(database.DBChartRedo)
+	err := s.chartRedos.find(func(d interface{}) bool {
+		return chartRedo(d).ShardNumber == shardNumber
+	}).one(redo)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return redo, err
+}
+
+//RemoveChartRedo remove the mark after the charts are counted again, a mark changed by a later
+//reorganization in the meantime is kept
+func (s *Store) RemoveChartRedo(redo *database.DBChartRedo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.chartRedos.removeAll(func(d interface{}) bool {
+		r := chartRedo(d)
+		return r.ShardNumber == redo.ShardNumber && r.ZeroTime == redo.ZeroTime && r.Height == redo.Height
+	})
+	return nil
+}
+
+//RemoveChartData remove the one day chart rows of the shard since beginTime, so that they will be counted again
+func (s *Store) RemoveChartData(shardNumber int, beginTime int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	charts := []*collection{
+		&s.chartTxs,
+		&s.chartHashRates,
+		&s.chartDifficulties,
+		&s.chartBlockAvgTimes,
+		&s.chartBlocks,
+		&s.chartAddresses,
+		&s.chartSingleAddresses,
+	}
+
+	for _, c := range charts {
+		c.removeAll(func(d interface{}) bool {
+			shard, t := chartKey(d)
+			return shard == shardNumber && t >= beginTime
+		})
+	}
+	return nil
+}
+
+//AddOneDayTransInfo insert one day transaction info
+func (s *Store) AddOneDayTransInfo(shardNumber int, t *database.DBOneDayTxInfo) error {
+	t.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartTxs, t)
+}
+
+//GetOneDayTransInfo get one day transaction info by zero hour timestamp
+func (s *Store) GetOneDayTransInfo(shardNumber int, zeroTime int64) (*database.DBOneDayTxInfo, error) {
+	row := new(database.DBOneDayTxInfo)
+	err := s.getChartRow(&s.chartTxs, shardNumber, zeroTime, row)
+	return row, err
+}
+
+//GetTransInfoChart get the transaction chart of all shards
+func (s *Store) GetTransInfoChart() ([]*database.DBOneDayTxInfo, error) {
+	var rows []*database.DBOneDayTxInfo
+	err := s.getChart(&s.chartTxs, nil, &rows)
+	return rows, err
+}
+
+//GetTransInfoChartByShardNumber get the transaction chart of the shard
+func (s *Store) GetTransInfoChartByShardNumber(shardNumber int) ([]*database.DBOneDayTxInfo, error) {
+	var rows []*database.DBOneDayTxInfo
+	err := s.getChart(&s.chartTxs, inShard(shardNumber), &rows)
+	return rows, err
+}
+
+//AddOneDayHashRate insert one day hashrate info
+func (s *Store) AddOneDayHashRate(shardNumber int, t *database.DBOneDayHashRate) error {
+	t.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartHashRates, t)
+}
+
+//GetOneDayHashRate get one day hashrate info by zero hour timestamp
+func (s *Store) GetOneDayHashRate(shardNumber int, zeroTime int64) (*database.DBOneDayHashRate, error) {
+	row := new(database.DBOneDayHashRate)
+	err := s.getChartRow(&s.chartHashRates, shardNumber, zeroTime, row)
+	return row, err
+}
+
+//GetHashRateChart get the hashrate chart of all shards
+func (s *Store) GetHashRateChart() ([]*database.DBOneDayHashRate, error) {
+	var rows []*database.DBOneDayHashRate
+	err := s.getChart(&s.chartHashRates, nil, &rows)
+	return rows, err
+}
+
+//GetHashRateChartByShardNumber get the hashrate chart of the shard
+func (s *Store) GetHashRateChartByShardNumber(shardNumber int) ([]*database.DBOneDayHashRate, error) {
+	var rows []*database.DBOneDayHashRate
+	err := s.getChart(&s.chartHashRates, inShard(shardNumber), &rows)
+	return rows, err
+}
+
+//AddOneDayBlockDifficulty insert one day avg block difficulty info
+func (s *Store) AddOneDayBlockDifficulty(shardNumber int, t *database.DBOneDayBlockDifficulty) error {
+	t.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartDifficulties, t)
+}
+
+//GetOneDayBlockDifficulty get one day avg block difficulty info by zero hour timestamp
+func (s *Store) GetOneDayBlockDifficulty(shardNumber int, zeroTime int64) (*database.DBOneDayBlockDifficulty, error) {
+	row := new(database.DBOneDayBlockDifficulty)
+	err := s.getChartRow(&s.chartDifficulties, shardNumber, zeroTime, row)
+	return row, err
+}
+
+//GetOneDayBlockDifficultyChart get the block difficulty chart of all shards
+func (s *Store) GetOneDayBlockDifficultyChart() ([]*database.DBOneDayBlockDifficulty, error) {
+	var rows []*database.DBOneDayBlockDifficulty
+	err := s.getChart(&s.chartDifficulties, nil, &rows)
+	return rows, err
+}
+
+//GetOneDayBlockDifficultyChartByShardNumber get the block difficulty chart of the shard
+func (s *Store) GetOneDayBlockDifficultyChartByShardNumber(shardNumber int) ([]*database.DBOneDayBlockDifficulty, error) {
+	var rows []*database.DBOneDayBlockDifficulty
+	err := s.getChart(&s.chartDifficulties, inShard(shardNumber), &rows)
+	return rows, err
+}
+
+//AddOneDayBlockAvgTime insert one day avg block time info
+func (s *Store) AddOneDayBlockAvgTime(shardNumber int, t *database.DBOneDayBlockAvgTime) error {
+	t.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartBlockAvgTimes, t)
+}
+
+//GetOneDayBlockAvgTime get one day avg block time info by zero hour timestamp
+func (s *Store) GetOneDayBlockAvgTime(shardNumber int, zeroTime int64) (*database.DBOneDayBlockAvgTime, error) {
+	row := new(database.DBOneDayBlockAvgTime)
+	err := s.getChartRow(&s.chartBlockAvgTimes, shardNumber, zeroTime, row)
+	return row, err
+}
+
+//GetOneDayBlockAvgTimeChart get the block time chart of all shards
+func (s *Store) GetOneDayBlockAvgTimeChart() ([]*database.DBOneDayBlockAvgTime, error) {
+	var rows []*database.DBOneDayBlockAvgTime
+	err := s.getChart(&s.chartBlockAvgTimes, nil, &rows)
+	return rows, err
+}
+
+//GetOneDayBlockAvgTimeChartByShardNumber get the block time chart of the shard
+func (s *Store) GetOneDayBlockAvgTimeChartByShardNumber(shardNumber int) ([]*database.DBOneDayBlockAvgTime, error) {
+	var rows []*database.DBOneDayBlockAvgTime
+	err := s.getChart(&s.chartBlockAvgTimes, inShard(shardNumber), &rows)
+	return rows, err
+}
+
+//AddOneDayBlock insert one day block info
+func (s *Store) AddOneDayBlock(shardNumber int, t *database.DBOneDayBlockInfo) error {
+	t.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartBlocks, t)
+}
+
+//GetOneDayBlock get one day block info by zero hour timestamp
+func (s *Store) GetOneDayBlock(shardNumber int, zeroTime int64) (*database.DBOneDayBlockInfo, error) {
+	row := new(database.DBOneDayBlockInfo)
+	err := s.getChartRow(&s.chartBlocks, shardNumber, zeroTime, row)
+	return row, err
+}
+
+//GetOneDayBlocksChart get the block count and reward chart of all shards
+func (s *Store) GetOneDayBlocksChart() ([]*database.DBOneDayBlockInfo, error) {
+	var rows []*database.DBOneDayBlockInfo
+	err := s.getChart(&s.chartBlocks, nil, &rows)
+	return rows, err
+}
+
+//GetOneDayBlocksChartByShardNumber get the block count and reward chart of the shard
+func (s *Store) GetOneDayBlocksChartByShardNumber(shardNumber int) ([]*database.DBOneDayBlockInfo, error) {
+	var rows []*database.DBOneDayBlockInfo
+	err := s.getChart(&s.chartBlocks, inShard(shardNumber), &rows)
+	return rows, err
+}
+
+//AddOneDayAddress insert one day address info
+func (s *Store) AddOneDayAddress(shardNumber int, t *database.DBOneDayAddressInfo) error {
+	t.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartAddresses, t)
+}
+
+//GetOneDayAddress get one day address info by zero hour timestamp
+func (s *Store) GetOneDayAddress(shardNumber int, zeroTime int64) (*database.DBOneDayAddressInfo, error) {
+	row := new(database.DBOneDayAddressInfo)
+	err := s.getChartRow(&s.chartAddresses, shardNumber, zeroTime, row)
+	return row, err
+}
+
+//GetOneDayAddressesChart get the address chart of all shards
+func (s *Store) GetOneDayAddressesChart() ([]*database.DBOneDayAddressInfo, error) {
+	var rows []*database.DBOneDayAddressInfo
+	err := s.getChart(&s.chartAddresses, nil, &rows)
+	return rows, err
+}
+
+//GetOneDayAddressesChartByShardNumber get the address chart of the shard
+func (s *Store) GetOneDayAddressesChartByShardNumber(shardNumber int) ([]*database.DBOneDayAddressInfo, error) {
+	var rows []*database.DBOneDayAddressInfo
+	err := s.getChart(&s.chartAddresses, inShard(shardNumber), &rows)
+	return rows, err
+}
+
+//AddOneDaySingleAddressInfo insert one day single address info
+func (s *Store) AddOneDaySingleAddressInfo(shardNumber int, t *database.DBOneDaySingleAddressInfo) error {
+	t.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartSingleAddresses, t)
+}
+
+//GetOneDaySingleAddressInfo get the single address info of the shard by address
+func (s *Store) GetOneDaySingleAddressInfo(shardNumber int, address string) (*database.DBOneDaySingleAddressInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	row := new(database.DBOneDaySingleAddressInfo)
+	err := s.chartSingleAddresses.find(func(d interface{}) bool {
+		info := d.(*database.DBOneDaySingleAddressInfo)
+		return info.ShardNumber == shardNumber && info.Address == address
+	}).one(row)
+	return row, err
+}
+
+//RemoveTopMinerInfo remove the top miner rank info of all shards
+func (s *Store) RemoveTopMinerInfo() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.chartTopMiners.drop()
+	return nil
+}
+
+//AddTopMinerInfo add top miner rank info
+func (s *Store) AddTopMinerInfo(shardNumber int, rankInfo *database.DBMinerRankInfo) error {
+	rankInfo.ShardNumber = shardNumber
+	return s.addChartRow(&s.chartTopMiners, rankInfo)
+}
+
+//GetTopMinerChart get the top miner rank info of all shards
+func (s *Store) GetTopMinerChart() ([]*database.DBMinerRankInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var rows []*database.DBMinerRankInfo
+	err := s.chartTopMiners.find(nil).all(&rows)
+	return rows, err
+}
+
+//GetTopMinerChartByShardNumber get the top miner rank info of the shard
+func (s *Store) GetTopMinerChartByShardNumber(shardNumber int) ([]*database.DBMinerRankInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var rows []*database.DBMinerRankInfo
+	err := s.chartTopMiners.find(func(d interface{}) bool {
+		return minerRank(d).ShardNumber == shardNumber
+	}).all(&rows)
+	return rows, err
+}
+
+//AddNodeInfo add node info
+func (s *Store) AddNodeInfo(info *database.DBNodeInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.nodeInfos.insert(info)
+}
+
+//DeleteNodeInfo delete the node info of the same id
+func (s *Store) DeleteNodeInfo(info *database.DBNodeInfo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.nodeInfos.remove(func(d interface{}) bool {
+		return nodeInfo(d).ID == info.ID
+	})
+}
+
+//GetNodeInfo get node info by host
+func (s *Store) GetNodeInfo(host string) (*database.DBNodeInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	info := new(database.DBNodeInfo)
+	err := s.nodeInfos.find(func(d interface{}) bool {
+		return nodeInfo(d).Host == host
+	}).one(info)
+	return info, err
+}
+
+//GetNodeInfoByID get node info by node id
+func (s *Store) GetNodeInfoByID(id string) (*database.DBNodeInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	info := new(database.DBNodeInfo)
+	err := s.nodeInfos.find(func(d interface{}) bool {
+		return nodeInfo(d).ID == id
+	}).one(info)
+	return info, err
+}
+
+//GetNodeInfosByShardNumber get all node infos of the shard
+func (s *Store) GetNodeInfosByShardNumber(shardNumber int) ([]*database.DBNodeInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var infos []*database.DBNodeInfo
+	err := s.nodeInfos.find(func(d interface{}) bool {
+		return nodeInfo(d).ShardNumber == shardNumber
+	}).all(&infos)
+	return infos, err
+}
+
+//GetNodeInfos get all node infos
+func (s *Store) GetNodeInfos() ([]*database.DBNodeInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var infos []*database.DBNodeInfo
+	err := s.nodeInfos.find(nil).all(&infos)
+	return infos, err
+}
+
+//GetNodeCntByShardNumber get the node count of the shard
+func (s *Store) GetNodeCntByShardNumber(shardNumber int) (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint64(s.nodeInfos.find(func(d interface{}) bool {
+		return nodeInfo(d).ShardNumber == shardNumber
+	}).count()), nil
+}
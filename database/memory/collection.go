@@ -0,0 +1,234 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+//collection is a list of documents in insertion order, which is the natural order of mongo
+type collection struct {
+	docs []interface{}
+}
+
+//query select the documents of a collection the way mgo.Query does
+type query struct {
+	docs  []interface{}
+	skip  int
+	limit int
+}
+
+//clone copy the document through bson the way mongo stores and loads it, so the amounts out of
+//the range of Decimal128 are rejected and the caller never shares memory with the store
+func clone(in, out interface{}) error {
+	data, err := bson.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, out)
+}
+
+//newDoc return a copy of the document
+func newDoc(doc interface{}) (interface{}, error) {
+	t := reflect.TypeOf(doc)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("document must be a struct pointer, got %T", doc)
+	}
+
+	copied := reflect.New(t.Elem()).Interface()
+	if err := clone(doc, copied); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+//insert add a copy of the document
+func (c *collection) insert(doc interface{}) error {
+	copied, err := newDoc(doc)
+	if err != nil {
+		return err
+	}
+
+	c.docs = append(c.docs, copied)
+	return nil
+}
+
+//upsert replace the first matched document with a copy of doc, doc is inserted if none matches
+func (c *collection) upsert(match func(interface{}) bool, doc interface{}) error {
+	copied, err := newDoc(doc)
+	if err != nil {
+		return err
+	}
+
+	for i, d := range c.docs {
+		if match(d) {
+			c.docs[i] = copied
+			return nil
+		}
+	}
+
+	c.docs = append(c.docs, copied)
+	return nil
+}
+
+//update modify all the matched documents and return the number of them
+func (c *collection) update(match func(interface{}) bool, modify func(interface{})) int {
+	updated := 0
+	for _, d := range c.docs {
+		if match(d) {
+			modify(d)
+			updated++
+		}
+	}
+	return updated
+}
+
+//remove remove the first matched document, mgo.ErrNotFound is returned if none matches
+func (c *collection) remove(match func(interface{}) bool) error {
+	for i, d := range c.docs {
+		if match(d) {
+			c.docs = append(c.docs[:i], c.docs[i+1:]...)
+			return nil
+		}
+	}
+	return mgo.ErrNotFound
+}
+
+//removeAll remove all the matched documents
+func (c *collection) removeAll(match func(interface{}) bool) {
+	kept := c.docs[:0]
+	for _, d := range c.docs {
+		if !match(d) {
+			kept = append(kept, d)
+		}
+	}
+
+	//release the removed documents
+	for i := len(kept); i < len(c.docs); i++ {
+		c.docs[i] = nil
+	}
+	c.docs = kept
+}
+
+//drop remove all the documents
+func (c *collection) drop() {
+	c.docs = nil
+}
+
+//find return the query of the matched documents, nil matches all
+func (c *collection) find(match func(interface{}) bool) *query {
+	q := &query{}
+	for _, d := range c.docs {
+		if match == nil || match(d) {
+			q.docs = append(q.docs, d)
+		}
+	}
+	return q
+}
+
+//sort order the documents, documents which are equal keep their natural order
+func (q *query) sort(less func(a, b interface{}) bool) *query {
+	sort.SliceStable(q.docs, func(i, j int) bool {
+		return less(q.docs[i], q.docs[j])
+	})
+	return q
+}
+
+//page keep the documents of a page next to a key, the documents must be in list order. cmp compare a document
+//with the key, it is negative if the document follows the key. An older page follows the key and a newer
+//page precedes it, both keep the n documents closest to the key. Without a key, the page starts from the
+//first document, or the last one for a newer page
+func (q *query) page(cmp func(d interface{}) int, hasKey, older bool, n int) *query {
+	var docs []interface{}
+	for _, d := range q.docs {
+		if !hasKey || (older && cmp(d) < 0) || (!older && cmp(d) > 0) {
+			docs = append(docs, d)
+		}
+	}
+
+	if n > 0 && n < len(docs) {
+		if older {
+			docs = docs[:n]
+		} else {
+			docs = docs[len(docs)-n:]
+		}
+	}
+	q.docs = docs
+	return q
+}
+
+//skipN skip the first n documents
+func (q *query) skipN(n int) *query {
+	q.skip = n
+	return q
+}
+
+//limitN return at most n documents, 0 means no limit like mongo
+func (q *query) limitN(n int) *query {
+	q.limit = n
+	return q
+}
+
+//selected return the documents after skip and limit
+func (q *query) selected() []interface{} {
+	docs := q.docs
+	if q.skip > 0 {
+		if q.skip >= len(docs) {
+			return nil
+		}
+		docs = docs[q.skip:]
+	}
+
+	if q.limit > 0 && q.limit < len(docs) {
+		docs = docs[:q.limit]
+	}
+	return docs
+}
+
+//count return the number of the selected documents
+func (q *query) count() int {
+	return len(q.selected())
+}
+
+//one copy the first selected document into out, mgo.ErrNotFound is returned if there is none
+func (q *query) one(out interface{}) error {
+	docs := q.selected()
+	if len(docs) == 0 {
+		return mgo.ErrNotFound
+	}
+	return clone(docs[0], out)
+}
+
+//all copy the selected documents into out, which is a pointer to a slice of struct pointers
+func (q *query) all(out interface{}) error {
+	slicev := reflect.ValueOf(out)
+	if slicev.Kind() != reflect.Ptr || slicev.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("result must be a slice pointer, got %T", out)
+	}
+
+	slicev = slicev.Elem()
+	elemt := slicev.Type().Elem()
+	if elemt.Kind() != reflect.Ptr {
+		return fmt.Errorf("result must be a slice of pointers, got %T", out)
+	}
+
+	result := reflect.Zero(slicev.Type())
+	for _, d := range q.selected() {
+		elem := reflect.New(elemt.Elem())
+		if err := clone(d, elem.Interface()); err != nil {
+			return err
+		}
+		result = reflect.Append(result, elem)
+	}
+
+	slicev.Set(result)
+	return nil
+}
@@ -0,0 +1,68 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package memory
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/seeleteam/scan-api/database"
+)
+
+//compareKey compare the sort value and then the id of an item with a key, cmpValue is the result of comparing the values
+func compareKey(cmpValue int, id, keyID string) int {
+	if cmpValue != 0 {
+		return cmpValue
+	}
+	return strings.Compare(id, keyID)
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+//GetAccountsPage get a page of the accounts of the shard next to the account with the balance and the address,
+//the richest comes first
+func (s *Store) GetAccountsPage(shardNumber int, balance *big.Int, address string, older bool, limit int) ([]*database.DBAccount, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	key := database.NewBigInt(balance)
+	var accounts []*database.DBAccount
+	err := s.accounts.find(func(d interface{}) bool {
+		a := account(d)
+		return a.AccType == 0 && a.ShardNumber == shardNumber
+	}).sort(func(a, b interface{}) bool {
+		return compareKey(account(a).Balance.Cmp(&account(b).Balance.Int), account(a).Address, account(b).Address) > 0
+	}).page(func(d interface{}) int {
+		return compareKey(account(d).Balance.Cmp(&key.Int), account(d).Address, address)
+	}, address != "", older, limit).all(&accounts)
+	return accounts, err
+}
+
+//GetContractsPage get a page of the contracts of the shard next to the contract with the timestamp and the address,
+//the latest comes first
+func (s *Store) GetContractsPage(shardNumber int, timestamp int64, address string, older bool, limit int) ([]*database.DBAccount, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var accounts []*database.DBAccount
+	err := s.accounts.find(func(d interface{}) bool {
+		a := account(d)
+		return a.AccType == 1 && a.ShardNumber == shardNumber
+	}).sort(func(a, b interface{}) bool {
+		return compareKey(compareInt(account(a).TimeStamp, account(b).TimeStamp), account(a).Address, account(b).Address) > 0
+	}).page(func(d interface{}) int {
+		return compareKey(compareInt(account(d).TimeStamp, timestamp), account(d).Address, address)
+	}, address != "", older, limit).all(&accounts)
+	return accounts, err
+}
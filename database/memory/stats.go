@@ -0,0 +1,125 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package memory
+
+import (
+	"github.com/seeleteam/scan-api/database"
+	mgo "gopkg.in/mgo.v2"
+)
+
+//GetStats get the stats of all shards ordered by shard number
+func (s *Store) GetStats() ([]*database.DBShardStats, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var stats []*database.DBShardStats
+	err := s.stats.find(nil).sort(func(a, b interface{}) bool {
+		return shardStats(a).ShardNumber < shardStats(b).ShardNumber
+	}).all(&stats)
+	return stats, err
+}
+
+//GetShardStats get the stats of the shard, they are empty if no block of the shard is counted yet
+func (s *Store) GetShardStats(shardNumber int) (*database.DBShardStats, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	stats := new(database.DBShardStats)
+	err := s.stats.find(func(d interface{}) bool {
+		return shardStats(d).ShardNumber == shardNumber
+	}).one(stats)
+	if err == mgo.ErrNotFound {
+		return &database.DBShardStats{ShardNumber: shardNumber, Height: -1}, nil
+	}
+	return stats, err
+}
+
+//ApplyStats add the delta of the block at height to the stats of the shard,
+//the stats which already count the block are left unchanged
+func (s *Store) ApplyStats(shardNumber int, height int64, delta *database.DBStatsDelta) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	exists := false
+	s.stats.update(func(d interface{}) bool {
+		return shardStats(d).ShardNumber == shardNumber
+	}, func(d interface{}) {
+		exists = true
+		stats := shardStats(d)
+		if stats.Height < height {
+			stats.Add(delta)
+			stats.Height = height
+		}
+	})
+
+	if exists {
+		return nil
+	}
+	return s.stats.insert(&database.DBShardStats{ShardNumber: shardNumber, Height: height, DBStatsDelta: *delta})
+}
+
+//RevertStats subtract the delta of the block at height from the stats of the shard,
+//the stats which do not count the block are left unchanged
+func (s *Store) RevertStats(shardNumber int, height int64, delta *database.DBStatsDelta) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats.update(func(d interface{}) bool {
+		stats := shardStats(d)
+		return stats.ShardNumber == shardNumber && stats.Height >= height
+	}, func(d interface{}) {
+		stats := shardStats(d)
+		stats.Add(delta.Neg())
+		stats.Height = height - 1
+	})
+	return nil
+}
+
+//SetStats replace the stats of the shard
+func (s *Store) SetStats(stats *database.DBShardStats) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.stats.upsert(func(d interface{}) bool {
+		return shardStats(d).ShardNumber == stats.ShardNumber
+	}, stats)
+}
+
+//RecountStats count the stats of the shard from the stored blocks, txs and accounts and replace the maintained ones
+func (s *Store) RecountStats(shardNumber int) error {
+	s.lock.RLock()
+	stats := &database.DBShardStats{ShardNumber: shardNumber, Height: -1}
+	for _, d := range s.blocks.docs {
+		if b := block(d); b.ShardNumber == shardNumber {
+			stats.Blocks++
+			if b.Height > stats.Height {
+				stats.Height = b.Height
+			}
+		}
+	}
+
+	stats.Txs = int64(s.txs.find(func(d interface{}) bool {
+		return tx(d).ShardNumber == shardNumber
+	}).count())
+
+	for _, d := range s.accounts.docs {
+		a := account(d)
+		if a.ShardNumber != shardNumber {
+			continue
+		}
+
+		switch a.AccType {
+		case 0:
+			stats.Accounts++
+		case 1:
+			stats.Contracts++
+		}
+		stats.TotalBalance.Add(&stats.TotalBalance.Int, &a.Balance.Int)
+	}
+	s.lock.RUnlock()
+
+	return s.SetStats(stats)
+}
@@ -0,0 +1,757 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package memory
+
+import (
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/seeleteam/scan-api/database"
+	mgo "gopkg.in/mgo.v2"
+)
+
+//Store is an in-memory storage backend. It implements the same repository interfaces as
+//database.Client with the same sorting, pagination and not-found semantics, so the syncer,
+//the api handlers and the chart and node services run on it without a mongodb
+type Store struct {
+	lock sync.RWMutex
+
+	blocks     collection
+	txs        collection
+	pendingTxs collection
+	receipts   collection
+	balances   collection
+	accounts   collection
+	blockUndos collection
+	reorgs     collection
+	cursors    collection
+	stats      collection
+
+	addressTxs      collection
+	addressTxCounts collection
+
+	chartTxs             collection
+	chartHashRates       collection
+	chartDifficulties    collection
+	chartBlockAvgTimes   collection
+	chartBlocks          collection
+	chartAddresses       collection
+	chartSingleAddresses collection
+	chartTopMiners       collection
+	chartRedos           collection
+
+	nodeInfos collection
+}
+
+//NewStore return an empty store
+func NewStore() *Store {
+	return &Store{}
+}
+
+func block(d interface{}) *database.DBBlock             { return d.(*database.DBBlock) }
+func tx(d interface{}) *database.DBTx                   { return d.(*database.DBTx) }
+func receipt(d interface{}) *database.DBReceipt         { return d.(*database.DBReceipt) }
+func balance(d interface{}) *database.DBBalanceChange   { return d.(*database.DBBalanceChange) }
+func account(d interface{}) *database.DBAccount         { return d.(*database.DBAccount) }
+func blockUndo(d interface{}) *database.DBBlockUndo     { return d.(*database.DBBlockUndo) }
+func reorg(d interface{}) *database.DBReorg             { return d.(*database.DBReorg) }
+func syncCursor(d interface{}) *database.DBSyncCursor   { return d.(*database.DBSyncCursor) }
+func shardStats(d interface{}) *database.DBShardStats   { return d.(*database.DBShardStats) }
+func nodeInfo(d interface{}) *database.DBNodeInfo       { return d.(*database.DBNodeInfo) }
+func minerRank(d interface{}) *database.DBMinerRankInfo { return d.(*database.DBMinerRankInfo) }
+
+func addressTx(d interface{}) *database.DBAddressTx           { return d.(*database.DBAddressTx) }
+func addressTxCount(d interface{}) *database.DBAddressTxCount { return d.(*database.DBAddressTxCount) }
+func chartRedo(d interface{}) *database.DBChartRedo           { return d.(*database.DBChartRedo) }
+
+//isPoolPending return whether the tx is still in the tx pool, txs written before the pool state
+//existed have no state and are treated as pending
+func isPoolPending(t *database.DBTx) bool {
+	return t.PoolState != database.PoolStateMined && t.PoolState != database.PoolStateDropped
+}
+
+//involves return whether the tx is sent from or to the address
+func involves(t *database.DBTx, address string) bool {
+	return t.From == address || t.To == address
+}
+
+//AddBlock insert a block, the block with the same hash is replaced
+func (s *Store) AddBlock(b *database.DBBlock) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.blocks.upsert(func(d interface{}) bool {
+		return block(d).HeadHash == b.HeadHash
+	}, b)
+}
+
+//RemoveBlock remove block by height
+func (s *Store) RemoveBlock(shardNumber int, height uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.blocks.removeAll(func(d interface{}) bool {
+		b := block(d)
+		return b.ShardNumber == shardNumber && b.Height == int64(height)
+	})
+	return nil
+}
+
+//GetBlockByHeight get block by block height
+func (s *Store) GetBlockByHeight(shardNumber int, height uint64) (*database.DBBlock, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	b := new(database.DBBlock)
+	err := s.blocks.find(func(d interface{}) bool {
+		b := block(d)
+		return b.ShardNumber == shardNumber && b.Height == int64(height)
+	}).one(b)
+	return b, err
+}
+
+//GetBlockByHash get a block by block header hash
+func (s *Store) GetBlockByHash(hash string) (*database.DBBlock, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	b := new(database.DBBlock)
+	err := s.blocks.find(func(d interface{}) bool {
+		return block(d).HeadHash == hash
+	}).one(b)
+	return b, err
+}
+
+//GetBlocksByHeight get a block list by height range, the highest comes first
+func (s *Store) GetBlocksByHeight(shardNumber int, begin uint64, end uint64) ([]*database.DBBlock, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var blocks []*database.DBBlock
+	err := s.blocks.find(func(d interface{}) bool {
+		b := block(d)
+		return b.ShardNumber == shardNumber && b.Height >= int64(begin) && b.Height < int64(end)
+	}).sort(func(a, b interface{}) bool {
+		return block(a).Height > block(b).Height
+	}).all(&blocks)
+	return blocks, err
+}
+
+//GetBlocksByTime get a block list by time period
+func (s *Store) GetBlocksByTime(shardNumber int, beginTime, endTime int64) ([]*database.DBBlock, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var blocks []*database.DBBlock
+	err := s.blocks.find(func(d interface{}) bool {
+		b := block(d)
+		return b.ShardNumber == shardNumber && b.Timestamp >= beginTime && b.Timestamp <= endTime
+	}).all(&blocks)
+	return blocks, err
+}
+
+//GetBlockHeight get the block count of the shard
+func (s *Store) GetBlockHeight(shardNumber int) (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint64(s.blocks.find(func(d interface{}) bool {
+		return block(d).ShardNumber == shardNumber
+	}).count()), nil
+}
+
+//GetBlockCnt get the block count of all shards
+func (s *Store) GetBlockCnt() (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint64(s.blocks.find(nil).count()), nil
+}
+
+//GetMinedBlocksCntByShardNumberAndAddress get the count of the blocks mined by the address
+func (s *Store) GetMinedBlocksCntByShardNumberAndAddress(shardNumber int, address string) (int64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return int64(s.blocks.find(func(d interface{}) bool {
+		b := block(d)
+		return b.ShardNumber == shardNumber && b.Creator == address
+	}).count()), nil
+}
+
+//AddTx insert a transaction, the transaction with the same hash is replaced
+func (s *Store) AddTx(t *database.DBTx) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.txs.upsert(func(d interface{}) bool {
+		return tx(d).Hash == t.Hash
+	}, t)
+}
+
+//RemoveTxs remove all txs in the block
+func (s *Store) RemoveTxs(shardNumber int, blockHeight uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	height := strconv.FormatUint(blockHeight, 10)
+	s.txs.removeAll(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && t.Block == height
+	})
+	return nil
+}
+
+//GetTxsByBlock get all txs in the block in idx order
+func (s *Store) GetTxsByBlock(shardNumber int, blockHeight uint64) ([]*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	height := strconv.FormatUint(blockHeight, 10)
+	var txs []*database.DBTx
+	err := s.txs.find(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && t.Block == height
+	}).sort(func(a, b interface{}) bool {
+		return tx(a).Idx < tx(b).Idx
+	}).all(&txs)
+	return txs, err
+}
+
+//GetTxByIdx get transaction by idx
+func (s *Store) GetTxByIdx(idx uint64) (*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t := new(database.DBTx)
+	err := s.txs.find(func(d interface{}) bool {
+		return tx(d).Idx == int64(idx)
+	}).one(t)
+	return t, err
+}
+
+//GetTxsByIdx get a transaction list by idx range, the latest comes first
+func (s *Store) GetTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var txs []*database.DBTx
+	err := s.txs.find(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && t.Idx >= int64(begin) && t.Idx < int64(end)
+	}).sort(func(a, b interface{}) bool {
+		return tx(a).Idx > tx(b).Idx
+	}).all(&txs)
+	return txs, err
+}
+
+//GetTxByHash get transaction info by hash
+func (s *Store) GetTxByHash(hash string) (*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t := new(database.DBTx)
+	err := s.txs.find(func(d interface{}) bool {
+		return tx(d).Hash == hash
+	}).one(t)
+	return t, err
+}
+
+//GetTxCnt get the tx count of all shards
+func (s *Store) GetTxCnt() (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint64(s.txs.find(nil).count()), nil
+}
+
+//GetTxCntByShardNumber get tx count by shardNumber
+func (s *Store) GetTxCntByShardNumber(shardNumber int) (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint64(s.txs.find(func(d interface{}) bool {
+		return tx(d).ShardNumber == shardNumber
+	}).count()), nil
+}
+
+//GetTxCntByShardNumberAndAddress get tx count for the account
+func (s *Store) GetTxCntByShardNumberAndAddress(shardNumber int, address string) (int64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return int64(s.txs.find(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && involves(t, address)
+	}).count()), nil
+}
+
+//GetTxsByAddresss return a tx list by address, the latest comes first. The timestamp is a
+//string, so the txs are sorted by its text like mongo does
+func (s *Store) GetTxsByAddresss(address string, max int) ([]*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var txs []*database.DBTx
+	err := s.txs.find(func(d interface{}) bool {
+		return involves(tx(d), address)
+	}).sort(func(a, b interface{}) bool {
+		return tx(a).Timestamp > tx(b).Timestamp
+	}).limitN(max).all(&txs)
+	return txs, err
+}
+
+//AddPendingTx insert a pending transaction, the transaction with the same hash is replaced
+func (s *Store) AddPendingTx(t *database.DBTx) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.pendingTxs.upsert(func(d interface{}) bool {
+		return tx(d).Hash == t.Hash
+	}, t)
+}
+
+//RemoveAllPendingTxs remove all pending transactions
+func (s *Store) RemoveAllPendingTxs() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pendingTxs.drop()
+	return nil
+}
+
+//GetPendingTxs get the txs still in the tx pool, the latest seen comes first
+func (s *Store) GetPendingTxs(shardNumber int, skip, limit int) ([]*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var txs []*database.DBTx
+	err := s.pendingTxs.find(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && isPoolPending(t)
+	}).sort(func(a, b interface{}) bool {
+		ta, tb := tx(a), tx(b)
+		if ta.FirstSeen != tb.FirstSeen {
+			return ta.FirstSeen > tb.FirstSeen
+		}
+		return ta.Idx > tb.Idx
+	}).skipN(skip).limitN(limit).all(&txs)
+	return txs, err
+}
+
+//GetAllPendingTxs get all the txs still in the tx pool of the shard
+func (s *Store) GetAllPendingTxs(shardNumber int) ([]*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var txs []*database.DBTx
+	err := s.pendingTxs.find(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && isPoolPending(t)
+	}).all(&txs)
+	return txs, err
+}
+
+//GetPendingTxsByAddress return the txs of the address still in the tx pool, the latest comes first
+func (s *Store) GetPendingTxsByAddress(address string) ([]*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var txs []*database.DBTx
+	err := s.pendingTxs.find(func(d interface{}) bool {
+		t := tx(d)
+		return isPoolPending(t) && involves(t, address)
+	}).sort(func(a, b interface{}) bool {
+		return tx(a).Timestamp > tx(b).Timestamp
+	}).all(&txs)
+	return txs, err
+}
+
+//GetPendingTxByHash get a tx seen in the tx pool by hash, it may have been mined or dropped
+func (s *Store) GetPendingTxByHash(hash string) (*database.DBTx, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t := new(database.DBTx)
+	err := s.pendingTxs.find(func(d interface{}) bool {
+		return tx(d).Hash == hash
+	}).one(t)
+	return t, err
+}
+
+//GetPendingTxCntByShardNumber get the count of txs still in the tx pool
+func (s *Store) GetPendingTxCntByShardNumber(shardNumber int) (uint64, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint64(s.pendingTxs.find(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && isPoolPending(t)
+	}).count()), nil
+}
+
+//UpdatePendingTxState move the pool tx to the given state, the tx already in that state is left unchanged
+func (s *Store) UpdatePendingTxState(hash string, state string, leftPoolTime, inclusionTime int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pendingTxs.update(func(d interface{}) bool {
+		t := tx(d)
+		return t.Hash == hash && t.PoolState != state
+	}, func(d interface{}) {
+		t := tx(d)
+		t.PoolState = state
+		t.LeftPoolTime = leftPoolTime
+		t.InclusionTime = inclusionTime
+	})
+	return nil
+}
+
+//RemovePoolTxsBefore remove the mined and dropped pool txs which left the pool before the given time
+func (s *Store) RemovePoolTxsBefore(shardNumber int, before int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pendingTxs.removeAll(func(d interface{}) bool {
+		t := tx(d)
+		return t.ShardNumber == shardNumber && !isPoolPending(t) && t.LeftPoolTime < before
+	})
+	return nil
+}
+
+//AddReceipt insert a transaction receipt, the receipt of the same tx is replaced
+func (s *Store) AddReceipt(r *database.DBReceipt) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.receipts.upsert(func(d interface{}) bool {
+		return receipt(d).TxHash == r.TxHash
+	}, r)
+}
+
+//GetReceiptByTxHash get the receipt of a transaction
+func (s *Store) GetReceiptByTxHash(txHash string) (*database.DBReceipt, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	r := new(database.DBReceipt)
+	err := s.receipts.find(func(d interface{}) bool {
+		return receipt(d).TxHash == txHash
+	}).one(r)
+	return r, err
+}
+
+//RemoveReceipts remove the receipts of all txs in the block
+func (s *Store) RemoveReceipts(shardNumber int, blockHeight uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.receipts.removeAll(func(d interface{}) bool {
+		r := receipt(d)
+		return r.ShardNumber == shardNumber && r.BlockHeight == int64(blockHeight)
+	})
+	return nil
+}
+
+//AddBalanceChange insert or replace the balance change of the account in the block
+func (s *Store) AddBalanceChange(change *database.DBBalanceChange) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.balances.upsert(func(d interface{}) bool {
+		c := balance(d)
+		return c.Address == change.Address && c.Height == change.Height
+	}, change)
+}
+
+//RemoveBalanceChanges remove the balance changes made by the block
+func (s *Store) RemoveBalanceChanges(shardNumber int, height uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.balances.removeAll(func(d interface{}) bool {
+		c := balance(d)
+		return c.ShardNumber == shardNumber && c.Height == int64(height)
+	})
+	return nil
+}
+
+//GetBalanceAtHeight get the last balance change of the account at or before the height,
+//it returns nil if the account had not been touched by then
+func (s *Store) GetBalanceAtHeight(address string, height uint64) (*database.DBBalanceChange, error) {
+	return s.lastBalanceChange(func(c *database.DBBalanceChange) bool {
+		return c.Address == address && c.Height <= int64(height)
+	})
+}
+
+//GetBalanceAtTime get the last balance change of the account at or before the timestamp,
+//it returns nil if the account had not been touched by then
+func (s *Store) GetBalanceAtTime(address string, timestamp int64) (*database.DBBalanceChange, error) {
+	return s.lastBalanceChange(func(c *database.DBBalanceChange) bool {
+		return c.Address == address && c.Timestamp <= timestamp
+	})
+}
+
+func (s *Store) lastBalanceChange(match func(*database.DBBalanceChange) bool) (*database.DBBalanceChange, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	change := new(database.DBBalanceChange)
+	err := s.balances.find(func(d interface{}) bool {
+		return match(balance(d))
+	}).sort(func(a, b interface{}) bool {
+		return balance(a).Height > balance(b).Height
+	}).one(change)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return change, err
+}
+
+//GetBalanceHistory get the balance changes of the account between the begin and end timestamp (both included),
+//at most max changes are returned in height order
+func (s *Store) GetBalanceHistory(address string, begin, end int64, max int) ([]*database.DBBalanceChange, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var changes []*database.DBBalanceChange
+	err := s.balances.find(func(d interface{}) bool {
+		c := balance(d)
+		return c.Address == address && c.Timestamp >= begin && c.Timestamp <= end
+	}).sort(func(a, b interface{}) bool {
+		return balance(a).Height < balance(b).Height
+	}).limitN(max).all(&changes)
+	return changes, err
+}
+
+//GetAccountByAddress get an account by address
+func (s *Store) GetAccountByAddress(address string) (*database.DBAccount, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	a := new(database.DBAccount)
+	err := s.accounts.find(func(d interface{}) bool {
+		return account(d).Address == address
+	}).one(a)
+	return a, err
+}
+
+//AddAccount insert an account
+func (s *Store) AddAccount(a *database.DBAccount) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.accounts.insert(a)
+}
+
+//UpdateAccount replace the account of the same address, the account is inserted if there is none
+func (s *Store) UpdateAccount(a *database.DBAccount) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.accounts.upsert(func(d interface{}) bool {
+		return account(d).Address == a.Address
+	}, a)
+}
+
+//UpdateAccountMinedBlock update field mined block in the account info
+func (s *Store) UpdateAccountMinedBlock(address string, mined int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, d := range s.accounts.docs {
+		if a := account(d); a.Address == address {
+			a.Mined = mined
+			return nil
+		}
+	}
+	return mgo.ErrNotFound
+}
+
+//RemoveAccount remove account by address
+func (s *Store) RemoveAccount(address string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.accounts.remove(func(d interface{}) bool {
+		return account(d).Address == address
+	})
+}
+
+//countAccounts return the number of the accounts of the type, shardNumber 0 means all shards
+func (s *Store) countAccounts(accType, shardNumber int) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return uint64(s.accounts.find(func(d interface{}) bool {
+		a := account(d)
+		return a.AccType == accType && (shardNumber == 0 || a.ShardNumber == shardNumber)
+	}).count())
+}
+
+//GetAccountCnt get account count
+func (s *Store) GetAccountCnt() (uint64, error) {
+	return s.countAccounts(0, 0), nil
+}
+
+//GetContractCnt get contract count
+func (s *Store) GetContractCnt() (uint64, error) {
+	return s.countAccounts(1, 0), nil
+}
+
+//GetAccountCntByShardNumber get account count of the shard
+func (s *Store) GetAccountCntByShardNumber(shardNumber int) (uint64, error) {
+	return s.countAccounts(0, shardNumber), nil
+}
+
+//GetContractCntByShardNumber get contract count of the shard
+func (s *Store) GetContractCntByShardNumber(shardNumber int) (uint64, error) {
+	return s.countAccounts(1, shardNumber), nil
+}
+
+//GetAccountsByShardNumber get an account list sort by balance
+func (s *Store) GetAccountsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var accounts []*database.DBAccount
+	err := s.accounts.find(func(d interface{}) bool {
+		a := account(d)
+		return a.AccType == 0 && a.ShardNumber == shardNumber
+	}).sort(func(a, b interface{}) bool {
+		return account(a).Balance.Cmp(&account(b).Balance.Int) > 0
+	}).limitN(max).all(&accounts)
+	return accounts, err
+}
+
+//GetContractsByShardNumber get a contract list, the latest comes first
+func (s *Store) GetContractsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var accounts []*database.DBAccount
+	err := s.accounts.find(func(d interface{}) bool {
+		a := account(d)
+		return a.AccType == 1 && a.ShardNumber == shardNumber
+	}).sort(func(a, b interface{}) bool {
+		return account(a).TimeStamp > account(b).TimeStamp
+	}).limitN(max).all(&accounts)
+	return accounts, err
+}
+
+//GetTotalBalance return the sum of all account balances of each shard
+func (s *Store) GetTotalBalance() (map[int]*big.Int, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	totalBalance := make(map[int]*big.Int)
+	for _, d := range s.accounts.docs {
+		a := account(d)
+		total, ok := totalBalance[a.ShardNumber]
+		if !ok {
+			total = new(big.Int)
+			totalBalance[a.ShardNumber] = total
+		}
+		total.Add(total, &a.Balance.Int)
+	}
+	return totalBalance, nil
+}
+
+//AddBlockUndo insert the undo journal of a block
+func (s *Store) AddBlockUndo(undo *database.DBBlockUndo) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.blockUndos.upsert(func(d interface{}) bool {
+		u := blockUndo(d)
+		return u.ShardNumber == undo.ShardNumber && u.Height == undo.Height
+	}, undo)
+}
+
+//GetBlockUndo get the undo journal of the block by height
+func (s *Store) GetBlockUndo(shardNumber int, height uint64) (*database.DBBlockUndo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	undo := new(database.DBBlockUndo)
+	err := s.blockUndos.find(func(d interface{}) bool {
+		u := blockUndo(d)
+		return u.ShardNumber == shardNumber && u.Height == int64(height)
+	}).one(undo)
+	return undo, err
+}
+
+//RemoveBlockUndo remove the undo journal of the block by height
+func (s *Store) RemoveBlockUndo(shardNumber int, height uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.blockUndos.removeAll(func(d interface{}) bool {
+		u := blockUndo(d)
+		return u.ShardNumber == shardNumber && u.Height == int64(height)
+	})
+	return nil
+}
+
+//RemoveBlockUndosBefore remove the undo journals of the blocks lower than height
+func (s *Store) RemoveBlockUndosBefore(shardNumber int, height uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.blockUndos.removeAll(func(d interface{}) bool {
+		u := blockUndo(d)
+		return u.ShardNumber == shardNumber && u.Height < int64(height)
+	})
+	return nil
+}
+
+//GetSyncCursor get the sync cursor of the shard, it returns nil if the shard has no cursor yet
+func (s *Store) GetSyncCursor(shardNumber int) (*database.DBSyncCursor, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	cursor := new(database.DBSyncCursor)
+	err := s.cursors.find(func(d interface{}) bool {
+		return syncCursor(d).ShardNumber == shardNumber
+	}).one(cursor)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return cursor, err
+}
+
+//SetSyncCursor update the sync cursor of the shard
+func (s *Store) SetSyncCursor(cursor *database.DBSyncCursor) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.cursors.upsert(func(d interface{}) bool {
+		return syncCursor(d).ShardNumber == cursor.ShardNumber
+	}, cursor)
+}
+
+//AddReorg insert a chain reorganization event
+func (s *Store) AddReorg(r *database.DBReorg) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.reorgs.insert(r)
+}
+
+//GetReorgs get the latest chain reorganization events of the shard
+func (s *Store) GetReorgs(shardNumber int, max int) ([]*database.DBReorg, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var reorgs []*database.DBReorg
+	err := s.reorgs.find(func(d interface{}) bool {
+		return reorg(d).ShardNumber == shardNumber
+	}).sort(func(a, b interface{}) bool {
+		return reorg(a).Timestamp > reorg(b).Timestamp
+	}).limitN(max).all(&reorgs)
+	return reorgs, err
+}
@@ -0,0 +1,18 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/seeleteam/scan-api/database/dbtest"
+)
+
+func TestConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) dbtest.Database {
+		return NewStore()
+	})
+}
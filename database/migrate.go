@@ -0,0 +1,84 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"github.com/seeleteam/scan-api/log"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	bsonTypeDecimal128 = 19
+)
+
+//amountCollection describle a collection storing amounts, field is the amount
+//field which tells whether a document has been migrated
+type amountCollection struct {
+	name   string
+	field  string
+	newDoc func() interface{}
+}
+
+var amountCollections = []amountCollection{
+	{blockTbl, "reward", func() interface{} { return new(DBBlock) }},
+	{txTbl, "amount", func() interface{} { return new(DBTx) }},
+	{pendingTxTbl, "amount", func() interface{} { return new(DBTx) }},
+	{accTbl, "balance", func() interface{} { return new(DBAccount) }},
+	{chartBlockTbl, "rewards", func() interface{} { return new(DBOneDayBlockInfo) }},
+}
+
+//MigrateAmounts rewrite the amounts stored as int64 or double into Decimal128,
+//documents which have been migrated are skipped, so it is safe to run it again
+func (c *Client) MigrateAmounts() error {
+	for _, coll := range amountCollections {
+		var migrated int
+		query := func(c *mgo.Collection) error {
+			var err error
+			migrated, err = migrateAmountCollection(c, coll)
+			return err
+		}
+		if err := c.withCollection(coll.name, query); err != nil {
+			return err
+		}
+		log.Info("[DB] migrated amounts of %d documents in %s", migrated, coll.name)
+	}
+
+	return nil
+}
+
+func migrateAmountCollection(c *mgo.Collection, coll amountCollection) (int, error) {
+	filter := bson.M{
+		coll.field: bson.M{"$exists": true, "$not": bson.M{"$type": bsonTypeDecimal128}},
+	}
+
+	migrated := 0
+	var doc bson.M
+	iter := c.Find(filter).Iter()
+	for iter.Next(&doc) {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			iter.Close()
+			return migrated, err
+		}
+
+		v := coll.newDoc()
+		if err := bson.Unmarshal(data, v); err != nil {
+			iter.Close()
+			return migrated, err
+		}
+
+		if err := c.UpdateId(doc["_id"], bson.M{"$set": v}); err != nil {
+			iter.Close()
+			return migrated, err
+		}
+		migrated++
+		doc = nil
+	}
+
+	return migrated, iter.Close()
+}
@@ -0,0 +1,80 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"math/big"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+//pageFilter add the condition of a page next to an item to the filter, the list is ordered by field and then
+//by idField, both descending. An older page follows the item, a newer page precedes it and is queried in
+//ascending order, so that the items closest to the item come first. Without an id, the page starts from
+//the newest item, or the oldest one for a newer page. The sort of the query is returned with the filter
+func pageFilter(filter bson.M, field string, value interface{}, idField, id string, older bool) (bson.M, []string) {
+	op, sort := "$lt", []string{"-" + field, "-" + idField}
+	if !older {
+		op, sort = "$gt", []string{field, idField}
+	}
+
+	if id == "" {
+		return filter, sort
+	}
+
+	page := bson.M{"$or": []bson.M{
+		{field: bson.M{op: value}},
+		{field: value, idField: bson.M{op: id}},
+	}}
+	return bson.M{"$and": []bson.M{filter, page}}, sort
+}
+
+//GetAccountsPage get a page of the accounts of the shard next to the account with the balance and the address,
+//the richest comes first
+func (c *Client) GetAccountsPage(shardNumber int, balance *big.Int, address string, older bool, limit int) ([]*DBAccount, error) {
+	var accounts []*DBAccount
+	filter, sort := pageFilter(bson.M{"accType": 0, "shardNumber": shardNumber},
+		"balance", NewBigInt(balance), "address", address, older)
+	query := func(c *mgo.Collection) error {
+		return c.Find(filter).Sort(sort...).Limit(limit).All(&accounts)
+	}
+	err := c.withCollection(accTbl, query)
+
+	if !older {
+		reverseAccounts(accounts)
+	}
+	return accounts, err
+}
+
+//GetContractsPage get a page of the contracts of the shard next to the contract with the timestamp and the address,
+//the latest comes first
+func (c *Client) GetContractsPage(shardNumber int, timestamp int64, address string, older bool, limit int) ([]*DBAccount, error) {
+	var accounts []*DBAccount
+	filter, sort := pageFilter(bson.M{"accType": 1, "shardNumber": shardNumber},
+		"timestamp", timestamp, "address", address, older)
+	query := func(c *mgo.Collection) error {
+		return c.Find(filter).Sort(sort...).Limit(limit).All(&accounts)
+	}
+	err := c.withCollection(accTbl, query)
+
+	if !older {
+		reverseAccounts(accounts)
+	}
+	return accounts, err
+}
+
+func reverseAddressTxs(txs []*DBAddressTx) {
+	for i, j := 0, len(txs)-1; i < j; i, j = i+1, j-1 {
+		txs[i], txs[j] = txs[j], txs[i]
+	}
+}
+
+func reverseAccounts(accounts []*DBAccount) {
+	for i, j := 0, len(accounts)-1; i < j; i, j = i+1, j-1 {
+		accounts[i], accounts[j] = accounts[j], accounts[i]
+	}
+}
@@ -0,0 +1,150 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seeleteam/scan-api/log"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	schemaVersionTbl = "schema_version"
+	schemaVersionID  = "schema"
+)
+
+//DBSchemaVersion describle the version of the stored data, which is the version of the last migration applied
+type DBSchemaVersion struct {
+	ID        string `bson:"_id"`
+	Version   int    `bson:"version"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+//migration is an ordered change of the stored data. A migration must be safe to run again,
+//so that a migration failed halfway is resumed by running it again
+type migration struct {
+	version     int
+	description string
+	run         func(c *Client) error
+}
+
+var migrations = []migration{
+	{1, "convert the amounts stored as int64 or double into Decimal128", (*Client).MigrateAmounts},
+	{2, "set the pool state of the pending txs written before it existed", (*Client).migratePoolState},
+	{3, "count the stats of the stored blocks, txs and accounts", (*Client).recountAllStats},
+	{4, "build and count the tx history of the addresses", (*Client).migrateAddressTxs},
+	{5, "remove the block undos deeper than the max reorg depth", (*Client).pruneBlockUndos},
+	{6, "order the tx history of the addresses by the numeric timestamp", (*Client).migrateAddressTxTime},
+}
+
+//LatestSchemaVersion return the schema version the code reads and writes
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+//GetSchemaVersion get the version of the stored data, it is 0 if no migration has been applied
+func (c *Client) GetSchemaVersion() (int, error) {
+	v := new(DBSchemaVersion)
+	query := func(c *mgo.Collection) error {
+		return c.FindId(schemaVersionID).One(v)
+	}
+	err := c.withCollection(schemaVersionTbl, query)
+	if err == mgo.ErrNotFound {
+		return 0, nil
+	}
+	return v.Version, err
+}
+
+//setSchemaVersion update the version of the stored data
+func (c *Client) setSchemaVersion(version int) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.UpsertId(schemaVersionID, &DBSchemaVersion{
+			ID:        schemaVersionID,
+			Version:   version,
+			Timestamp: time.Now().Unix(),
+		})
+		return err
+	}
+	return c.withCollection(schemaVersionTbl, query)
+}
+
+//Migrate apply the migrations newer than the stored schema version in order, the version is
+//stored after each migration, so a failed migrate continues from the failed migration
+func (c *Client) Migrate() error {
+	current, err := c.GetSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if current > LatestSchemaVersion() {
+		return fmt.Errorf("schema version %d of the database is newer than %d", current, LatestSchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		log.Info("[DB] migrate to schema version %d: %s", m.version, m.description)
+		if err := m.run(c); err != nil {
+			return fmt.Errorf("migration %d failed, %v", m.version, err)
+		}
+
+		if err := c.setSchemaVersion(m.version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//CheckSchemaVersion check the schema version of the stored data at startup. A new database is
+//set to the latest version, an error is returned if the data is older or written by a newer version
+func (c *Client) CheckSchemaVersion() error {
+	current, err := c.GetSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if current == 0 {
+		blockCnt, err := c.GetBlockCnt()
+		if err != nil {
+			return err
+		}
+
+		//nothing to migrate
+		if blockCnt == 0 {
+			return c.setSchemaVersion(LatestSchemaVersion())
+		}
+	}
+
+	latest := LatestSchemaVersion()
+	if current > latest {
+		return fmt.Errorf("schema version %d of the database is newer than %d, please upgrade", current, latest)
+	}
+
+	if current < latest {
+		return fmt.Errorf("schema version %d of the database is older than %d, please run the migrate command", current, latest)
+	}
+	return nil
+}
+
+//migratePoolState set the pool state of the pending txs without one to pending, which is how they are read
+func (c *Client) migratePoolState() error {
+	query := func(c *mgo.Collection) error {
+		info, err := c.UpdateAll(bson.M{"poolState": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"poolState": PoolStatePending}})
+		if err == nil {
+			log.Info("[DB] set the pool state of %d pending txs", info.Updated)
+		}
+		return err
+	}
+	return c.withCollection(pendingTxTbl, query)
+}
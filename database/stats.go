@@ -0,0 +1,233 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"math/big"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+//Add add the changes of another delta to the delta
+func (d *DBStatsDelta) Add(other *DBStatsDelta) {
+	d.Blocks += other.Blocks
+	d.Txs += other.Txs
+	d.Accounts += other.Accounts
+	d.Contracts += other.Contracts
+	d.TotalBalance.Add(&d.TotalBalance.Int, &other.TotalBalance.Int)
+}
+
+//Neg return a delta reverting the changes of the delta
+func (d *DBStatsDelta) Neg() *DBStatsDelta {
+	return &DBStatsDelta{
+		Blocks:       -d.Blocks,
+		Txs:          -d.Txs,
+		Accounts:     -d.Accounts,
+		Contracts:    -d.Contracts,
+		TotalBalance: NewBigInt(new(big.Int).Neg(&d.TotalBalance.Int)),
+	}
+}
+
+//GetStats get the stats of all shards ordered by shard number
+func (c *Client) GetStats() ([]*DBShardStats, error) {
+	var stats []*DBShardStats
+	query := func(c *mgo.Collection) error {
+		return c.Find(nil).Sort("shardNumber").All(&stats)
+	}
+	err := c.withCollection(statsTbl, query)
+	return stats, err
+}
+
+//GetShardStats get the stats of the shard, they are empty if no block of the shard is counted yet
+func (c *Client) GetShardStats(shardNumber int) (*DBShardStats, error) {
+	stats := new(DBShardStats)
+	query := func(c *mgo.Collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber}).One(stats)
+	}
+	err := c.withCollection(statsTbl, query)
+	if err == mgo.ErrNotFound {
+		return &DBShardStats{ShardNumber: shardNumber, Height: -1}, nil
+	}
+	return stats, err
+}
+
+//ApplyStats add the delta of the block at height to the stats of the shard,
+//the stats which already count the block are left unchanged
+func (c *Client) ApplyStats(shardNumber int, height int64, delta *DBStatsDelta) error {
+	query := func(c *mgo.Collection) error {
+		err := c.Update(bson.M{"shardNumber": shardNumber, "height": bson.M{"$lt": height}}, statsUpdate(height, delta))
+		if err != mgo.ErrNotFound {
+			return err
+		}
+
+		cnt, err := c.Find(bson.M{"shardNumber": shardNumber}).Count()
+		if err != nil || cnt > 0 {
+			return err
+		}
+		return c.Insert(&DBShardStats{ShardNumber: shardNumber, Height: height, DBStatsDelta: *delta})
+	}
+	err := c.withCollection(statsTbl, query)
+	return err
+}
+
+//RevertStats subtract the delta of the block at height from the stats of the shard,
+//the stats which do not count the block are left unchanged
+func (c *Client) RevertStats(shardNumber int, height int64, delta *DBStatsDelta) error {
+	query := func(c *mgo.Collection) error {
+		err := c.Update(bson.M{"shardNumber": shardNumber, "height": bson.M{"$gte": height}}, statsUpdate(height-1, delta.Neg()))
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	err := c.withCollection(statsTbl, query)
+	return err
+}
+
+//statsUpdate return the update adding the delta to the stats and moving them to height
+func statsUpdate(height int64, delta *DBStatsDelta) bson.M {
+	return bson.M{
+		"$inc": bson.M{
+			"blocks":       delta.Blocks,
+			"txs":          delta.Txs,
+			"accounts":     delta.Accounts,
+			"contracts":    delta.Contracts,
+			"totalBalance": delta.TotalBalance,
+		},
+		"$set": bson.M{"height": height},
+	}
+}
+
+//SetStats replace the stats of the shard
+func (c *Client) SetStats(stats *DBShardStats) error {
+	query := func(c *mgo.Collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": stats.ShardNumber}, stats)
+		return err
+	}
+	err := c.withCollection(statsTbl, query)
+	return err
+}
+
+//RecountStats count the stats of the shard from the stored blocks, txs and accounts
+//with the aggregation framework and replace the maintained ones
+func (c *Client) RecountStats(shardNumber int) error {
+	stats, err := c.countStats(bson.M{"shardNumber": shardNumber})
+	if err != nil {
+		return err
+	}
+
+	shardStats, ok := stats[shardNumber]
+	if !ok {
+		shardStats = &DBShardStats{ShardNumber: shardNumber, Height: -1}
+	}
+	return c.SetStats(shardStats)
+}
+
+//recountAllStats count the stats of all shards and replace the maintained ones
+func (c *Client) recountAllStats() error {
+	stats, err := c.countStats(bson.M{})
+	if err != nil {
+		return err
+	}
+
+	for _, shardStats := range stats {
+		if err := c.SetStats(shardStats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//countStats count the stats of the shards whose documents match the filter, grouped by shard number
+func (c *Client) countStats(match bson.M) (map[int]*DBShardStats, error) {
+	stats := make(map[int]*DBShardStats)
+	shard := func(shardNumber int) *DBShardStats {
+		s, ok := stats[shardNumber]
+		if !ok {
+			s = &DBShardStats{ShardNumber: shardNumber, Height: -1}
+			stats[shardNumber] = s
+		}
+		return s
+	}
+
+	countBlocks := func(c *mgo.Collection) error {
+		var result []struct {
+			ShardNumber int   `bson:"_id"`
+			Blocks      int64 `bson:"blocks"`
+			Height      int64 `bson:"height"`
+		}
+		err := c.Pipe([]bson.M{
+			{"$match": match},
+			{"$group": bson.M{"_id": "$shardNumber", "blocks": bson.M{"$sum": 1}, "height": bson.M{"$max": "$height"}}},
+		}).All(&result)
+
+		for _, item := range result {
+			s := shard(item.ShardNumber)
+			s.Blocks = item.Blocks
+			s.Height = item.Height
+		}
+		return err
+	}
+	if err := c.withCollection(blockTbl, countBlocks); err != nil {
+		return nil, err
+	}
+
+	countTxs := func(c *mgo.Collection) error {
+		var result []struct {
+			ShardNumber int   `bson:"_id"`
+			Txs         int64 `bson:"txs"`
+		}
+		err := c.Pipe([]bson.M{
+			{"$match": match},
+			{"$group": bson.M{"_id": "$shardNumber", "txs": bson.M{"$sum": 1}}},
+		}).All(&result)
+
+		for _, item := range result {
+			shard(item.ShardNumber).Txs = item.Txs
+		}
+		return err
+	}
+	if err := c.withCollection(txTbl, countTxs); err != nil {
+		return nil, err
+	}
+
+	countAccounts := func(c *mgo.Collection) error {
+		var result []struct {
+			ID struct {
+				ShardNumber int `bson:"shardNumber"`
+				AccType     int `bson:"accType"`
+			} `bson:"_id"`
+			Accounts int64  `bson:"accounts"`
+			Total    BigInt `bson:"total"`
+		}
+		err := c.Pipe([]bson.M{
+			{"$match": match},
+			{"$group": bson.M{
+				"_id":      bson.M{"shardNumber": "$shardNumber", "accType": "$accType"},
+				"accounts": bson.M{"$sum": 1},
+				"total":    bson.M{"$sum": "$balance"},
+			}},
+		}).All(&result)
+
+		for _, item := range result {
+			s := shard(item.ID.ShardNumber)
+			switch item.ID.AccType {
+			case 0:
+				s.Accounts = item.Accounts
+			case 1:
+				s.Contracts = item.Accounts
+			}
+			s.TotalBalance.Add(&s.TotalBalance.Int, &item.Total.Int)
+		}
+		return err
+	}
+	if err := c.withCollection(accTbl, countAccounts); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
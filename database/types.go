@@ -16,7 +16,7 @@ type DBSimpleTxInBlock struct {
 	Hash      string `bson:"hash"`
 	From      string `bson:"from"`
 	To        string `bson:"to"`
-	Amount    int64  `bson:"amount"`
+	Amount    BigInt `bson:"amount"`
 	Timestamp string `bson:"timestamp"`
 }
 
@@ -32,7 +32,7 @@ type DBBlock struct {
 	Creator         string              `bson:"creator"`
 	Nonce           string              `bson:"nonce"`
 	TxHash          string              `bson:"txHash"`
-	Reward          int64               `bson:"reward"`
+	Reward          BigInt              `bson:"reward"`
 	Txs             []DBSimpleTxInBlock `bson:"transactions"`
 	ShardNumber     int                 `bson:"shardNumber"`
 }
@@ -43,25 +43,167 @@ type DBTx struct {
 	Hash         string `bson:"hash"`
 	From         string `bson:"from"`
 	To           string `bson:"to"`
-	Amount       int64  `bson:"amount"`
+	Amount       BigInt `bson:"amount"`
 	AccountNonce string `bson:"accountNonce"`
 	Timestamp    string `bson:"timestamp"`
 	Payload      string `bson:"payload"`
 	Block        string `bson:"block"`
 	Idx          int64  `bson:"idx"`
 	ShardNumber  int    `bson:"shardNumber"`
-	Fee          int64  `bson:"fee"`
+	Fee          BigInt `bson:"fee"`
 	Pending      bool   `bson:"pending"`
+	Failed       bool   `bson:"failed"`
+
+	//lifecycle in the tx pool, the times are unix seconds
+	PoolState     string `bson:"poolState"` //pending, mined or dropped
+	FirstSeen     int64  `bson:"firstSeen"`
+	LeftPoolTime  int64  `bson:"leftPoolTime"`  //when the tx was mined or dropped
+	InclusionTime int64  `bson:"inclusionTime"` //seconds from first seen to mined
+}
+
+//pool states of a transaction seen in the tx pool
+const (
+	PoolStatePending = "pending"
+	PoolStateMined   = "mined"
+	PoolStateDropped = "dropped"
+)
+
+//directions of a transaction in the tx history of an address
+const (
+	DirectionIn     = "in"     //received
+	DirectionOut    = "out"    //sent
+	DirectionSelf   = "self"   //sent to the address itself
+	DirectionCreate = "create" //created the contract at the address
+)
+
+//DBAddressTx describle an entry of the tx history of an address, a transaction has one entry for every address
+//it is sent from, sent to or creates. The entry carries the transaction, so that a page of the history is read
+//from one collection
+type DBAddressTx struct {
+	Address   string `bson:"address"`
+	Direction string `bson:"direction"`
+	Time      int64  `bson:"time"` //timestamp of the tx as a number, the history is ordered by it
+	DBTx      `bson:",inline"`
+}
+
+//DBAddressTxCount describle the counters of the tx history of an address in the blocks of a shard,
+//they are maintained by the syncer block by block
+type DBAddressTxCount struct {
+	Address     string `bson:"address"`
+	ShardNumber int    `bson:"shardNumber"`
+	Height      int64  `bson:"height"` //height of the last block counted
+	Txs         int64  `bson:"txs"`
+	In          int64  `bson:"in"`  //received, including the ones sent to the address itself
+	Out         int64  `bson:"out"` //sent, including the ones sent to the address itself
+	Created     int64  `bson:"created"`
+	Failed      int64  `bson:"failed"` //sent and failed
+}
+
+//AddressTxFilter select the entries of the tx history of an address
+type AddressTxFilter struct {
+	Address   string
+	Direction string //in or out also select the txs sent to the address itself, all are selected if empty
+	Pending   bool   //select the txs still in the tx pool instead of the mined ones
+}
+
+//DBReceipt describle the execution result of a transaction which stored in the database
+type DBReceipt struct {
+	TxHash          string `bson:"txHash"`
+	ShardNumber     int    `bson:"shardNumber"`
+	BlockHeight     int64  `bson:"blockHeight"`
+	Failed          bool   `bson:"failed"`
+	Result          string `bson:"result"`
+	PostState       string `bson:"postState"`
+	UsedGas         int64  `bson:"usedGas"`
+	TotalFee        BigInt `bson:"totalFee"`
+	ContractAddress string `bson:"contractAddress"`
+}
+
+//DBBalanceChange describle the balance of an account after a block touched it
+type DBBalanceChange struct {
+	ShardNumber int    `bson:"shardNumber"`
+	Address     string `bson:"address"`
+	Height      int64  `bson:"height"`
+	Timestamp   int64  `bson:"timestamp"`
+	Balance     BigInt `bson:"balance"`
+	Delta       BigInt `bson:"delta"`
 }
 
 //DBAccount describle a account which stored in the database
 type DBAccount struct {
 	AccType     int    `bson:"accType"` //0 is normal account, 1 is contract account
 	Address     string `bson:"address"`
-	Balance     int64  `bson:"balance"`
+	Balance     BigInt `bson:"balance"`
 	ShardNumber int    `bson:"shardNumber"`
 	TxCount     int64  `bson:"txCount"`
+	Mined       int64  `bson:"mined"`
 	TimeStamp   int64  `bson:"timestamp"`
+	SyncHeight  int64  `bson:"syncHeight"` //height of the last block applied to the account
+}
+
+//DBAccountUndo describle the changes a block made to an account
+type DBAccountUndo struct {
+	Address         string `bson:"address"`
+	TxCount         int64  `bson:"txCount"`
+	Mined           int64  `bson:"mined"`
+	Created         bool   `bson:"created"` //the account is not in database before the block
+	CreatedContract bool   `bson:"createdContract"`
+	Balance         BigInt `bson:"balance"` //balance delta, negative if the account spent more than it received
+}
+
+//DBBlockUndo describle the undo journal of a block, it is used to roll the block back when the chain reorganizes
+type DBBlockUndo struct {
+	ShardNumber int             `bson:"shardNumber"`
+	Height      int64           `bson:"height"`
+	HeadHash    string          `bson:"headHash"`
+	PreHash     string          `bson:"preBlockHash"`
+	TxIdx       int64           `bson:"txIdx"` //idx of the last tx before the block
+	Accounts    []DBAccountUndo `bson:"accounts"`
+	Stats       *DBStatsDelta   `bson:"stats,omitempty"` //nil if the block was synced before the stats existed
+}
+
+//DBStatsDelta describle the changes a block made to the stats of its shard
+type DBStatsDelta struct {
+	Blocks       int64  `bson:"blocks"`
+	Txs          int64  `bson:"txs"`
+	Accounts     int64  `bson:"accounts"`
+	Contracts    int64  `bson:"contracts"`
+	TotalBalance BigInt `bson:"totalBalance"`
+}
+
+//DBShardStats describle the counters and the total balance of a shard, they are maintained by the syncer block by block
+type DBShardStats struct {
+	ShardNumber  int   `bson:"shardNumber"`
+	Height       int64 `bson:"height"` //height of the last block counted, -1 if no block is counted
+	DBStatsDelta `bson:",inline"`
+}
+
+//DBSyncCursor describle the sync progress of a shard, it is advanced only after all the writes of a block finish
+type DBSyncCursor struct {
+	ShardNumber int    `bson:"shardNumber"`
+	Height      int64  `bson:"height"` //height of the last committed block, -1 if no block is committed
+	HeadHash    string `bson:"headHash"`
+	TxIdx       int64  `bson:"txIdx"` //idx of the last committed tx
+	Timestamp   int64  `bson:"timestamp"`
+}
+
+//DBReorg describle a chain reorganization happened in a shard
+type DBReorg struct {
+	ShardNumber int      `bson:"shardNumber"`
+	Depth       int64    `bson:"depth"`
+	ForkHeight  int64    `bson:"forkHeight"`
+	ForkHash    string   `bson:"forkHash"`
+	OldHashes   []string `bson:"oldHashes"`
+	NewHashes   []string `bson:"newHashes"`
+	Timestamp   int64    `bson:"timestamp"`
+}
+
+//DBChartRedo describle the one day charts of a shard to be counted again after a chain reorganization,
+//the days since ZeroTime are counted again by the chart service once the shard is synced to Height
+type DBChartRedo struct {
+	ShardNumber int   `bson:"shardNumber"`
+	ZeroTime    int64 `bson:"zeroTime"` //zero hour timestamp of the first day to count again
+	Height      int64 `bson:"height"`   //height the new branch is counted from, it is the node height at the reorganization
 }
 
 //CreateDbBlock convert an rpc block to an dbblock
@@ -81,19 +223,21 @@ func CreateDbBlock(b *rpc.BlockInfo) *DBBlock {
 		simpleTx.Hash = b.Txs[i].Hash
 		simpleTx.From = b.Txs[i].From
 		simpleTx.To = b.Txs[i].To
-		simpleTx.Amount = b.Txs[i].Amount.Int64()
+		simpleTx.Amount = NewBigInt(b.Txs[i].Amount)
 		simpleTx.Timestamp = strconv.FormatUint(b.Txs[i].Timestamp, 10)
 		dbBlock.Txs = append(dbBlock.Txs, simpleTx)
 
 		if i != len(b.Txs)-1 {
-			dbBlock.Reward += b.Txs[i].Fee
+			if b.Txs[i].Fee != nil {
+				dbBlock.Reward.Add(&dbBlock.Reward.Int, b.Txs[i].Fee)
+			}
 		}
 	}
 
 	//coinbase reward
 	if len(b.Txs) > 0 {
 		tx := b.Txs[len(b.Txs)-1]
-		dbBlock.Reward = tx.Amount.Int64()
+		dbBlock.Reward = NewBigInt(tx.Amount)
 	}
 
 	return &dbBlock
@@ -106,21 +250,69 @@ func CreateDbTx(t rpc.Transaction) *DBTx {
 	trans.Hash = t.Hash
 	trans.From = t.From
 	trans.To = t.To
-	trans.Amount = t.Amount.Int64()
+	trans.Amount = NewBigInt(t.Amount)
 	trans.Timestamp = strconv.FormatUint(t.Timestamp, 10)
 	trans.AccountNonce = strconv.FormatUint(t.AccountNonce, 10)
 	trans.Payload = t.Payload
 	trans.Block = strconv.FormatUint(t.Block, 10)
 	trans.Idx = int64(t.Idx)
-	trans.Fee = t.Fee
+	trans.Fee = NewBigInt(t.Fee)
 	return &trans
 }
 
+//CreateDbAddressTxs return the entries of the tx history of the addresses involved in the transaction,
+//contractAddress is the contract created by it. The null address sending the coinbase transactions has no entry,
+//and only the entry of the created contract keeps the payload, which is the creation code
+func CreateDbAddressTxs(tx *DBTx, contractAddress string) []*DBAddressTx {
+	timestamp, _ := strconv.ParseInt(tx.Timestamp, 10, 64)
+	entry := func(address, direction string) *DBAddressTx {
+		e := &DBAddressTx{Address: address, Direction: direction, Time: timestamp, DBTx: *tx}
+		if direction != DirectionCreate {
+			e.Payload = ""
+		}
+		return e
+	}
+
+	var entries []*DBAddressTx
+	switch {
+	case tx.From == tx.To:
+		entries = append(entries, entry(tx.From, DirectionSelf))
+	default:
+		if tx.From != nullAddress && tx.From != "" {
+			entries = append(entries, entry(tx.From, DirectionOut))
+		}
+		if tx.To != "" {
+			entries = append(entries, entry(tx.To, DirectionIn))
+		}
+	}
+
+	if contractAddress != "" {
+		entries = append(entries, entry(contractAddress, DirectionCreate))
+	}
+	return entries
+}
+
+//CreateDbReceipt convert an rpc receipt to an dbreceipt
+func CreateDbReceipt(r *rpc.Receipt, shardNumber int, blockHeight uint64) *DBReceipt {
+	return &DBReceipt{
+		TxHash:          r.TxHash,
+		ShardNumber:     shardNumber,
+		BlockHeight:     int64(blockHeight),
+		Failed:          r.Failed,
+		Result:          r.Result,
+		PostState:       r.PostState,
+		UsedGas:         int64(r.UsedGas),
+		TotalFee:        NewBigInt(r.TotalFee),
+		ContractAddress: r.ContractAddress,
+	}
+}
+
 //CreateEmptyAccount create an empty dbaccount
 func CreateEmptyAccount(address string, shardNumber int) *DBAccount {
 	return &DBAccount{
 		Address:     address,
 		ShardNumber: shardNumber,
+		SyncHeight:  -1,
 	}
 }
 
@@ -173,10 +365,10 @@ type DBOneDayBlockAvgTime struct {
 
 //DBOneDayBlockInfo describle all blocks in an single day
 type DBOneDayBlockInfo struct {
-	TotalBlocks int64 `bson:"totalblocks"`
-	Rewards     int64 `bson:"rewards"`
-	TimeStamp   int64 `bson:"timestamp"`
-	ShardNumber int   `bson:"shardnumber"`
+	TotalBlocks int64  `bson:"totalblocks"`
+	Rewards     BigInt `bson:"rewards"`
+	TimeStamp   int64  `bson:"timestamp"`
+	ShardNumber int    `bson:"shardnumber"`
 }
 
 //DBOneDayAddressInfo describle all blocks in an single day
@@ -54,13 +54,22 @@ type NodeService struct {
 
 	nodeDB NodeDB
 	cfg    *Config
+
+	//pool the seele nodes in RPCNodes, the unhealthy ones are skipped
+	pool *rpc.Pool
 }
 
 func New(cfg *Config, nodeDB NodeDB) *NodeService {
+	pool, err := rpc.NewPool(cfg.RPCNodes)
+	if err != nil {
+		log.Error(err)
+	}
+
 	return &NodeService{
 		nodeDB:  nodeDB,
 		cfg:     cfg,
 		nodeMap: make(map[string]database.DBNodeInfo),
+		pool:    pool,
 	}
 }
 
@@ -160,34 +169,21 @@ func getNodeKeyByNodeInfo(n *database.DBNodeInfo) string {
 //FindNode get all peers info and store them into database
 func (n *NodeService) FindNode() {
 
-	var allPeerInfos []rpc.PeerInfo
-	for i := 0; i < len(n.cfg.RPCNodes); i++ {
-		rpcURL := n.cfg.RPCNodes[i]
-
-		rpc := rpc.NewRPC(rpcURL)
-		defer func() {
-			if rpc != nil {
-				rpc.Release()
-			}
-		}()
-
-		if rpc == nil {
-			continue
-		}
-
-		if err := rpc.Connect(); err != nil {
-			fmt.Printf("rpc init failed, connurl:%v\n", rpcURL)
-			continue
-		}
+	if n.pool == nil {
+		return
+	}
 
-		peerInfos, err := rpc.GetPeersInfo()
+	var allPeerInfos []rpc.PeerInfo
+	n.pool.Each(func(rpcURL string, client *rpc.SeeleRPC) error {
+		peerInfos, err := client.GetPeersInfo()
 		if err != nil {
-			log.Fatal(err)
-			continue
+			log.Error("get peers of %s failed, %v", rpcURL, err)
+			return err
 		}
 
 		allPeerInfos = append(allPeerInfos, peerInfos...)
-	}
+		return nil
+	})
 
 	if len(allPeerInfos) == 0 {
 		return
@@ -228,6 +224,11 @@ func (n *NodeService) RestoreNodeFromDB() {
 //StartFindNodeService start the node map service
 func (n *NodeService) StartFindNodeService() {
 	n.RestoreNodeFromDB()
+	if n.pool != nil {
+		if err := n.pool.Start(); err != nil {
+			fmt.Printf("rpc init failed, connurl:%v\n", n.cfg.RPCNodes)
+		}
+	}
 	n.FindNode()
 
 	ticks := time.NewTicker(n.cfg.Interval * time.Second)
@@ -29,7 +29,7 @@ type Transaction struct {
 	AccountNonce    uint64   `json:"accountNonce"`
 	Payload         string   `json:"payload"`
 	Timestamp       uint64   `json:"timestamp"`
-	Fee             int64    `json:"fee"`
+	Fee             *big.Int `json:"fee"`
 	Block           uint64   `json:"block"`
 	Idx             uint64   `json:"idx"`
 	TxType          int      `json:"txtype"`
@@ -48,6 +48,7 @@ type BlockInfo struct {
 	Nonce           uint64        `json:"nonce"`
 	TxHash          string        `json:"txHash"`
 	Txs             []Transaction `json:"txs"`
+	Debts           []Debt        `json:"debts"`
 }
 
 // GetBlockByHeightRequest request param for GetBlockByHeight api
@@ -56,6 +57,32 @@ type GetBlockByHeightRequest struct {
 	FullTx bool  `json:"fullTx"`
 }
 
+// GetBlockByHashRequest request param for GetBlockByHash api
+type GetBlockByHashRequest struct {
+	HashHex string `json:"hash"`
+	FullTx  bool   `json:"fullTx"`
+}
+
+// GetCodeRequest request param for GetCode api, a negative height means the current block
+type GetCodeRequest struct {
+	Address string `json:"address"`
+	Height  int64  `json:"height"`
+}
+
+// CallRequest request param for Call api, a negative height means the current block
+type CallRequest struct {
+	To      string `json:"to"`
+	Payload string `json:"payload"`
+	Height  int64  `json:"height"`
+}
+
+// GetLogsRequest request param for GetLogs api, an empty topic matches all the logs of the contract
+type GetLogsRequest struct {
+	Height   int64  `json:"height"`
+	Contract string `json:"contract"`
+	Topic    string `json:"topic"`
+}
+
 //PeerInfo is the peer info send from seele node
 type PeerInfo struct {
 	ID            string   `json:"id"`            // Unique of the node
@@ -65,10 +92,63 @@ type PeerInfo struct {
 	ShardNumber   int      `json:"shardNumber"`
 }
 
-//Receipt
+//Receipt is the execution result of a transaction send from seele node
 type Receipt struct {
-	Result          string `json:"result"`
-	PostState       string `json:"result"`
-	TxHash          string `json:"txhash"`
-	ContractAddress string `json:"contractaddress"`
+	Result          string   `json:"result"`
+	PostState       string   `json:"poststate"`
+	TxHash          string   `json:"txhash"`
+	ContractAddress string   `json:"contract"`
+	Failed          bool     `json:"failed"`
+	UsedGas         uint64   `json:"usedGas"`
+	TotalFee        *big.Int `json:"totalFee"`
+}
+
+//TxInfo is a transaction and the block which contains it, the block is empty if the transaction is still in the pool
+type TxInfo struct {
+	Transaction
+	Status      string `json:"status"`
+	BlockHash   string `json:"blockHash"`
+	BlockHeight uint64 `json:"blockHeight"`
+	TxIndex     uint64 `json:"txIndex"`
+}
+
+//NodeInfo is the state of seele node
+type NodeInfo struct {
+	Coinbase           string   `json:"coinbase"`
+	CurrentBlockHeight uint64   `json:"currentBlockHeight"`
+	HeaderHash         string   `json:"headerHash"`
+	Shard              int      `json:"shard"`
+	MinerStatus        string   `json:"minerStatus"`
+	Version            string   `json:"version"`
+	BlockAge           *big.Int `json:"blockAge"`
+	PeerCnt            string   `json:"peerCnt"`
+}
+
+//Log is an event emitted by a contract
+type Log struct {
+	Address     string   `json:"address"`
+	Topics      []string `json:"topics"`
+	Data        string   `json:"data"`
+	BlockHeight uint64   `json:"blockHeight"`
+	TxHash      string   `json:"txHash"`
+	TxIndex     uint64   `json:"txIndex"`
+}
+
+//Debt is the credit of a cross-shard transaction to the account in the target shard
+type Debt struct {
+	Hash      string   `json:"hash"`
+	TxHash    string   `json:"txHash"`
+	FromShard int      `json:"fromShard"`
+	Account   string   `json:"account"`
+	Amount    *big.Int `json:"amount"`
+	Price     *big.Int `json:"price"`
+	Code      string   `json:"code"`
+}
+
+//DebtInfo is a debt and the block which contains it, the block is empty if the debt is still in the pool
+type DebtInfo struct {
+	Debt
+	Status      string `json:"status"`
+	BlockHash   string `json:"blockHash"`
+	BlockHeight uint64 `json:"blockHeight"`
 }
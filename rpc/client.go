@@ -5,6 +5,7 @@
 package rpc
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"io"
@@ -31,6 +32,18 @@ type clientCodec struct {
 	// and then look it up by request ID when filling out the rpc Response.
 	mutex   sync.Mutex        // protects pending
 	pending map[uint64]string // map request id to method name
+
+	// Requests written while a batch is open are buffered and sent
+	// together as a JSON array when the batch is flushed.
+	wmutex  sync.Mutex // protects enc, batches and batch
+	batches int
+	batch   []*clientRequest
+
+	// responses of a batch not read yet
+	queue []clientResponse
+
+	// notifications pushed by the server are delivered to their subscriptions
+	subs *subscriptions
 }
 
 // NewClientCodec returns a new rpc.ClientCodec using JSON-RPC 2.0 on conn.
@@ -40,6 +53,7 @@ func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
 		enc:     json.NewEncoder(conn),
 		c:       conn,
 		pending: make(map[uint64]string),
+		subs:    newSubscriptions(),
 	}
 }
 
@@ -52,8 +66,65 @@ type clientRequest struct {
 
 func (c *clientCodec) WriteRequest(r *rpc.Request, param interface{}) error {
 	// If return error: it will be returned as is for this call.
-	// Allow param to be only Array, Slice, Map or Struct.
-	// When param is nil or uninitialized Map or Slice - omit "params".
+	param, err := checkParam(param)
+	if err != nil {
+		return err
+	}
+
+	var req clientRequest
+	if r.Seq != seqNotify {
+		c.mutex.Lock()
+		c.pending[r.Seq] = r.ServiceMethod
+		c.mutex.Unlock()
+		// r is reused by package rpc, the request may be buffered in a batch
+		seq := r.Seq
+		req.ID = &seq
+	}
+	req.Version = jsonrpcVersion
+	req.Method = r.ServiceMethod
+	req.Params[0] = param
+
+	c.wmutex.Lock()
+	defer c.wmutex.Unlock()
+	if c.batches > 0 {
+		c.batch = append(c.batch, &req)
+		return nil
+	}
+
+	if err := c.enc.Encode(&req); err != nil {
+		return NewError(errInternal.Code, err.Error())
+	}
+	return nil
+}
+
+// startBatch buffer the requests written from now on until flushBatch is called.
+func (c *clientCodec) startBatch() {
+	c.wmutex.Lock()
+	c.batches++
+	c.wmutex.Unlock()
+}
+
+// flushBatch send the buffered requests as a JSON array when the last open batch is flushed.
+func (c *clientCodec) flushBatch() error {
+	c.wmutex.Lock()
+	defer c.wmutex.Unlock()
+
+	c.batches--
+	if c.batches > 0 || len(c.batch) == 0 {
+		return nil
+	}
+
+	batch := c.batch
+	c.batch = nil
+	if err := c.enc.Encode(batch); err != nil {
+		return NewError(errInternal.Code, err.Error())
+	}
+	return nil
+}
+
+// checkParam allow param to be only Array, Slice, Map or Struct.
+// When param is nil or uninitialized Map or Slice - omit "params".
+func checkParam(param interface{}) (interface{}, error) {
 	if param != nil {
 		switch k := reflect.TypeOf(param).Kind(); k {
 		case reflect.Map:
@@ -81,27 +152,13 @@ func (c *clientCodec) WriteRequest(r *rpc.Request, param interface{}) error {
 				}
 			case reflect.Array, reflect.Struct, reflect.String, reflect.Ptr, reflect.Interface:
 			default:
-				return NewError(errInternal.Code, "unsupported param type: Ptr to "+k.String())
+				return nil, NewError(errInternal.Code, "unsupported param type: Ptr to "+k.String())
 			}
 		default:
-			return NewError(errInternal.Code, "unsupported param type: "+k.String())
+			return nil, NewError(errInternal.Code, "unsupported param type: "+k.String())
 		}
 	}
-
-	var req clientRequest
-	if r.Seq != seqNotify {
-		c.mutex.Lock()
-		c.pending[r.Seq] = r.ServiceMethod
-		c.mutex.Unlock()
-		req.ID = &r.Seq
-	}
-	req.Version = jsonrpcVersion
-	req.Method = r.ServiceMethod
-	req.Params[0] = param
-	if err := c.enc.Encode(&req); err != nil {
-		return NewError(errInternal.Code, err.Error())
-	}
-	return nil
+	return param, nil
 }
 
 type clientResponse struct {
@@ -120,8 +177,8 @@ func (r *clientResponse) reset() {
 
 func (r *clientResponse) UnmarshalJSON(raw []byte) error {
 	r.reset()
-	type resp *clientResponse
-	if err := json.Unmarshal(raw, resp(r)); err != nil {
+	type resp clientResponse
+	if err := json.Unmarshal(raw, (*resp)(r)); err != nil {
 		return errors.New("bad response: " + string(raw))
 	}
 
@@ -169,13 +226,44 @@ func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
 	// - io.EOF will became ErrShutdown or io.ErrUnexpectedEOF
 	// - it will be returned as is for all pending calls
 	// - client will be shutdown
-	// So, return io.EOF as is, return *Error for all other errors.
-	if err := c.dec.Decode(&c.resp); err != nil {
-		if err == io.EOF {
-			return err
+	// So, return io.EOF and the errors of the connection as is, return *Error for all other errors.
+	if len(c.queue) == 0 {
+		var raw json.RawMessage
+		for {
+			if err := c.dec.Decode(&raw); err != nil {
+				c.subs.closeAll()
+				if _, ok := err.(net.Error); ok || err == io.EOF || err == io.ErrUnexpectedEOF {
+					return err
+				}
+				return NewError(errInternal.Code, err.Error())
+			}
+
+			// notifications are not the responses of any call
+			if !c.subs.dispatch(raw) {
+				break
+			}
+		}
+
+		// the responses of a batch are returned one by one
+		raw = bytes.TrimLeft(raw, " \t\r\n")
+		if len(raw) > 0 && raw[0] == '[' {
+			if err := json.Unmarshal(raw, &c.queue); err != nil {
+				return NewError(errInternal.Code, err.Error())
+			}
+			if len(c.queue) == 0 {
+				return NewError(errInternal.Code, "bad response: empty batch")
+			}
+		} else {
+			c.queue = make([]clientResponse, 1)
+			if err := json.Unmarshal(raw, &c.queue[0]); err != nil {
+				c.queue = nil
+				return NewError(errInternal.Code, err.Error())
+			}
 		}
-		return NewError(errInternal.Code, err.Error())
 	}
+	c.resp = c.queue[0]
+	c.queue = c.queue[1:]
+
 	if c.resp.ID == nil {
 		return c.resp.Error
 	}
@@ -202,7 +290,10 @@ func (c *clientCodec) ReadResponseBody(x interface{}) error {
 	if x == nil {
 		return nil
 	}
-	if err := json.Unmarshal(*c.resp.Result, x); err != nil {
+	// numbers are decoded as json.Number, so that amounts keep their precision
+	dec := json.NewDecoder(bytes.NewReader(*c.resp.Result))
+	dec.UseNumber()
+	if err := dec.Decode(x); err != nil {
 		e := NewError(errInternal.Code, err.Error())
 		e.Data = NewError(errInternal.Code, "some other Call failed to unmarshal Reply")
 		return e
@@ -211,6 +302,7 @@ func (c *clientCodec) ReadResponseBody(x interface{}) error {
 }
 
 func (c *clientCodec) Close() error {
+	c.subs.closeAll()
 	return c.c.Close()
 }
 
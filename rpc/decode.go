@@ -0,0 +1,548 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+//The responses of seele node are decoded with the following compatibility policy:
+//unknown fields are ignored, missing or null optional fields are zero, while missing
+//required fields and values of a wrong type are reported as a DecodeError.
+
+var (
+	errMissingField = errors.New("missing required field")
+	errNotNumber    = errors.New("not a number")
+	errNullResult   = errors.New("null result")
+)
+
+//DecodeError is returned when a response of seele node could not be decoded
+type DecodeError struct {
+	Method string
+	Field  string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("rpc %s: could not decode response, %v", e.Method, e.Err)
+	}
+	return fmt.Sprintf("rpc %s: could not decode field %s, %v", e.Method, e.Field, e.Err)
+}
+
+//number is a json number or a numeric string in the response, null and missing are zero.
+//A value of other types is kept to be reported by validate
+type number struct {
+	value   *big.Int
+	invalid string
+}
+
+//UnmarshalJSON implements json.Unmarshaler
+func (n *number) UnmarshalJSON(data []byte) error {
+	n.value, n.invalid = nil, ""
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if len(data) >= 2 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			n.invalid = string(data)
+			return nil
+		}
+	}
+
+	if v, ok := parseNumber(s); ok {
+		n.value = v
+	} else {
+		n.invalid = string(data)
+	}
+	return nil
+}
+
+//big return the value, zero if it is missing
+func (n *number) big() *big.Int {
+	if n.value == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(n.value)
+}
+
+//uint64 return the value as uint64, zero if it is missing
+func (n *number) uint64() uint64 {
+	return n.big().Uint64()
+}
+
+//validate return an error if the value is not a number
+func (n *number) validate(field string) *fieldError {
+	if n.invalid != "" {
+		return &fieldError{field, fmt.Errorf("%v: %s", errNotNumber, n.invalid)}
+	}
+	return nil
+}
+
+//required return an error if the value is missing or not a number
+func (n *number) required(field string) *fieldError {
+	if err := n.validate(field); err != nil {
+		return err
+	}
+	if n.value == nil {
+		return &fieldError{field, errMissingField}
+	}
+	return nil
+}
+
+//fieldError is a validation error of a field, it is turned into a DecodeError with the method
+type fieldError struct {
+	field string
+	err   error
+}
+
+//requireString return an error if the string field is empty
+func requireString(field, value string) *fieldError {
+	if value == "" {
+		return &fieldError{field, errMissingField}
+	}
+	return nil
+}
+
+//firstError return the first not nil error
+func firstError(errs ...*fieldError) *fieldError {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//decodeResult unmarshal the raw result of the method into v, the error names the method and the field
+func decodeResult(method string, raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return &DecodeError{Method: method, Err: errNullResult}
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		decodeErr := &DecodeError{Method: method, Err: err}
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			decodeErr.Field = typeErr.Field
+			decodeErr.Err = fmt.Errorf("cannot use %s as %v", typeErr.Value, typeErr.Type)
+		}
+		return decodeErr
+	}
+	return nil
+}
+
+//validationError turn the field error into a DecodeError of the method
+func validationError(method string, err *fieldError) error {
+	if err == nil {
+		return nil
+	}
+	return &DecodeError{Method: method, Field: err.field, Err: err.err}
+}
+
+//decodeCurrentBlock decode the summary of a block, the transactions are hashes unless fullTx is set
+func decodeCurrentBlock(method string, raw json.RawMessage, fullTx bool) (*CurrentBlock, error) {
+	var block rpcBlock
+	if err := decodeResult(method, raw, &block); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, block.validate(fullTx)); err != nil {
+		return nil, err
+	}
+
+	currentBlock := &CurrentBlock{
+		HeadHash:  block.Hash,
+		Height:    block.Height.uint64(),
+		Timestamp: block.Timestamp.big(),
+		Difficult: block.Difficulty.big(),
+		Creator:   block.Creator,
+		TxCount:   len(block.Transactions),
+	}
+	return currentBlock, nil
+}
+
+//decodeBlock decode and validate a block in the result of the method
+func decodeBlock(method string, raw json.RawMessage, fullTx bool) (*BlockInfo, error) {
+	var rpcOutputBlock rpcBlock
+	if err := decodeResult(method, raw, &rpcOutputBlock); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, rpcOutputBlock.validate(fullTx)); err != nil {
+		return nil, err
+	}
+
+	var Txs []Transaction
+	var Debts []Debt
+	if fullTx {
+		for i := 0; i < len(rpcOutputBlock.Transactions); i++ {
+			Txs = append(Txs, rpcOutputBlock.Transactions[i].transaction())
+		}
+		for i := 0; i < len(rpcOutputBlock.Debts); i++ {
+			Debts = append(Debts, rpcOutputBlock.Debts[i].debt())
+		}
+	}
+
+	block := &BlockInfo{
+		Height:          rpcOutputBlock.Height.uint64(),
+		Hash:            rpcOutputBlock.Hash,
+		ParentHash:      rpcOutputBlock.ParentHash,
+		Nonce:           rpcOutputBlock.Nonce.uint64(),
+		StateHash:       rpcOutputBlock.StateHash,
+		TxHash:          rpcOutputBlock.TxHash,
+		Creator:         rpcOutputBlock.Creator,
+		Timestamp:       rpcOutputBlock.Timestamp.big(),
+		Difficulty:      rpcOutputBlock.Difficulty.big(),
+		TotalDifficulty: rpcOutputBlock.TotalDifficulty.big(),
+		Txs:             Txs,
+		Debts:           Debts,
+	}
+	return block, nil
+}
+
+//decodeReceipt decode and validate a receipt in the result of the method
+func decodeReceipt(method string, raw json.RawMessage) (*Receipt, error) {
+	var rpcOutputReceipt rpcReceipt
+	if err := decodeResult(method, raw, &rpcOutputReceipt); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, rpcOutputReceipt.validate()); err != nil {
+		return nil, err
+	}
+
+	receipt := &Receipt{
+		Result:          rpcOutputReceipt.Result,
+		PostState:       rpcOutputReceipt.PostState,
+		TxHash:          rpcOutputReceipt.TxHash,
+		ContractAddress: rpcOutputReceipt.ContractAddress,
+		Failed:          rpcOutputReceipt.Failed,
+		UsedGas:         rpcOutputReceipt.UsedGas.uint64(),
+		TotalFee:        rpcOutputReceipt.TotalFee.big(),
+	}
+	return receipt, nil
+}
+
+//decodeBalance decode and validate a balance in the result of the method
+func decodeBalance(method string, raw json.RawMessage) (*big.Int, error) {
+	var balance number
+	if err := decodeResult(method, raw, &balance); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, balance.required("balance")); err != nil {
+		return nil, err
+	}
+
+	return balance.big(), nil
+}
+
+//rpcTransaction is a transaction in the response
+type rpcTransaction struct {
+	Hash         string `json:"hash"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Amount       number `json:"amount"`
+	AccountNonce number `json:"accountNonce"`
+	Payload      string `json:"payload"`
+	Timestamp    number `json:"timestamp"`
+	Fee          number `json:"fee"`
+}
+
+func (t *rpcTransaction) validate(prefix string) *fieldError {
+	return firstError(
+		requireString(prefix+"hash", t.Hash),
+		requireString(prefix+"from", t.From),
+		t.Amount.validate(prefix+"amount"),
+		t.AccountNonce.validate(prefix+"accountNonce"),
+		t.Timestamp.validate(prefix+"timestamp"),
+		t.Fee.validate(prefix+"fee"),
+	)
+}
+
+func (t *rpcTransaction) transaction() Transaction {
+	return Transaction{
+		Hash:         t.Hash,
+		From:         t.From,
+		To:           t.To,
+		Amount:       t.Amount.big(),
+		AccountNonce: t.AccountNonce.uint64(),
+		Payload:      t.Payload,
+		Timestamp:    t.Timestamp.uint64(),
+		Fee:          t.Fee.big(),
+	}
+}
+
+//rpcTransactions decode the transactions, a block without full transactions has their hashes only
+type rpcTransactions []rpcTransaction
+
+//UnmarshalJSON implements json.Unmarshaler
+func (txs *rpcTransactions) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+
+	*txs = make(rpcTransactions, len(items))
+	for i, item := range items {
+		if err := unmarshalHashOrObject(item, &(*txs)[i].Hash, &(*txs)[i], fmt.Sprintf("transactions.%d.", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//unmarshalHashOrObject unmarshal an item of a block which is either its hash or the full object,
+//the field of a type error is prefixed with the path of the item
+func unmarshalHashOrObject(item json.RawMessage, hash *string, v interface{}, prefix string) error {
+	if len(item) > 0 && item[0] == '"' {
+		return json.Unmarshal(item, hash)
+	}
+
+	if err := json.Unmarshal(item, v); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			typeErr.Field = prefix + typeErr.Field
+		}
+		return err
+	}
+	return nil
+}
+
+func (txs rpcTransactions) validate(prefix string, fullTx bool) *fieldError {
+	for i := range txs {
+		itemPrefix := fmt.Sprintf("%s%d.", prefix, i)
+		if !fullTx {
+			if err := requireString(itemPrefix+"hash", txs[i].Hash); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := txs[i].validate(itemPrefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//rpcBlock is a block in the response of seele.GetBlockByHeight
+type rpcBlock struct {
+	Hash            string          `json:"hash"`
+	ParentHash      string          `json:"parentHash"`
+	Height          number          `json:"height"`
+	StateHash       string          `json:"stateHash"`
+	Timestamp       number          `json:"timestamp"`
+	Difficulty      number          `json:"difficulty"`
+	TotalDifficulty number          `json:"totalDifficulty"`
+	Creator         string          `json:"creator"`
+	Nonce           number          `json:"nonce"`
+	TxHash          string          `json:"txHash"`
+	Transactions    rpcTransactions `json:"transactions"`
+	Debts           rpcDebts        `json:"debts"`
+}
+
+func (b *rpcBlock) validate(fullTx bool) *fieldError {
+	return firstError(
+		requireString("hash", b.Hash),
+		requireString("parentHash", b.ParentHash),
+		b.Height.required("height"),
+		b.Timestamp.required("timestamp"),
+		b.Difficulty.validate("difficulty"),
+		b.TotalDifficulty.validate("totalDifficulty"),
+		requireString("creator", b.Creator),
+		b.Nonce.validate("nonce"),
+		b.Transactions.validate("transactions.", fullTx),
+		b.Debts.validate("debts.", fullTx),
+	)
+}
+
+//rpcPeerInfo is a peer in the response of network.GetPeersInfo
+type rpcPeerInfo struct {
+	ID      string   `json:"id"`
+	Caps    []string `json:"caps"`
+	Network struct {
+		LocalAddress  string `json:"localAddress"`
+		RemoteAddress string `json:"remoteAddress"`
+	} `json:"network"`
+	Shard number `json:"shard"`
+}
+
+func (p *rpcPeerInfo) validate(prefix string) *fieldError {
+	return firstError(
+		requireString(prefix+"id", p.ID),
+		requireString(prefix+"network.remoteAddress", p.Network.RemoteAddress),
+		p.Shard.validate(prefix+"shard"),
+	)
+}
+
+//rpcReceipt is the response of txpool.GetReceiptByTxHash
+type rpcReceipt struct {
+	Result          string `json:"result"`
+	PostState       string `json:"poststate"`
+	TxHash          string `json:"txhash"`
+	ContractAddress string `json:"contract"`
+	Failed          bool   `json:"failed"`
+	UsedGas         number `json:"usedGas"`
+	TotalFee        number `json:"totalFee"`
+}
+
+func (r *rpcReceipt) validate() *fieldError {
+	return firstError(
+		r.UsedGas.validate("usedGas"),
+		r.TotalFee.validate("totalFee"),
+	)
+}
+
+//rpcDebt is a debt in the response, the fields other than the hash are in data
+type rpcDebt struct {
+	Hash string `json:"hash"`
+	Data struct {
+		TxHash    string `json:"txHash"`
+		FromShard number `json:"fromShard"`
+		Account   string `json:"account"`
+		Amount    number `json:"amount"`
+		Price     number `json:"price"`
+		Code      string `json:"code"`
+	} `json:"data"`
+}
+
+func (d *rpcDebt) validate(prefix string) *fieldError {
+	return firstError(
+		requireString(prefix+"hash", d.Hash),
+		requireString(prefix+"data.txHash", d.Data.TxHash),
+		d.Data.FromShard.validate(prefix+"data.fromShard"),
+		requireString(prefix+"data.account", d.Data.Account),
+		d.Data.Amount.validate(prefix+"data.amount"),
+		d.Data.Price.validate(prefix+"data.price"),
+	)
+}
+
+func (d *rpcDebt) debt() Debt {
+	return Debt{
+		Hash:      d.Hash,
+		TxHash:    d.Data.TxHash,
+		FromShard: int(d.Data.FromShard.big().Int64()),
+		Account:   d.Data.Account,
+		Amount:    d.Data.Amount.big(),
+		Price:     d.Data.Price.big(),
+		Code:      d.Data.Code,
+	}
+}
+
+//rpcDebts decode the debts, a block without full transactions has their hashes only
+type rpcDebts []rpcDebt
+
+//UnmarshalJSON implements json.Unmarshaler
+func (debts *rpcDebts) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+
+	*debts = make(rpcDebts, len(items))
+	for i, item := range items {
+		if err := unmarshalHashOrObject(item, &(*debts)[i].Hash, &(*debts)[i], fmt.Sprintf("debts.%d.", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (debts rpcDebts) validate(prefix string, fullTx bool) *fieldError {
+	for i := range debts {
+		itemPrefix := fmt.Sprintf("%s%d.", prefix, i)
+		if !fullTx {
+			if err := requireString(itemPrefix+"hash", debts[i].Hash); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := debts[i].validate(itemPrefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//rpcDebtInfo is the response of txpool.GetDebtByHash
+type rpcDebtInfo struct {
+	Debt        rpcDebt `json:"debt"`
+	Status      string  `json:"status"`
+	BlockHash   string  `json:"blockHash"`
+	BlockHeight number  `json:"blockHeight"`
+}
+
+func (d *rpcDebtInfo) validate() *fieldError {
+	return firstError(
+		d.Debt.validate("debt."),
+		requireString("status", d.Status),
+		d.BlockHeight.validate("blockHeight"),
+	)
+}
+
+//rpcTxInfo is the response of txpool.GetTransactionByHash
+type rpcTxInfo struct {
+	Transaction rpcTransaction `json:"transaction"`
+	Status      string         `json:"status"`
+	BlockHash   string         `json:"blockHash"`
+	BlockHeight number         `json:"blockHeight"`
+	TxIndex     number         `json:"txIndex"`
+}
+
+func (t *rpcTxInfo) validate() *fieldError {
+	return firstError(
+		t.Transaction.validate("transaction."),
+		requireString("status", t.Status),
+		t.BlockHeight.validate("blockHeight"),
+		t.TxIndex.validate("txIndex"),
+	)
+}
+
+//rpcNodeInfo is the response of seele.GetInfo
+type rpcNodeInfo struct {
+	Coinbase           string `json:"Coinbase"`
+	CurrentBlockHeight number `json:"CurrentBlockHeight"`
+	HeaderHash         string `json:"HeaderHash"`
+	Shard              number `json:"Shard"`
+	MinerStatus        string `json:"MinerStatus"`
+	Version            string `json:"Version"`
+	BlockAge           number `json:"BlockAge"`
+	PeerCnt            string `json:"PeerCnt"`
+}
+
+func (n *rpcNodeInfo) validate() *fieldError {
+	return firstError(
+		n.CurrentBlockHeight.required("CurrentBlockHeight"),
+		requireString("HeaderHash", n.HeaderHash),
+		n.Shard.validate("Shard"),
+		n.BlockAge.validate("BlockAge"),
+	)
+}
+
+//rpcLog is a log in the response of seele.GetLogs
+type rpcLog struct {
+	Address     string   `json:"address"`
+	Topics      []string `json:"topics"`
+	Data        string   `json:"data"`
+	BlockHeight number   `json:"blockHeight"`
+	TxHash      string   `json:"txHash"`
+	TxIndex     number   `json:"txIndex"`
+}
+
+func (l *rpcLog) validate(prefix string) *fieldError {
+	return firstError(
+		requireString(prefix+"address", l.Address),
+		l.BlockHeight.validate(prefix+"blockHeight"),
+		l.TxIndex.validate(prefix+"txIndex"),
+	)
+}
@@ -0,0 +1,80 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const blockMethod = "seele.GetBlockByHeight"
+
+func TestDecodeBlock(t *testing.T) {
+	raw := `{"hash":"0x01","parentHash":"0x00","height":12,"timestamp":"1527350400","difficulty":1e+21,
+		"creator":"0xc1","unknown":{"a":1},"transactions":[{"hash":"0x02","from":"0xf1","to":null,
+		"amount":123456789012345678901234567890,"payload":null,"fee":"0x10"}]}`
+
+	block, err := decodeBlock(blockMethod, json.RawMessage(raw), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.Height != 12 || block.Timestamp.Int64() != 1527350400 {
+		t.Fatalf("bad height or timestamp %v %v", block.Height, block.Timestamp)
+	}
+
+	if block.Difficulty.String() != "1000000000000000000000" {
+		t.Fatalf("bad difficulty %v", block.Difficulty)
+	}
+
+	tx := block.Txs[0]
+	if tx.To != "" || tx.Payload != "" || tx.Fee.Int64() != 16 || tx.Amount.String() != "123456789012345678901234567890" {
+		t.Fatalf("bad transaction %+v", tx)
+	}
+}
+
+func TestDecodeBlockHashes(t *testing.T) {
+	raw := `{"hash":"0x01","parentHash":"0x00","height":1,"timestamp":1,"creator":"0xc1","transactions":["0x02"]}`
+	var block rpcBlock
+	if err := decodeResult(blockMethod, json.RawMessage(raw), &block); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := block.validate(false); err != nil {
+		t.Fatal(err.err)
+	}
+
+	if block.Transactions[0].Hash != "0x02" {
+		t.Fatalf("bad transaction hash %s", block.Transactions[0].Hash)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		raw   string
+		field string
+	}{
+		{`{"parentHash":"0x00","height":1,"timestamp":1,"creator":"0xc1"}`, "hash"},
+		{`{"hash":"0x01","parentHash":"0x00","timestamp":1,"creator":"0xc1"}`, "height"},
+		{`{"hash":"0x01","parentHash":"0x00","height":true,"timestamp":1,"creator":"0xc1"}`, "height"},
+		{`{"hash":1,"parentHash":"0x00","height":1,"timestamp":1,"creator":"0xc1"}`, "hash"},
+		{`{"hash":"0x01","parentHash":"0x00","height":1,"timestamp":1,"creator":"0xc1","transactions":[{"hash":"0x02"}]}`, "transactions.0.from"},
+		{`{"hash":"0x01","parentHash":"0x00","height":1,"timestamp":1,"creator":"0xc1","transactions":[{"hash":"0x02","from":5}]}`, "transactions.0.from"},
+		{`null`, ""},
+	}
+
+	for _, test := range tests {
+		_, err := decodeBlock(blockMethod, json.RawMessage(test.raw), true)
+		decodeErr, ok := err.(*DecodeError)
+		if !ok {
+			t.Fatalf("%s: expected a decode error, got %v", test.raw, err)
+		}
+
+		if decodeErr.Method != blockMethod || decodeErr.Field != test.field {
+			t.Fatalf("%s: expected field %q, got %v", test.raw, test.field, decodeErr)
+		}
+	}
+}
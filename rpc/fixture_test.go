@@ -0,0 +1,172 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+)
+
+//fixture is a recorded response of seele node to a request with the params
+type fixture struct {
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+//fixtureRequest is a json-rpc request sent to the fixture node
+type fixtureRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	ID     *json.RawMessage  `json:"id"`
+}
+
+//fixtureResponse is a json-rpc response of the fixture node
+type fixtureResponse struct {
+	Version string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+var (
+	fixtureNodeOnce sync.Once
+	fixtureNodeAddr string
+	fixtureNodeErr  error
+
+	fixtureRPC *SeeleRPC
+)
+
+//GetSeeleRPC return a client connected to the fixture node
+func GetSeeleRPC() (*SeeleRPC, error) {
+	fixtureNodeOnce.Do(func() {
+		fixtureNodeAddr, fixtureNodeErr = startFixtureNode()
+	})
+	if fixtureNodeErr != nil {
+		return nil, fixtureNodeErr
+	}
+
+	fixtureRPC = NewRPC(fixtureNodeAddr, WithRetry(0, 0))
+	if err := fixtureRPC.Connect(); err != nil {
+		return nil, err
+	}
+	return fixtureRPC, nil
+}
+
+//ReleaseSeeleRPC release the client returned by GetSeeleRPC
+func ReleaseSeeleRPC() {
+	fixtureRPC.Release()
+}
+
+//startFixtureNode serve the recorded responses in testdata, the file of a method is testdata/<method>.json
+func startFixtureNode() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveFixtures(conn)
+		}
+	}()
+	return l.Addr().String(), nil
+}
+
+func serveFixtures(conn net.Conn) {
+	defer conn.Close()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return
+		}
+
+		if len(raw) > 0 && raw[0] == '[' {
+			var reqs []fixtureRequest
+			if err := json.Unmarshal(raw, &reqs); err != nil {
+				return
+			}
+
+			resps := make([]fixtureResponse, 0, len(reqs))
+			for i := range reqs {
+				resps = append(resps, answerFixture(&reqs[i]))
+			}
+			enc.Encode(resps)
+			continue
+		}
+
+		var req fixtureRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return
+		}
+		enc.Encode(answerFixture(&req))
+	}
+}
+
+//answerFixture find the fixture of the method with the same params
+func answerFixture(req *fixtureRequest) fixtureResponse {
+	resp := fixtureResponse{Version: jsonrpcVersion, ID: req.ID}
+
+	fixtures, err := loadFixtures(req.Method)
+	if err != nil {
+		resp.Error = NewError(errMethod.Code, err.Error())
+		return resp
+	}
+
+	var params json.RawMessage
+	if len(req.Params) > 0 {
+		params = req.Params[0]
+	}
+
+	for _, f := range fixtures {
+		if sameJSON(f.Params, params) {
+			resp.Result, resp.Error = f.Result, f.Error
+			return resp
+		}
+	}
+
+	resp.Error = NewError(errParams.Code, fmt.Sprintf("no fixture of %s with params %s", req.Method, params))
+	return resp
+}
+
+func loadFixtures(method string) ([]fixture, error) {
+	buff, err := ioutil.ReadFile(filepath.Join("testdata", method+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []fixture
+	err = json.Unmarshal(buff, &fixtures)
+	return fixtures, err
+}
+
+//sameJSON return whether the two json values are equal, a missing value equals null
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &va); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &vb); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -126,8 +126,8 @@ func Test_Server(t *testing.T) {
 		if resp.Error != nil {
 			t.Fatalf("resp.Error: %s", resp.Error)
 		}
-		if resp.ID.(string) != string(i) {
-			t.Fatalf("resp: bad id %q want %q", resp.ID.(string), string(i))
+		if resp.ID.(string) != string(rune(i)) {
+			t.Fatalf("resp: bad id %q want %q", resp.ID.(string), string(rune(i)))
 		}
 		if resp.Result.C != 2*i+1 {
 			t.Fatalf("resp: bad result: %d+%d=%d", i, i+1, resp.Result.C)
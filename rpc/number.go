@@ -0,0 +1,25 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"math/big"
+)
+
+//parseNumber parse an integer in decimal or 0x hex format, or a number in exponent
+//format such as 1e+21, without losing precision
+func parseNumber(s string) (*big.Int, bool) {
+	if b, ok := new(big.Int).SetString(s, 0); ok {
+		return b, true
+	}
+
+	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return nil, false
+	}
+	b, _ := f.Int(nil)
+	return b, true
+}
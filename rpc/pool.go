@@ -0,0 +1,423 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCheckInterval = 10 * time.Second
+	defaultCheckTimeout  = 5 * time.Second
+	defaultMaxLag        = 3
+
+	//latencyWeight the weight of a new sample in the moving average of the latency
+	latencyWeight = 0.2
+)
+
+//ErrNoEndpoint is returned when no healthy endpoint of the pool has the requested height
+var ErrNoEndpoint = errors.New("no healthy rpc endpoint")
+
+//EndpointStatus is the state of an endpoint in the pool
+type EndpointStatus struct {
+	URL       string
+	Healthy   bool
+	Height    uint64
+	Latency   time.Duration
+	LastCheck time.Time
+	LastError string
+	RPC       Stats
+}
+
+//endpoint is a seele node in the pool
+type endpoint struct {
+	url    string
+	client *SeeleRPC
+
+	lock      sync.RWMutex
+	healthy   bool
+	height    uint64
+	latency   time.Duration
+	lastCheck time.Time
+	lastError string
+}
+
+//succeed record a successful call which took the latency
+func (e *endpoint) succeed(latency time.Duration) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.healthy = true
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(float64(e.latency)*(1-latencyWeight) + float64(latency)*latencyWeight)
+	}
+}
+
+//fail record a failed call, the endpoint is unhealthy until the next successful check if the connection failed
+func (e *endpoint) fail(err error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.lastError = err.Error()
+	if isConnError(err) {
+		e.healthy = false
+	}
+}
+
+//setHeight record the current height of the node
+func (e *endpoint) setHeight(height uint64) {
+	e.lock.Lock()
+	e.height = height
+	e.lock.Unlock()
+}
+
+func (e *endpoint) status() EndpointStatus {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return EndpointStatus{
+		URL:       e.url,
+		Healthy:   e.healthy,
+		Height:    e.height,
+		Latency:   e.latency,
+		LastCheck: e.lastCheck,
+		LastError: e.lastError,
+		RPC:       e.client.Stats(),
+	}
+}
+
+//Pool route the calls to several seele nodes of the same shard. The height and the latency of
+//every node are checked periodically, a call goes to the fastest healthy node which is not
+//lagging behind, and fails over to the next one if the node could not serve it
+type Pool struct {
+	endpoints []*endpoint
+
+	checkInterval time.Duration
+	checkTimeout  time.Duration
+	maxLag        uint64
+	clientOptions []func(rpc *SeeleRPC)
+
+	quit     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+//NewPool return a pool of the given urls, the duplicated urls are ignored
+func NewPool(urls []string, options ...func(p *Pool)) (*Pool, error) {
+	p := &Pool{
+		checkInterval: defaultCheckInterval,
+		checkTimeout:  defaultCheckTimeout,
+		maxLag:        defaultMaxLag,
+		quit:          make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		p.endpoints = append(p.endpoints, &endpoint{url: url, client: NewRPC(url, p.clientOptions...)})
+	}
+
+	if len(p.endpoints) == 0 {
+		return nil, errors.New("no rpc endpoint in the pool")
+	}
+	return p, nil
+}
+
+//WithCheckInterval set the interval of the health checks
+func WithCheckInterval(interval time.Duration) func(p *Pool) {
+	return func(p *Pool) {
+		if interval > 0 {
+			p.checkInterval = interval
+		}
+	}
+}
+
+//WithMaxLag set the max number of blocks an endpoint may be behind the highest one and still serve calls
+func WithMaxLag(maxLag uint64) func(p *Pool) {
+	return func(p *Pool) {
+		p.maxLag = maxLag
+	}
+}
+
+//WithClientOptions set the options of the client of every endpoint
+func WithClientOptions(options ...func(rpc *SeeleRPC)) func(p *Pool) {
+	return func(p *Pool) {
+		p.clientOptions = append(p.clientOptions, options...)
+	}
+}
+
+//Start check all the endpoints and keep checking them in background until the pool is closed,
+//it returns ErrNoEndpoint if none is healthy, the pool is still usable once any of them recovers
+func (p *Pool) Start() error {
+	p.Check()
+
+	p.wg.Add(1)
+	go p.checkLoop()
+
+	if !p.anyHealthy() {
+		return ErrNoEndpoint
+	}
+	return nil
+}
+
+//Close stop the health checks and release all the connections
+func (p *Pool) Close() {
+	p.stopOnce.Do(func() { close(p.quit) })
+	p.wg.Wait()
+
+	for _, e := range p.endpoints {
+		e.client.Release()
+	}
+}
+
+func (p *Pool) checkLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.Check()
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+//Check get the current block of every endpoint to update its health, height and latency
+func (p *Pool) Check() {
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), p.checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			block, err := e.client.CurrentBlockContext(ctx)
+
+			e.lock.Lock()
+			e.lastCheck = time.Now()
+			if err != nil {
+				//a node which can not tell its height is not used whatever the error is
+				e.healthy = false
+				e.lastError = err.Error()
+			}
+			e.lock.Unlock()
+
+			if err != nil {
+				return
+			}
+
+			e.setHeight(block.Height)
+			e.succeed(time.Since(start))
+		}(e)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) anyHealthy() bool {
+	for _, e := range p.endpoints {
+		if e.status().Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+//Height return the highest height of the healthy endpoints
+func (p *Pool) Height() uint64 {
+	var height uint64
+	for _, e := range p.endpoints {
+		status := e.status()
+		if status.Healthy && status.Height > height {
+			height = status.Height
+		}
+	}
+	return height
+}
+
+//Status return the state of every endpoint
+func (p *Pool) Status() []EndpointStatus {
+	status := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		status = append(status, e.status())
+	}
+	return status
+}
+
+//Stats return the sum of the call counters of all the endpoints, it is connected if any endpoint is
+func (p *Pool) Stats() Stats {
+	var stats Stats
+	for _, e := range p.endpoints {
+		s := e.client.Stats()
+		stats.Connected = stats.Connected || s.Connected
+		stats.Connects += s.Connects
+		stats.Disconnects += s.Disconnects
+		stats.Calls += s.Calls
+		stats.Failures += s.Failures
+		stats.Retries += s.Retries
+		stats.Timeouts += s.Timeouts
+		if s.LastConnect.After(stats.LastConnect) {
+			stats.LastConnect = s.LastConnect
+			stats.LastError = s.LastError
+		}
+	}
+	return stats
+}
+
+//candidates return the healthy endpoints which have the height and are not lagging behind, the fastest first
+func (p *Pool) candidates(minHeight uint64) []*endpoint {
+	best := p.Height()
+	var candidates []*endpoint
+	var latencies []time.Duration
+	for _, e := range p.endpoints {
+		status := e.status()
+		if !status.Healthy || status.Height < minHeight || status.Height+p.maxLag < best {
+			continue
+		}
+		candidates = append(candidates, e)
+		latencies = append(latencies, status.Latency)
+	}
+
+	sort.Sort(byLatency{candidates, latencies})
+	return candidates
+}
+
+type byLatency struct {
+	endpoints []*endpoint
+	latencies []time.Duration
+}
+
+func (s byLatency) Len() int           { return len(s.endpoints) }
+func (s byLatency) Less(i, j int) bool { return s.latencies[i] < s.latencies[j] }
+func (s byLatency) Swap(i, j int) {
+	s.endpoints[i], s.endpoints[j] = s.endpoints[j], s.endpoints[i]
+	s.latencies[i], s.latencies[j] = s.latencies[j], s.latencies[i]
+}
+
+//Do call fn with the client of the fastest endpoint which has the height minHeight, so that all
+//the calls in fn are pinned to a single node. If fn fails it is called again with the next endpoint,
+//so fn must be idempotent. It returns ErrNoEndpoint if no endpoint has the height
+func (p *Pool) Do(minHeight uint64, fn func(client *SeeleRPC) error) error {
+	return p.do(minHeight, func(e *endpoint) error { return fn(e.client) })
+}
+
+func (p *Pool) do(minHeight uint64, fn func(e *endpoint) error) error {
+	err := ErrNoEndpoint
+	for _, e := range p.candidates(minHeight) {
+		start := time.Now()
+		if err = fn(e); err == nil {
+			e.succeed(time.Since(start))
+			return nil
+		}
+
+		e.fail(err)
+		if err == context.Canceled {
+			return err
+		}
+	}
+	return err
+}
+
+//Each call fn with the client of every healthy endpoint
+func (p *Pool) Each(fn func(url string, client *SeeleRPC) error) {
+	for _, e := range p.endpoints {
+		if !e.status().Healthy {
+			continue
+		}
+
+		start := time.Now()
+		if err := fn(e.url, e.client); err != nil {
+			e.fail(err)
+		} else {
+			e.succeed(time.Since(start))
+		}
+	}
+}
+
+//CurrentBlock return the current block of the highest endpoint
+func (p *Pool) CurrentBlock() (*CurrentBlock, error) {
+	var block *CurrentBlock
+	err := p.do(p.Height(), func(e *endpoint) (err error) {
+		if block, err = e.client.CurrentBlock(); err == nil {
+			e.setHeight(block.Height)
+		}
+		return err
+	})
+	return block, err
+}
+
+//DoWithHead get the current block of the fastest endpoint and call fn with it and the client of
+//that endpoint, so the calls in fn are made against the node which reported the head
+func (p *Pool) DoWithHead(fn func(client *SeeleRPC, head *CurrentBlock) error) error {
+	return p.do(p.Height(), func(e *endpoint) error {
+		head, err := e.client.CurrentBlock()
+		if err != nil {
+			return err
+		}
+
+		e.setHeight(head.Height)
+		return fn(e.client, head)
+	})
+}
+
+//GetBlockByHeight get the block from an endpoint which has the height
+func (p *Pool) GetBlockByHeight(h uint64, fullTx bool) (*BlockInfo, error) {
+	var block *BlockInfo
+	err := p.Do(h, func(client *SeeleRPC) (err error) {
+		block, err = client.GetBlockByHeight(h, fullTx)
+		return err
+	})
+	return block, err
+}
+
+//GetBalances get the balances of the accounts from a single endpoint, the balance of a failed
+//request is nil and its error is in the BatchError
+func (p *Pool) GetBalances(addresses []string) ([]*big.Int, error) {
+	var balances []*big.Int
+	var batchErr error
+	err := p.Do(0, func(client *SeeleRPC) (err error) {
+		batchErr = nil
+		balances, err = client.GetBalances(addresses)
+		if _, ok := err.(*BatchError); ok {
+			batchErr = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, batchErr
+}
+
+//GetPendingTransactions get the pending transactions in the pool of the fastest endpoint
+func (p *Pool) GetPendingTransactions() ([]Transaction, error) {
+	var txs []Transaction
+	err := p.Do(0, func(client *SeeleRPC) (err error) {
+		txs, err = client.GetPendingTransactions()
+		return err
+	})
+	return txs, err
+}
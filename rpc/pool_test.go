@@ -0,0 +1,151 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"testing"
+)
+
+//testNode is a seele node at the given height, a broken node only serves the current block
+type testNode struct {
+	height int64
+	broken bool
+}
+
+func (n *testNode) GetBlockByHeight(req GetBlockByHeightRequest, reply *map[string]interface{}) error {
+	height := req.Height
+	if height < 0 {
+		height = n.height
+	} else if height > n.height || n.broken {
+		return errors.New("block not found")
+	}
+
+	*reply = map[string]interface{}{
+		"hash":       "0x01",
+		"parentHash": "0x00",
+		"height":     height,
+		"timestamp":  1,
+		"creator":    "0xc1",
+	}
+	return nil
+}
+
+//startTestNode serve the node on a local tcp port and return the url
+func startTestNode(t *testing.T, node *testNode) string {
+	srv := rpc.NewServer()
+	srv.RegisterName("seele", node)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go srv.ServeCodec(NewJSONCodec(conn, srv))
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestPoolPinHeight(t *testing.T) {
+	low := startTestNode(t, &testNode{height: 10})
+	high := startTestNode(t, &testNode{height: 20})
+
+	pool, err := NewPool([]string{low, high, low}, WithMaxLag(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pool.Status()) != 2 || pool.Height() != 20 {
+		t.Fatalf("bad pool status %+v", pool.Status())
+	}
+
+	for _, h := range []uint64{5, 15, 20} {
+		block, err := pool.GetBlockByHeight(h, false)
+		if err != nil || block.Height != h {
+			t.Fatalf("get block %d failed, %v", h, err)
+		}
+	}
+
+	if _, err := pool.GetBlockByHeight(21, false); err != ErrNoEndpoint {
+		t.Fatalf("expected ErrNoEndpoint, got %v", err)
+	}
+}
+
+func TestPoolFailover(t *testing.T) {
+	broken := startTestNode(t, &testNode{height: 20, broken: true})
+	good := startTestNode(t, &testNode{height: 20})
+	unreachable := "127.0.0.1:1"
+
+	pool, err := NewPool([]string{unreachable, broken, good}, WithClientOptions(WithRetry(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, status := range pool.Status() {
+		if status.Healthy != (status.URL != unreachable) {
+			t.Fatalf("bad health of %s, %+v", status.URL, status)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		block, err := pool.GetBlockByHeight(15, false)
+		if err != nil || block.Height != 15 {
+			t.Fatalf("failover failed, %v", err)
+		}
+	}
+}
+
+func TestPoolDoWithHead(t *testing.T) {
+	low := startTestNode(t, &testNode{height: 10})
+	high := startTestNode(t, &testNode{height: 20})
+
+	pool, err := NewPool([]string{low, high}, WithMaxLag(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		err := pool.DoWithHead(func(client *SeeleRPC, head *CurrentBlock) error {
+			if head.Height != 20 {
+				t.Fatalf("head %d is not got from the highest node", head.Height)
+			}
+
+			//the node which reported the head must serve it
+			block, err := client.GetBlockByHeight(head.Height, false)
+			if err != nil || block.Height != head.Height {
+				t.Fatalf("get head block %d failed, %v", head.Height, err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	netrpc "net/rpc"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	schemeReplay = "replay"
+
+	//the kinds of recorded errors, they tell how the error is replayed
+	errorKindServer  = "server"
+	errorKindTimeout = "timeout"
+	errorKindConn    = "conn"
+	errorKindOther   = "other"
+
+	maxRecordingLine = 64 * 1024 * 1024
+)
+
+//Recording is a request sent to seele node and its response, it is a line of the ndjson file of a Recorder
+type Recording struct {
+	Time   time.Time       `json:"time"`
+	URL    string          `json:"url"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	//ErrorKind is server, timeout, conn or other, the replayed error is of the same kind
+	ErrorKind string `json:"errorKind,omitempty"`
+}
+
+//replayError return the error of the recording the way the transport returned it
+func (rec *Recording) replayError() error {
+	switch rec.ErrorKind {
+	case "":
+		return nil
+	case errorKindServer:
+		return netrpc.ServerError(rec.Error)
+	case errorKindTimeout:
+		return context.DeadlineExceeded
+	case errorKindConn:
+		return io.ErrUnexpectedEOF
+	}
+	return errors.New(rec.Error)
+}
+
+//errorKind return the kind of the error returned by a transport
+func errorKind(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case err == context.DeadlineExceeded:
+		return errorKindTimeout
+	case isConnError(err):
+		return errorKindConn
+	}
+
+	if _, ok := err.(netrpc.ServerError); ok {
+		return errorKindServer
+	}
+	return errorKindOther
+}
+
+//Recorder write every request and response of the clients using it to a ndjson file,
+//the file can be served back by a Replay
+type Recorder struct {
+	lock   sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+//NewRecorder return a recorder writing to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+//CreateRecorder return a recorder appending to the file, the file is created if it does not exist
+func CreateRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{w: f, closer: f}, nil
+}
+
+//Record write the recording as a line
+func (r *Recorder) Record(rec *Recording) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
+
+//Close close the file of the recorder
+func (r *Recorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+//record write the request and the response of a call, a recording which can not be written
+//is dropped since recording must not break the sync
+func (r *Recorder) record(url, method string, args, reply interface{}, err error) {
+	params, marshalErr := json.Marshal(args)
+	if marshalErr != nil {
+		return
+	}
+
+	rec := &Recording{
+		Time:      time.Now(),
+		URL:       url,
+		Method:    method,
+		Params:    params,
+		ErrorKind: errorKind(err),
+	}
+
+	if err != nil {
+		rec.Error = err.Error()
+	} else if raw, ok := reply.(*json.RawMessage); ok {
+		rec.Result = *raw
+	} else if rec.Result, marshalErr = json.Marshal(reply); marshalErr != nil {
+		return
+	}
+
+	r.Record(rec)
+}
+
+//recordTransport record the calls sent over the transport
+type recordTransport struct {
+	transport
+	url      string
+	recorder *Recorder
+}
+
+func (t *recordTransport) call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	err := t.transport.call(ctx, serviceMethod, args, reply)
+	t.recorder.record(t.url, serviceMethod, args, reply, err)
+	return err
+}
+
+func (t *recordTransport) batch(ctx context.Context, elems []batchElem) error {
+	err := t.transport.batch(ctx, elems)
+	for i := range elems {
+		if err != nil {
+			t.recorder.record(t.url, elems[i].method, elems[i].args, nil, err)
+		} else {
+			t.recorder.record(t.url, elems[i].method, elems[i].args, elems[i].reply, elems[i].err)
+		}
+	}
+	return err
+}
+
+//NotRecordedError is returned by a Replay for a request which was not recorded
+type NotRecordedError struct {
+	Method string
+	Params string
+}
+
+func (e *NotRecordedError) Error() string {
+	return fmt.Sprintf("no recorded response to %s %s", e.Method, e.Params)
+}
+
+//Replay serve the responses of a recording, the responses to the same request are served
+//in the recorded order and the last one is served again once they run out
+type Replay struct {
+	lock      sync.Mutex
+	responses map[string][]*Recording
+	served    map[string]int
+}
+
+//NewReplay read the recordings from the ndjson stream written by a Recorder
+func NewReplay(r io.Reader) (*Replay, error) {
+	replay := &Replay{
+		responses: make(map[string][]*Recording),
+		served:    make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordingLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		rec := &Recording{}
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			return nil, fmt.Errorf("invalid recording at line %d, %v", line, err)
+		}
+
+		key := replayKey(rec.Method, rec.Params)
+		replay.responses[key] = append(replay.responses[key], rec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+//LoadReplay read the recordings from the file written by a Recorder
+func LoadReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewReplay(f)
+}
+
+//next return the recorded response to the request
+func (r *Replay) next(method string, params []byte) (*Recording, bool) {
+	key := replayKey(method, params)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	responses := r.responses[key]
+	if len(responses) == 0 {
+		return nil, false
+	}
+
+	i := r.served[key]
+	if i >= len(responses) {
+		return responses[len(responses)-1], true
+	}
+	r.served[key] = i + 1
+	return responses[i], true
+}
+
+//replayKey return the key of the request, the params are compacted so that edited recordings still match
+func replayKey(method string, params []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, params); err != nil {
+		return method + " " + string(params)
+	}
+	return method + " " + buf.String()
+}
+
+//replayTransport answer the calls with the responses of a Replay instead of sending them to seele node
+type replayTransport struct {
+	replay *Replay
+}
+
+func (t *replayTransport) call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	params, err := json.Marshal(args)
+	if err != nil {
+		return NewError(errInternal.Code, err.Error())
+	}
+
+	rec, ok := t.replay.next(serviceMethod, params)
+	if !ok {
+		return &NotRecordedError{Method: serviceMethod, Params: string(params)}
+	}
+
+	if err := rec.replayError(); err != nil {
+		return err
+	}
+
+	if raw, ok := reply.(*json.RawMessage); ok {
+		*raw = append(json.RawMessage(nil), rec.Result...)
+		return nil
+	}
+	return decodeHTTPResponse(&clientResponse{Result: &rec.Result}, reply)
+}
+
+func (t *replayTransport) batch(ctx context.Context, elems []batchElem) error {
+	//the recorded response of every request is taken even if the whole batch failed,
+	//so that the retried batch gets the next ones
+	var err error
+	for i := range elems {
+		elems[i].err = t.call(ctx, elems[i].method, elems[i].args, elems[i].reply)
+		if err == nil && isConnError(elems[i].err) {
+			err = elems[i].err
+		}
+	}
+	return err
+}
+
+func (t *replayTransport) close() error {
+	return nil
+}
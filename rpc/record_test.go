@@ -0,0 +1,106 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	if _, err := GetSeeleRPC(); err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	var buf bytes.Buffer
+	recorded := NewRPC(fixtureNodeAddr, WithRetry(0, 0), WithRecorder(NewRecorder(&buf)))
+	defer recorded.Release()
+
+	current, err := recorded.CurrentBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := recorded.GetBlockByHeight(current.Height-1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, missingErr := recorded.GetBlockByHeight(current.Height+100, true)
+	if missingErr == nil {
+		t.Fatal("expected the error of a missing block")
+	}
+
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sync.ndjson")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := NewRPC("replay://"+path, WithRetry(0, 0))
+	for i := 0; i < 2; i++ {
+		replayedCurrent, err := replayed.CurrentBlock()
+		if err != nil || !reflect.DeepEqual(replayedCurrent, current) {
+			t.Fatalf("bad replayed current block %+v, %v", replayedCurrent, err)
+		}
+	}
+
+	replayedBlock, err := replayed.GetBlockByHeight(current.Height-1, true)
+	if err != nil || !reflect.DeepEqual(replayedBlock, block) {
+		t.Fatalf("bad replayed block %+v, %v", replayedBlock, err)
+	}
+
+	if _, err := replayed.GetBlockByHeight(current.Height+100, true); err == nil || err.Error() != missingErr.Error() {
+		t.Fatalf("expected the recorded error %v, got %v", missingErr, err)
+	}
+
+	if _, err := replayed.GetBlockByHeight(1, true); err == nil {
+		t.Fatal("expected the error of a request which was not recorded")
+	} else if _, ok := err.(*NotRecordedError); !ok {
+		t.Fatalf("expected NotRecordedError, got %v", err)
+	}
+}
+
+func TestReplayOrder(t *testing.T) {
+	recording := strings.Join([]string{
+		`{"method":"seele.GetBalance","params":"0x01","result":1}`,
+		`{"method":"seele.GetBalance","params":"0x01","error":"EOF","errorKind":"conn"}`,
+		``,
+		`{"method":"seele.GetBalance","params": "0x01","result":2}`,
+	}, "\n")
+
+	replay, err := NewReplay(strings.NewReader(recording))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewRPC("replay://", WithReplay(replay), WithRetry(1, 1))
+	balance, err := client.GetBalance("0x01")
+	if err != nil || balance.Int64() != 1 {
+		t.Fatalf("bad first balance %v, %v", balance, err)
+	}
+
+	//the recorded connection error is retried the way it was when it was recorded
+	for i := 0; i < 2; i++ {
+		balance, err = client.GetBalance("0x01")
+		if err != nil || balance.Int64() != 2 {
+			t.Fatalf("bad balance %v, %v", balance, err)
+		}
+	}
+
+	if stats := client.Stats(); stats.Retries != 1 {
+		t.Fatalf("expected a retry, %+v", stats)
+	}
+}
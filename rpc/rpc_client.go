@@ -5,18 +5,72 @@
 
 package rpc
 
-// SeeleRPC json_rpc client
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	netrpc "net/rpc"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCallTimeout   = 30 * time.Second
+	defaultMaxRetries    = 3
+	defaultRetryDelay    = 500 * time.Millisecond
+	defaultMaxRetryDelay = 10 * time.Second
+)
+
+//nonIdempotentMethods the methods which must not be sent twice
+var nonIdempotentMethods = map[string]bool{
+	"seele.AddTx": true,
+}
+
+//Stats is the connection state of a SeeleRPC
+type Stats struct {
+	Connected   bool
+	Connects    uint64
+	Disconnects uint64
+	Calls       uint64
+	Failures    uint64
+	Retries     uint64
+	Timeouts    uint64
+	LastError   string
+	LastConnect time.Time
+}
+
+// SeeleRPC json_rpc client, it connects on demand and reconnects after the connection is lost,
+// so it is safe to call any method after Release. The transport is chosen by the url scheme:
+// tcp:// or no scheme, http:// or https://, ws:// or wss://, replay://<file> serves the recording in the file
 type SeeleRPC struct {
 	url    string
 	scheme string
-	conn   *Client
+
+	timeout       time.Duration
+	maxRetries    int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+
+	recorder *Recorder
+	replay   *Replay
+
+	lock  sync.Mutex
+	conn  transport
+	stats Stats
 }
 
 // NewRPC create new json_rpc client with given url
 func NewRPC(url string, options ...func(rpc *SeeleRPC)) *SeeleRPC {
+	scheme, address := splitURL(url)
 	rpc := &SeeleRPC{
-		url:    url,
-		scheme: "tcp",
+		url:           address,
+		scheme:        scheme,
+		timeout:       defaultCallTimeout,
+		maxRetries:    defaultMaxRetries,
+		retryDelay:    defaultRetryDelay,
+		maxRetryDelay: defaultMaxRetryDelay,
 	}
 	for _, option := range options {
 		option(rpc)
@@ -24,31 +78,296 @@ func NewRPC(url string, options ...func(rpc *SeeleRPC)) *SeeleRPC {
 	return rpc
 }
 
-//Connect Create tcp connect
+//WithTimeout set the deadline of a single call, which is used when the context has no deadline
+func WithTimeout(timeout time.Duration) func(rpc *SeeleRPC) {
+	return func(rpc *SeeleRPC) {
+		rpc.timeout = timeout
+	}
+}
+
+//WithRetry set the max number of retries of an idempotent call and the first backoff delay,
+//the delay doubles after every retry
+func WithRetry(maxRetries int, delay time.Duration) func(rpc *SeeleRPC) {
+	return func(rpc *SeeleRPC) {
+		if maxRetries >= 0 {
+			rpc.maxRetries = maxRetries
+		}
+		if delay > 0 {
+			rpc.retryDelay = delay
+		}
+	}
+}
+
+//WithRecorder record every request and its response with the recorder
+func WithRecorder(recorder *Recorder) func(rpc *SeeleRPC) {
+	return func(rpc *SeeleRPC) {
+		rpc.recorder = recorder
+	}
+}
+
+//WithReplay answer the requests with the recorded responses instead of sending them to seele node
+func WithReplay(replay *Replay) func(rpc *SeeleRPC) {
+	return func(rpc *SeeleRPC) {
+		rpc.replay = replay
+	}
+}
+
+//Connect Create the connection to seele node
 func (rpc *SeeleRPC) Connect() error {
-	if rpc.conn == nil {
-		conn, err := Dial(rpc.scheme, rpc.url)
+	_, err := rpc.getConn()
+	return err
+}
+
+//Release release current rpc, the next call connects again
+func (rpc *SeeleRPC) Release() {
+	if rpc == nil {
+		return
+	}
+
+	rpc.lock.Lock()
+	defer rpc.lock.Unlock()
+	rpc.closeConn()
+}
+
+//Stats return the connection state and the call counters
+func (rpc *SeeleRPC) Stats() Stats {
+	rpc.lock.Lock()
+	defer rpc.lock.Unlock()
+	return rpc.stats
+}
+
+//getConn return the current connection, connect if there is none
+func (rpc *SeeleRPC) getConn() (transport, error) {
+	rpc.lock.Lock()
+	defer rpc.lock.Unlock()
+
+	if rpc.conn != nil {
+		return rpc.conn, nil
+	}
+
+	conn, err := rpc.dial()
+	if err != nil {
+		rpc.stats.LastError = err.Error()
+		return nil, err
+	}
+
+	rpc.conn = conn
+	rpc.stats.Connected = true
+	rpc.stats.Connects++
+	rpc.stats.LastConnect = time.Now()
+	return conn, nil
+}
+
+//dial connect to seele node, or to the replay of the recording, the lock must be held
+func (rpc *SeeleRPC) dial() (transport, error) {
+	if rpc.replay == nil && rpc.scheme == schemeReplay {
+		replay, err := LoadReplay(rpc.url)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		rpc.replay = replay
+	}
+
+	var conn transport
+	if rpc.replay != nil {
+		conn = &replayTransport{rpc.replay}
+	} else {
+		var err error
+		if conn, err = dialTransport(rpc.scheme, rpc.url); err != nil {
+			return nil, err
 		}
-		rpc.conn = conn
 	}
-	return nil
+
+	if rpc.recorder != nil {
+		conn = &recordTransport{transport: conn, url: rpc.url, recorder: rpc.recorder}
+	}
+	return conn, nil
 }
 
-//Release release current rpc
-func (rpc *SeeleRPC) Release() {
-	if rpc != nil && rpc.conn != nil {
-		rpc.conn.Close()
+//dropConn close the connection if it is still the current one
+func (rpc *SeeleRPC) dropConn(conn transport) {
+	rpc.lock.Lock()
+	defer rpc.lock.Unlock()
+	if rpc.conn == conn {
+		rpc.closeConn()
+	}
+}
+
+//closeConn close the current connection, the lock must be held
+func (rpc *SeeleRPC) closeConn() {
+	if rpc.conn != nil {
+		rpc.conn.close()
 		rpc.conn = nil
+		rpc.stats.Connected = false
+		rpc.stats.Disconnects++
+	}
+}
+
+//record update the call counters
+func (rpc *SeeleRPC) record(err error, retry bool) {
+	rpc.lock.Lock()
+	defer rpc.lock.Unlock()
+
+	if retry {
+		rpc.stats.Retries++
+		return
+	}
+
+	rpc.stats.Calls++
+	if err != nil {
+		rpc.stats.Failures++
+		rpc.stats.LastError = err.Error()
+		if err == context.DeadlineExceeded {
+			rpc.stats.Timeouts++
+		}
 	}
 }
 
 func (rpc *SeeleRPC) call(serviceMethod string, args interface{}, reply interface{}) error {
-	err := rpc.conn.Call(serviceMethod, args, &reply)
+	return rpc.callContext(context.Background(), serviceMethod, args, reply)
+}
+
+//callContext send the request, an idempotent call is retried with backoff if the connection fails
+func (rpc *SeeleRPC) callContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := rpc.callOnce(ctx, serviceMethod, args, reply)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		if err == nil || ctx.Err() != nil || !isConnError(err) || nonIdempotentMethods[serviceMethod] || attempt >= rpc.maxRetries {
+			rpc.record(err, false)
+			return err
+		}
+
+		rpc.record(err, true)
+		select {
+		case <-time.After(rpc.backoff(attempt)):
+		case <-ctx.Done():
+			rpc.record(ctx.Err(), false)
+			return ctx.Err()
+		}
+	}
+}
+
+//batchContext send the requests in a single batch, the whole batch is retried with backoff
+//if the connection fails and all the requests are idempotent
+func (rpc *SeeleRPC) batchContext(ctx context.Context, elems []batchElem) error {
+	idempotent := true
+	for i := range elems {
+		if nonIdempotentMethods[elems[i].method] {
+			idempotent = false
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := rpc.batchOnce(ctx, elems)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+
+		if err == nil || ctx.Err() != nil || !isConnError(err) || !idempotent || attempt >= rpc.maxRetries {
+			rpc.record(err, false)
+			return err
+		}
+
+		rpc.record(err, true)
+		select {
+		case <-time.After(rpc.backoff(attempt)):
+		case <-ctx.Done():
+			rpc.record(ctx.Err(), false)
+			return ctx.Err()
+		}
+	}
+}
+
+//batchOnce send the batch once, the connection is dropped if it fails or the batch times out
+func (rpc *SeeleRPC) batchOnce(ctx context.Context, elems []batchElem) error {
+	conn, err := rpc.getConn()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if _, ok := ctx.Deadline(); !ok && rpc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rpc.timeout)
+		defer cancel()
+	}
+
+	for i := range elems {
+		elems[i].err = nil
+	}
+
+	err = conn.batch(ctx, elems)
+	if ctx.Err() != nil {
+		rpc.dropConn(conn)
+		return ctx.Err()
+	}
+
+	if isConnError(err) {
+		rpc.dropConn(conn)
+		return err
+	}
+
+	//the connection is lost in the middle of the batch
+	for i := range elems {
+		if isConnError(elems[i].err) {
+			rpc.dropConn(conn)
+			return elems[i].err
+		}
+	}
+	return err
+}
+
+//callOnce send the request once, the connection is dropped if it fails or the call times out
+func (rpc *SeeleRPC) callOnce(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	conn, err := rpc.getConn()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok && rpc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rpc.timeout)
+		defer cancel()
+	}
+
+	err = conn.call(ctx, serviceMethod, args, reply)
+	if ctx.Err() != nil {
+		//the reply may still arrive on the connection, it can not be reused
+		rpc.dropConn(conn)
+		return ctx.Err()
+	}
+
+	if isConnError(err) {
+		rpc.dropConn(conn)
+	}
+	return err
+}
+
+//backoff return the delay before the retry, it doubles after every retry and has a random jitter
+func (rpc *SeeleRPC) backoff(attempt int) time.Duration {
+	delay := rpc.retryDelay << uint(attempt)
+	if delay <= 0 || delay > rpc.maxRetryDelay {
+		delay = rpc.maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+//isConnError return whether the error is caused by the connection instead of the node
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if err == netrpc.ErrShutdown || err == io.EOF || err == io.ErrUnexpectedEOF || err == context.DeadlineExceeded {
+		return true
+	}
+
+	if e, ok := err.(*httpStatusError); ok {
+		return e.code >= http.StatusInternalServerError
+	}
+
+	_, ok := err.(net.Error)
+	return ok
 }
@@ -0,0 +1,227 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/rpc"
+	"sync"
+	"testing"
+	"time"
+)
+
+//testServer accept the connections on a local tcp port and handle each of them with handle
+type testServer struct {
+	net.Listener
+	handle func(conn net.Conn)
+
+	lock  sync.Mutex
+	conns []net.Conn
+}
+
+func startTestServer(t *testing.T, handle func(conn net.Conn)) *testServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{Listener: l, handle: handle}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			s.lock.Lock()
+			s.conns = append(s.conns, conn)
+			s.lock.Unlock()
+			go handle(conn)
+		}
+	}()
+	return s
+}
+
+//dropConns close all the accepted connections
+func (s *testServer) dropConns() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+//serveNode serve the seele api of the node on the connection
+func serveNode(node *testNode) func(conn net.Conn) {
+	srv := rpc.NewServer()
+	srv.RegisterName("seele", node)
+	return func(conn net.Conn) {
+		srv.ServeCodec(NewJSONCodec(conn, srv))
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	server := startTestServer(t, serveNode(&testNode{height: 10}))
+	defer server.Close()
+
+	client := NewRPC(server.Addr().String(), WithRetry(1, time.Millisecond))
+	defer client.Release()
+
+	getBlock := func(what string) {
+		if block, err := client.GetBlockByHeight(5, false); err != nil || block.Height != 5 {
+			t.Fatalf("get block %s failed, %v", what, err)
+		}
+	}
+
+	getBlock("on the first connection")
+	client.Release()
+	getBlock("after release")
+	if stats := client.Stats(); stats.Connects != 2 || stats.Disconnects != 1 || stats.Retries != 0 {
+		t.Fatalf("bad stats after release %+v", stats)
+	}
+
+	//the dropped connection is only found by the next call, which is retried on a new one
+	server.dropConns()
+	getBlock("after the connection is dropped")
+	if stats := client.Stats(); stats.Connects != 3 || stats.Retries != 1 || stats.Failures != 0 {
+		t.Fatalf("bad stats after the connection is dropped %+v", stats)
+	}
+}
+
+func TestClientMaxRetries(t *testing.T) {
+	server := startTestServer(t, func(conn net.Conn) { conn.Close() })
+	defer server.Close()
+
+	client := NewRPC(server.Addr().String(), WithRetry(2, time.Millisecond))
+	defer client.Release()
+
+	if _, err := client.GetBlockByHeight(5, false); !isConnError(err) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+
+	if stats := client.Stats(); stats.Connects != 3 || stats.Retries != 2 || stats.Calls != 1 || stats.Failures != 1 {
+		t.Fatalf("bad stats %+v", stats)
+	}
+
+	//a request which must not be sent twice is not retried
+	var reply interface{}
+	if err := client.call("seele.AddTx", nil, &reply); !isConnError(err) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+
+	if stats := client.Stats(); stats.Connects != 4 || stats.Retries != 2 || stats.Calls != 2 {
+		t.Fatalf("the non idempotent call is retried %+v", stats)
+	}
+}
+
+func TestClientNoRetryOnNodeError(t *testing.T) {
+	server := startTestServer(t, serveNode(&testNode{height: 10}))
+	defer server.Close()
+
+	client := NewRPC(server.Addr().String(), WithRetry(2, time.Millisecond))
+	defer client.Release()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetBlockByHeight(20, false); err == nil || isConnError(err) {
+			t.Fatalf("expected the error of the node, got %v", err)
+		}
+	}
+
+	//the connection is kept after the node answers with an error
+	if stats := client.Stats(); stats.Connects != 1 || stats.Retries != 0 || stats.Failures != 2 || !stats.Connected {
+		t.Fatalf("bad stats %+v", stats)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	//the server reads the requests and never answers
+	server := startTestServer(t, func(conn net.Conn) { io.Copy(ioutil.Discard, conn) })
+	defer server.Close()
+
+	timeout := 50 * time.Millisecond
+	client := NewRPC(server.Addr().String(), WithTimeout(timeout), WithRetry(1, time.Millisecond))
+	defer client.Release()
+
+	start := time.Now()
+	if _, err := client.GetBlockByHeight(5, false); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline exceeded, got %v", err)
+	}
+
+	//every attempt has its own deadline
+	if elapsed := time.Since(start); elapsed < 2*timeout || elapsed > 20*timeout {
+		t.Fatalf("bad time of the timed out call %v", elapsed)
+	}
+
+	if stats := client.Stats(); stats.Timeouts != 1 || stats.Retries != 1 || stats.Connects != 2 || stats.Connected {
+		t.Fatalf("bad stats %+v", stats)
+	}
+
+	//the deadline of the context is not extended by the retries
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start = time.Now()
+	if _, err := client.GetBlockByHeightContext(ctx, 5, false); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline exceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*timeout {
+		t.Fatalf("the call is not canceled at the deadline, %v", elapsed)
+	}
+
+	if stats := client.Stats(); stats.Timeouts != 2 || stats.Retries != 1 {
+		t.Fatalf("bad stats %+v", stats)
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	client := NewRPC("127.0.0.1:1", WithRetry(3, 100*time.Millisecond))
+	client.maxRetryDelay = time.Second
+
+	for _, test := range []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond, 800 * time.Millisecond},
+		{4, 500 * time.Millisecond, time.Second},
+		{100, 500 * time.Millisecond, time.Second},
+	} {
+		for i := 0; i < 10; i++ {
+			if delay := client.backoff(test.attempt); delay < test.min || delay > test.max {
+				t.Fatalf("backoff of attempt %d is %v, want [%v, %v]", test.attempt, delay, test.min, test.max)
+			}
+		}
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		conn bool
+	}{
+		{nil, false},
+		{io.EOF, true},
+		{io.ErrUnexpectedEOF, true},
+		{rpc.ErrShutdown, true},
+		{context.DeadlineExceeded, true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{&httpStatusError{http.StatusBadGateway}, true},
+		{&httpStatusError{http.StatusBadRequest}, false},
+		{rpc.ServerError("block not found"), false},
+		{errors.New("block not found"), false},
+	} {
+		if got := isConnError(test.err); got != test.conn {
+			t.Fatalf("isConnError(%v) is %v, want %v", test.err, got, test.conn)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+//GetBlockByHash get block and transaction data from seele node
+func (rpc *SeeleRPC) GetBlockByHash(hash string, fullTx bool) (*BlockInfo, error) {
+	return rpc.GetBlockByHashContext(context.Background(), hash, fullTx)
+}
+
+//GetBlockByHashContext get block and transaction data from seele node, the call is canceled with the context
+func (rpc *SeeleRPC) GetBlockByHashContext(ctx context.Context, hash string, fullTx bool) (*BlockInfo, error) {
+	const method = "seele.GetBlockByHash"
+	request := GetBlockByHashRequest{
+		HashHex: hash,
+		FullTx:  fullTx,
+	}
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, request, &raw); err != nil {
+		return nil, err
+	}
+
+	return decodeBlock(method, raw, fullTx)
+}
+
+//GetTransactionByHash get the transaction in the tx pool or in a block
+func (rpc *SeeleRPC) GetTransactionByHash(txHash string) (*TxInfo, error) {
+	return rpc.GetTransactionByHashContext(context.Background(), txHash)
+}
+
+//GetTransactionByHashContext get the transaction in the tx pool or in a block, the call is canceled with the context
+func (rpc *SeeleRPC) GetTransactionByHashContext(ctx context.Context, txHash string) (*TxInfo, error) {
+	const method = "txpool.GetTransactionByHash"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, &txHash, &raw); err != nil {
+		return nil, err
+	}
+
+	var rpcOutputTx rpcTxInfo
+	if err := decodeResult(method, raw, &rpcOutputTx); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, rpcOutputTx.validate()); err != nil {
+		return nil, err
+	}
+
+	txInfo := &TxInfo{
+		Transaction: rpcOutputTx.Transaction.transaction(),
+		Status:      rpcOutputTx.Status,
+		BlockHash:   rpcOutputTx.BlockHash,
+		BlockHeight: rpcOutputTx.BlockHeight.uint64(),
+		TxIndex:     rpcOutputTx.TxIndex.uint64(),
+	}
+	return txInfo, nil
+}
+
+//GetAccountNonce get the nonce of the account
+func (rpc *SeeleRPC) GetAccountNonce(address string) (uint64, error) {
+	return rpc.GetAccountNonceContext(context.Background(), address)
+}
+
+//GetAccountNonceContext get the nonce of the account, the call is canceled with the context
+func (rpc *SeeleRPC) GetAccountNonceContext(ctx context.Context, address string) (uint64, error) {
+	const method = "seele.GetAccountNonce"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, &address, &raw); err != nil {
+		return 0, err
+	}
+
+	var nonce number
+	if err := decodeResult(method, raw, &nonce); err != nil {
+		return 0, err
+	}
+
+	if err := validationError(method, nonce.required("nonce")); err != nil {
+		return 0, err
+	}
+	return nonce.uint64(), nil
+}
+
+//GetInfo get the state of seele node
+func (rpc *SeeleRPC) GetInfo() (*NodeInfo, error) {
+	return rpc.GetInfoContext(context.Background())
+}
+
+//GetInfoContext get the state of seele node, the call is canceled with the context
+func (rpc *SeeleRPC) GetInfoContext(ctx context.Context) (*NodeInfo, error) {
+	const method = "seele.GetInfo"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, nil, &raw); err != nil {
+		return nil, err
+	}
+
+	var rpcOutputInfo rpcNodeInfo
+	if err := decodeResult(method, raw, &rpcOutputInfo); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, rpcOutputInfo.validate()); err != nil {
+		return nil, err
+	}
+
+	nodeInfo := &NodeInfo{
+		Coinbase:           rpcOutputInfo.Coinbase,
+		CurrentBlockHeight: rpcOutputInfo.CurrentBlockHeight.uint64(),
+		HeaderHash:         rpcOutputInfo.HeaderHash,
+		Shard:              int(rpcOutputInfo.Shard.big().Int64()),
+		MinerStatus:        rpcOutputInfo.MinerStatus,
+		Version:            rpcOutputInfo.Version,
+		BlockAge:           rpcOutputInfo.BlockAge.big(),
+		PeerCnt:            rpcOutputInfo.PeerCnt,
+	}
+	return nodeInfo, nil
+}
+
+//GetNetVersion get the version of the network seele node joins
+func (rpc *SeeleRPC) GetNetVersion() (string, error) {
+	return rpc.GetNetVersionContext(context.Background())
+}
+
+//GetNetVersionContext get the version of the network seele node joins, the call is canceled with the context
+func (rpc *SeeleRPC) GetNetVersionContext(ctx context.Context) (string, error) {
+	const method = "network.GetNetVersion"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, nil, &raw); err != nil {
+		return "", err
+	}
+
+	var version string
+	if err := decodeResult(method, raw, &version); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+//GetCode get the code of the contract at the height, a negative height means the current block
+func (rpc *SeeleRPC) GetCode(address string, height int64) (string, error) {
+	return rpc.GetCodeContext(context.Background(), address, height)
+}
+
+//GetCodeContext get the code of the contract at the height, the call is canceled with the context
+func (rpc *SeeleRPC) GetCodeContext(ctx context.Context, address string, height int64) (string, error) {
+	const method = "seele.GetCode"
+	request := GetCodeRequest{
+		Address: address,
+		Height:  height,
+	}
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, request, &raw); err != nil {
+		return "", err
+	}
+
+	var code string
+	if err := decodeResult(method, raw, &code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+//Call run the payload on the contract at the height without creating a transaction,
+//a negative height means the current block
+func (rpc *SeeleRPC) Call(to, payload string, height int64) (*Receipt, error) {
+	return rpc.CallContext(context.Background(), to, payload, height)
+}
+
+//CallContext run the payload on the contract at the height without creating a transaction,
+//the call is canceled with the context
+func (rpc *SeeleRPC) CallContext(ctx context.Context, to, payload string, height int64) (*Receipt, error) {
+	const method = "seele.Call"
+	request := CallRequest{
+		To:      to,
+		Payload: payload,
+		Height:  height,
+	}
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, request, &raw); err != nil {
+		return nil, err
+	}
+
+	return decodeReceipt(method, raw)
+}
+
+//GetLogs get the logs of the contract in the block at the height, an empty topic matches all the logs
+func (rpc *SeeleRPC) GetLogs(height int64, contract, topic string) ([]Log, error) {
+	return rpc.GetLogsContext(context.Background(), height, contract, topic)
+}
+
+//GetLogsContext get the logs of the contract in the block at the height, the call is canceled with the context
+func (rpc *SeeleRPC) GetLogsContext(ctx context.Context, height int64, contract, topic string) ([]Log, error) {
+	const method = "seele.GetLogs"
+	request := GetLogsRequest{
+		Height:   height,
+		Contract: contract,
+		Topic:    topic,
+	}
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, request, &raw); err != nil {
+		return nil, err
+	}
+
+	//a block without logs may be null
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	var rpcLogs []rpcLog
+	if err := decodeResult(method, raw, &rpcLogs); err != nil {
+		return nil, err
+	}
+
+	logs := make([]Log, 0, len(rpcLogs))
+	for i := range rpcLogs {
+		if err := validationError(method, rpcLogs[i].validate(fmt.Sprintf("%d.", i))); err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, Log{
+			Address:     rpcLogs[i].Address,
+			Topics:      rpcLogs[i].Topics,
+			Data:        rpcLogs[i].Data,
+			BlockHeight: rpcLogs[i].BlockHeight.uint64(),
+			TxHash:      rpcLogs[i].TxHash,
+			TxIndex:     rpcLogs[i].TxIndex.uint64(),
+		})
+	}
+	return logs, nil
+}
+
+//GetDebtByHash get the debt of a cross-shard transaction in the debt pool or in a block
+func (rpc *SeeleRPC) GetDebtByHash(hash string) (*DebtInfo, error) {
+	return rpc.GetDebtByHashContext(context.Background(), hash)
+}
+
+//GetDebtByHashContext get the debt of a cross-shard transaction in the debt pool or in a block,
+//the call is canceled with the context
+func (rpc *SeeleRPC) GetDebtByHashContext(ctx context.Context, hash string) (*DebtInfo, error) {
+	const method = "txpool.GetDebtByHash"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, &hash, &raw); err != nil {
+		return nil, err
+	}
+
+	var rpcOutputDebt rpcDebtInfo
+	if err := decodeResult(method, raw, &rpcOutputDebt); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, rpcOutputDebt.validate()); err != nil {
+		return nil, err
+	}
+
+	debtInfo := &DebtInfo{
+		Debt:        rpcOutputDebt.Debt.debt(),
+		Status:      rpcOutputDebt.Status,
+		BlockHash:   rpcOutputDebt.BlockHash,
+		BlockHeight: rpcOutputDebt.BlockHeight.uint64(),
+	}
+	return debtInfo, nil
+}
+
+//GetPendingDebts get the debts in the debt pool
+func (rpc *SeeleRPC) GetPendingDebts() ([]Debt, error) {
+	return rpc.GetPendingDebtsContext(context.Background())
+}
+
+//GetPendingDebtsContext get the debts in the debt pool, the call is canceled with the context
+func (rpc *SeeleRPC) GetPendingDebtsContext(ctx context.Context) ([]Debt, error) {
+	const method = "txpool.GetPendingDebts"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, nil, &raw); err != nil {
+		return nil, err
+	}
+
+	//an empty debt pool may be null
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	var rpcDebts rpcDebts
+	if err := decodeResult(method, raw, &rpcDebts); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, rpcDebts.validate("", true)); err != nil {
+		return nil, err
+	}
+
+	debts := make([]Debt, 0, len(rpcDebts))
+	for i := range rpcDebts {
+		debts = append(debts, rpcDebts[i].debt())
+	}
+	return debts, nil
+}
@@ -0,0 +1,150 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+//BatchError is returned by a batch call when some of the requests fail,
+//Errors has the error of every request in order, nil if the request succeeded
+type BatchError struct {
+	Method string
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errors {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("rpc %s: %d of %d requests in the batch failed, first error: %v", e.Method, failed, len(e.Errors), first)
+}
+
+//batchError return a BatchError if any request in the batch failed
+func batchError(method string, errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return &BatchError{Method: method, Errors: errs}
+		}
+	}
+	return nil
+}
+
+//batchCall send a request of the method for every args in a single batch and decode every result,
+//decode is called only for the requests which succeeded
+func (rpc *SeeleRPC) batchCall(ctx context.Context, method string, args []interface{}, decode func(i int, raw json.RawMessage) error) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	raws := make([]json.RawMessage, len(args))
+	elems := make([]batchElem, len(args))
+	for i := range args {
+		elems[i] = batchElem{method: method, args: args[i], reply: &raws[i]}
+	}
+
+	if err := rpc.batchContext(ctx, elems); err != nil {
+		return err
+	}
+
+	errs := make([]error, len(elems))
+	for i := range elems {
+		errs[i] = elems[i].err
+		if errs[i] == nil {
+			errs[i] = decode(i, raws[i])
+		}
+	}
+	return batchError(method, errs)
+}
+
+//GetBlocksByHeightRange get the blocks from height begin to height end (both included) in a single batch
+func (rpc *SeeleRPC) GetBlocksByHeightRange(begin, end uint64, fullTx bool) ([]*BlockInfo, error) {
+	return rpc.GetBlocksByHeightRangeContext(context.Background(), begin, end, fullTx)
+}
+
+//GetBlocksByHeightRangeContext get the blocks from height begin to height end (both included) in a single batch,
+//the call is canceled with the context. The block of a failed request is nil and its error is in the BatchError
+func (rpc *SeeleRPC) GetBlocksByHeightRangeContext(ctx context.Context, begin, end uint64, fullTx bool) ([]*BlockInfo, error) {
+	const method = "seele.GetBlockByHeight"
+	if end < begin {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, end-begin+1)
+	for h := begin; h <= end; h++ {
+		args = append(args, GetBlockByHeightRequest{Height: int64(h), FullTx: fullTx})
+	}
+
+	blocks := make([]*BlockInfo, len(args))
+	err := rpc.batchCall(ctx, method, args, func(i int, raw json.RawMessage) (err error) {
+		blocks[i], err = decodeBlock(method, raw, fullTx)
+		return err
+	})
+	if _, ok := err.(*BatchError); err != nil && !ok {
+		return nil, err
+	}
+	return blocks, err
+}
+
+//GetReceiptsByTxHashes get the receipts of the mined transactions in a single batch
+func (rpc *SeeleRPC) GetReceiptsByTxHashes(txHashes []string) ([]*Receipt, error) {
+	return rpc.GetReceiptsByTxHashesContext(context.Background(), txHashes)
+}
+
+//GetReceiptsByTxHashesContext get the receipts of the mined transactions in a single batch, the call is canceled
+//with the context. The receipt of a failed request is nil and its error is in the BatchError
+func (rpc *SeeleRPC) GetReceiptsByTxHashesContext(ctx context.Context, txHashes []string) ([]*Receipt, error) {
+	const method = "txpool.GetReceiptByTxHash"
+	args := make([]interface{}, len(txHashes))
+	for i := range txHashes {
+		args[i] = &txHashes[i]
+	}
+
+	receipts := make([]*Receipt, len(args))
+	err := rpc.batchCall(ctx, method, args, func(i int, raw json.RawMessage) (err error) {
+		receipts[i], err = decodeReceipt(method, raw)
+		return err
+	})
+	if _, ok := err.(*BatchError); err != nil && !ok {
+		return nil, err
+	}
+	return receipts, err
+}
+
+//GetBalances get the balances of the accounts in a single batch
+func (rpc *SeeleRPC) GetBalances(addresses []string) ([]*big.Int, error) {
+	return rpc.GetBalancesContext(context.Background(), addresses)
+}
+
+//GetBalancesContext get the balances of the accounts in a single batch, the call is canceled with the context.
+//The balance of a failed request is nil and its error is in the BatchError
+func (rpc *SeeleRPC) GetBalancesContext(ctx context.Context, addresses []string) ([]*big.Int, error) {
+	const method = "seele.GetBalance"
+	args := make([]interface{}, len(addresses))
+	for i := range addresses {
+		args[i] = &addresses[i]
+	}
+
+	balances := make([]*big.Int, len(args))
+	err := rpc.batchCall(ctx, method, args, func(i int, raw json.RawMessage) (err error) {
+		balances[i], err = decodeBalance(method, raw)
+		return err
+	})
+	if _, ok := err.(*BatchError); err != nil && !ok {
+		return nil, err
+	}
+	return balances, err
+}
@@ -6,122 +6,83 @@
 package rpc
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"math/big"
 )
 
 // CurrentBlock returns the current block info.
-func (rpc *SeeleRPC) CurrentBlock() (currentBlock *CurrentBlock, err error) {
+func (rpc *SeeleRPC) CurrentBlock() (*CurrentBlock, error) {
+	return rpc.CurrentBlockContext(context.Background())
+}
+
+//CurrentBlockContext returns the current block info, the call is canceled with the context
+func (rpc *SeeleRPC) CurrentBlockContext(ctx context.Context) (*CurrentBlock, error) {
+	const method = "seele.GetBlockByHeight"
 	request := GetBlockByHeightRequest{
 		Height: -1,
 		FullTx: true,
 	}
-	rpcOutputBlock := make(map[string]interface{})
-	if err := rpc.call("seele.GetBlockByHeight", request, &rpcOutputBlock); err != nil {
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, request, &raw); err != nil {
 		return nil, err
 	}
 
-	timestamp := int64(rpcOutputBlock["timestamp"].(float64))
-	difficulty := int64(rpcOutputBlock["difficulty"].(float64))
-	height := uint64(rpcOutputBlock["height"].(float64))
-
-	currentBlock = &CurrentBlock{
-		HeadHash:  rpcOutputBlock["hash"].(string),
-		Height:    height,
-		Timestamp: big.NewInt(timestamp),
-		Difficult: big.NewInt(difficulty),
-		Creator:   rpcOutputBlock["creator"].(string),
-		TxCount:   len(rpcOutputBlock["transactions"].([]interface{})),
-	}
-	return currentBlock, err
+	return decodeCurrentBlock(method, raw, true)
 }
 
 //GetBlockByHeight get block and transaction data from seele node
-func (rpc *SeeleRPC) GetBlockByHeight(h uint64, fullTx bool) (block *BlockInfo, err error) {
+func (rpc *SeeleRPC) GetBlockByHeight(h uint64, fullTx bool) (*BlockInfo, error) {
+	return rpc.GetBlockByHeightContext(context.Background(), h, fullTx)
+}
+
+//GetBlockByHeightContext get block and transaction data from seele node, the call is canceled with the context
+func (rpc *SeeleRPC) GetBlockByHeightContext(ctx context.Context, h uint64, fullTx bool) (*BlockInfo, error) {
+	const method = "seele.GetBlockByHeight"
 	request := GetBlockByHeightRequest{
 		Height: int64(h),
 		FullTx: fullTx,
 	}
-	rpcOutputBlock := make(map[string]interface{})
-	if err := rpc.call("seele.GetBlockByHeight", request, &rpcOutputBlock); err != nil {
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, request, &raw); err != nil {
 		return nil, err
 	}
 
-	height := uint64(rpcOutputBlock["height"].(float64))
-	hash := rpcOutputBlock["hash"].(string)
-	parentHash := rpcOutputBlock["parentHash"].(string)
-	nonce := uint64(rpcOutputBlock["nonce"].(float64))
-	stateHash := rpcOutputBlock["stateHash"].(string)
-	txHash := rpcOutputBlock["txHash"].(string)
-	creator := rpcOutputBlock["creator"].(string)
-	timestamp := int64(rpcOutputBlock["timestamp"].(float64))
-	difficulty := int64(rpcOutputBlock["difficulty"].(float64))
-	totalDifficulty := int64(rpcOutputBlock["totalDifficulty"].(float64))
+	return decodeBlock(method, raw, fullTx)
+}
 
-	var Txs []Transaction
-	if fullTx {
-		var rpcTxs []interface{}
-		rpcTxs = rpcOutputBlock["transactions"].([]interface{})
-		for i := 0; i < len(rpcTxs); i++ {
-			var tx Transaction
-			rpcTx := rpcTxs[i].(map[string]interface{})
-			tx.Hash = rpcTx["hash"].(string)
-			tx.From = rpcTx["from"].(string)
-			tx.To = rpcTx["to"].(string)
-			amount := int64(rpcTx["amount"].(float64))
-			tx.Amount = big.NewInt(amount)
-			tx.AccountNonce = uint64(rpcTx["accountNonce"].(float64))
-			tx.Payload = rpcTx["payload"].(string)
-			tx.Timestamp = uint64(rpcTx["timestamp"].(float64))
-			tx.Fee = int64(rpcTx["fee"].(float64))
-			Txs = append(Txs, tx)
-		}
-	}
+//GetPeersInfo get peers info from connected seele node
+func (rpc *SeeleRPC) GetPeersInfo() ([]PeerInfo, error) {
+	return rpc.GetPeersInfoContext(context.Background())
+}
 
-	block = &BlockInfo{
-		Height:          height,
-		Hash:            hash,
-		ParentHash:      parentHash,
-		Nonce:           nonce,
-		StateHash:       stateHash,
-		TxHash:          txHash,
-		Creator:         creator,
-		Timestamp:       big.NewInt(timestamp),
-		Difficulty:      big.NewInt(difficulty),
-		TotalDifficulty: big.NewInt(totalDifficulty),
-		Txs:             Txs,
+//GetPeersInfoContext get peers info from connected seele node, the call is canceled with the context
+func (rpc *SeeleRPC) GetPeersInfoContext(ctx context.Context) ([]PeerInfo, error) {
+	const method = "network.GetPeersInfo"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, nil, &raw); err != nil {
+		return nil, err
 	}
-	return block, err
-}
 
-//GetPeersInfo get peers info from connected seele node
-func (rpc *SeeleRPC) GetPeersInfo() (result []PeerInfo, err error) {
-	var rcpPeerInfos []interface{}
-	if err := rpc.call("network.GetPeersInfo", nil, &rcpPeerInfos); err != nil {
+	var rpcPeerInfos []rpcPeerInfo
+	if err := decodeResult(method, raw, &rpcPeerInfos); err != nil {
 		return nil, err
 	}
 
 	var peerInfos []PeerInfo
-	for i := 0; i < len(rcpPeerInfos); i++ {
-		rpcPeerInfo := rcpPeerInfos[i].(map[string]interface{})
-
-		id := rpcPeerInfo["id"].(string)
-		rpcCaps := rpcPeerInfo["caps"].([]interface{})
-		var caps []string
-		for j := 0; j < len(rpcCaps); j++ {
-			capString := rpcCaps[j].(string)
-			caps = append(caps, capString)
+	for i := 0; i < len(rpcPeerInfos); i++ {
+		rpcPeerInfo := &rpcPeerInfos[i]
+		if err := validationError(method, rpcPeerInfo.validate(fmt.Sprintf("%d.", i))); err != nil {
+			return nil, err
 		}
-		rpcPeerNetWork := rpcPeerInfo["network"].(map[string]interface{})
-		localAddress := rpcPeerNetWork["localAddress"].(string)
-		remoteAddress := rpcPeerNetWork["remoteAddress"].(string)
-		shardNumber := int(rpcPeerInfo["shard"].(float64))
 
 		peerInfo := PeerInfo{
-			ID:            id,
-			Caps:          caps,
-			LocalAddress:  localAddress,
-			RemoteAddress: remoteAddress,
-			ShardNumber:   shardNumber,
+			ID:            rpcPeerInfo.ID,
+			Caps:          rpcPeerInfo.Caps,
+			LocalAddress:  rpcPeerInfo.Network.LocalAddress,
+			RemoteAddress: rpcPeerInfo.Network.RemoteAddress,
+			ShardNumber:   int(rpcPeerInfo.Shard.big().Int64()),
 		}
 
 		peerInfos = append(peerInfos, peerInfo)
@@ -131,60 +92,67 @@ func (rpc *SeeleRPC) GetPeersInfo() (result []PeerInfo, err error) {
 }
 
 //GetBalance get the balance of the account
-func (rpc *SeeleRPC) GetBalance(address string) (int64, error) {
-	var result interface{}
-	if err := rpc.call("seele.GetBalance", &address, &result); err != nil {
-		return 0, err
+func (rpc *SeeleRPC) GetBalance(address string) (*big.Int, error) {
+	return rpc.GetBalanceContext(context.Background(), address)
+}
+
+//GetBalanceContext get the balance of the account, the call is canceled with the context
+func (rpc *SeeleRPC) GetBalanceContext(ctx context.Context, address string) (*big.Int, error) {
+	const method = "seele.GetBalance"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, &address, &raw); err != nil {
+		return nil, err
 	}
 
-	balance := int64(result.(float64))
-	return balance, nil
+	return decodeBalance(method, raw)
 }
 
-//GetReceiptByTxHash
+//GetReceiptByTxHash get the receipt of a mined transaction
 func (rpc *SeeleRPC) GetReceiptByTxHash(txhash string) (*Receipt, error) {
+	return rpc.GetReceiptByTxHashContext(context.Background(), txhash)
+}
 
-	rpcOutputReceipt := make(map[string]interface{})
-	if err := rpc.call("txpool.GetReceiptByTxHash", &txhash, &rpcOutputReceipt); err != nil {
+//GetReceiptByTxHashContext get the receipt of a mined transaction, the call is canceled with the context
+func (rpc *SeeleRPC) GetReceiptByTxHashContext(ctx context.Context, txhash string) (*Receipt, error) {
+	const method = "txpool.GetReceiptByTxHash"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, &txhash, &raw); err != nil {
 		return nil, err
 	}
 
-	result := rpcOutputReceipt["result"].(string)
-	postState := rpcOutputReceipt["poststate"].(string)
-	txHash := rpcOutputReceipt["txhash"].(string)
-	contractAddress := rpcOutputReceipt["contract"].(string)
-
-	receipt := Receipt{
-		Result:          result,
-		PostState:       postState,
-		TxHash:          txHash,
-		ContractAddress: contractAddress,
-	}
-	return &receipt, nil
+	return decodeReceipt(method, raw)
 }
 
-//GetPendingTransactions
+//GetPendingTransactions get the transactions in the tx pool
 func (rpc *SeeleRPC) GetPendingTransactions() ([]Transaction, error) {
-	var rpcOutputTxs []interface{}
-	if err := rpc.call("txpool.GetPendingTransactions", nil, &rpcOutputTxs); err != nil {
+	return rpc.GetPendingTransactionsContext(context.Background())
+}
+
+//GetPendingTransactionsContext get the transactions in the tx pool, the call is canceled with the context
+func (rpc *SeeleRPC) GetPendingTransactionsContext(ctx context.Context) ([]Transaction, error) {
+	const method = "txpool.GetPendingTransactions"
+	var raw json.RawMessage
+	if err := rpc.callContext(ctx, method, nil, &raw); err != nil {
 		return nil, err
 	}
 
-	var Txs []Transaction
+	//an empty tx pool may be null
+	if string(raw) == "null" {
+		return nil, nil
+	}
 
+	var rpcOutputTxs rpcTransactions
+	if err := decodeResult(method, raw, &rpcOutputTxs); err != nil {
+		return nil, err
+	}
+
+	if err := validationError(method, rpcOutputTxs.validate("transactions.", true)); err != nil {
+		return nil, err
+	}
+
+	var Txs []Transaction
 	for i := 0; i < len(rpcOutputTxs); i++ {
-		var tx Transaction
-		rpcTx := rpcOutputTxs[i].(map[string]interface{})
-		tx.Hash = rpcTx["hash"].(string)
-		tx.From = rpcTx["from"].(string)
-		tx.To = rpcTx["to"].(string)
-		amount := int64(rpcTx["amount"].(float64))
-		tx.Amount = big.NewInt(amount)
-		tx.AccountNonce = uint64(rpcTx["accountNonce"].(float64))
-		tx.Payload = rpcTx["payload"].(string)
-		tx.Timestamp = uint64(rpcTx["timestamp"].(float64))
-		tx.Fee = int64(rpcTx["fee"].(float64))
-		Txs = append(Txs, tx)
+		Txs = append(Txs, rpcOutputTxs[i].transaction())
 	}
 
 	return Txs, nil
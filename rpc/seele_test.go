@@ -54,3 +54,186 @@ func TestGetPeersInfo(t *testing.T) {
 
 	rpcSeeleRPC.GetPeersInfo()
 }
+
+func TestGetBlockByHash(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	rpcSeeleRPC, err := GetSeeleRPC()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	full, err := rpcSeeleRPC.GetBlockByHeight(1023, true)
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if len(full.Txs) != 2 || full.Txs[1].Fee.Int64() != 21000 || len(full.Debts) != 1 {
+		t.Fatalf("bad block %+v", full)
+	}
+
+	debt := full.Debts[0]
+	if debt.FromShard != 2 || debt.Amount.Int64() != 500000 || debt.Account != "0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21" {
+		t.Fatalf("bad debt %+v", debt)
+	}
+
+	block, err := rpcSeeleRPC.GetBlockByHash(full.Hash, false)
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if block.Height != full.Height || block.ParentHash != full.ParentHash || len(block.Txs) != 0 || len(block.Debts) != 0 {
+		t.Fatalf("bad block %+v", block)
+	}
+
+	if _, err := rpcSeeleRPC.GetBlockByHash("0x0000000000000000000000000000000000000000000000000000000000000bad", false); err == nil {
+		t.Fatal("expected the error of a missing block")
+	}
+}
+
+func TestGetTransactionByHash(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	rpcSeeleRPC, err := GetSeeleRPC()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	tx, err := rpcSeeleRPC.GetTransactionByHash("0x3c2d1e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d")
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if tx.Status != "block" || tx.BlockHeight != 1023 || tx.TxIndex != 1 || tx.AccountNonce != 7 || tx.Amount.Int64() != 100000 {
+		t.Fatalf("bad transaction %+v", tx)
+	}
+
+	tx, err = rpcSeeleRPC.GetTransactionByHash("0x4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e")
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if tx.Status != "pool" || tx.BlockHash != "" || tx.To != "" || tx.Payload == "" {
+		t.Fatalf("bad pending transaction %+v", tx)
+	}
+
+	if _, err := rpcSeeleRPC.GetTransactionByHash("0x0000000000000000000000000000000000000000000000000000000000000bad"); err == nil {
+		t.Fatal("expected the error of a missing transaction")
+	}
+}
+
+func TestGetAccountNonce(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	rpcSeeleRPC, err := GetSeeleRPC()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	nonces := map[string]uint64{
+		"0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21": 8,
+		"0x2a87b6504cd00af95a83b9887112016a2a991cf1": 3,
+	}
+	for address, expected := range nonces {
+		nonce, err := rpcSeeleRPC.GetAccountNonce(address)
+		if err != nil || nonce != expected {
+			t.Fatalf("bad nonce of %s, %d %v", address, nonce, err)
+		}
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	rpcSeeleRPC, err := GetSeeleRPC()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	info, err := rpcSeeleRPC.GetInfo()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if info.CurrentBlockHeight != 1024 || info.Shard != 1 || info.Version != "1.0.0" || info.BlockAge.Int64() != 11 {
+		t.Fatalf("bad node info %+v", info)
+	}
+
+	version, err := rpcSeeleRPC.GetNetVersion()
+	if err != nil || version != "1" {
+		t.Fatalf("bad net version %s, %v", version, err)
+	}
+}
+
+func TestGetCodeAndCall(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	rpcSeeleRPC, err := GetSeeleRPC()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	const contract = "0x91dbd8fd6b2f6e6a1e4bd40eec8bc01ac6a98a52"
+	code, err := rpcSeeleRPC.GetCode(contract, -1)
+	if err != nil || code != "0x6080604052600080fd00a165627a7a72305820" {
+		t.Fatalf("bad code %s, %v", code, err)
+	}
+
+	code, err = rpcSeeleRPC.GetCode("0x2a87b6504cd00af95a83b9887112016a2a991cf1", -1)
+	if err != nil || code != "0x" {
+		t.Fatalf("bad code of an account %s, %v", code, err)
+	}
+
+	receipt, err := rpcSeeleRPC.Call(contract, "0x6d4ce63c", -1)
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if receipt.Failed || receipt.UsedGas != 21676 || receipt.Result != "0x0000000000000000000000000000000000000000000000000000000000000005" {
+		t.Fatalf("bad call result %+v", receipt)
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	rpcSeeleRPC, err := GetSeeleRPC()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	const contract = "0x91dbd8fd6b2f6e6a1e4bd40eec8bc01ac6a98a52"
+	logs, err := rpcSeeleRPC.GetLogs(1023, contract, "")
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if len(logs) != 1 || logs[0].Address != contract || len(logs[0].Topics) != 1 || logs[0].BlockHeight != 1023 || logs[0].TxIndex != 1 {
+		t.Fatalf("bad logs %+v", logs)
+	}
+
+	logs, err = rpcSeeleRPC.GetLogs(1024, contract, "")
+	if err != nil || len(logs) != 0 {
+		t.Fatalf("expected no logs, got %+v %v", logs, err)
+	}
+}
+
+func TestGetDebts(t *testing.T) {
+	defer ReleaseSeeleRPC()
+	rpcSeeleRPC, err := GetSeeleRPC()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	debt, err := rpcSeeleRPC.GetDebtByHash("0x7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f")
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if debt.Status != "block" || debt.BlockHeight != 1023 || debt.FromShard != 2 || debt.Price.Int64() != 10 {
+		t.Fatalf("bad debt %+v", debt)
+	}
+
+	debts, err := rpcSeeleRPC.GetPendingDebts()
+	if err != nil {
+		t.Fatalf("rpc error, %v", err)
+	}
+
+	if len(debts) != 1 || debts[0].FromShard != 3 || debts[0].Amount.Int64() != 25000000 {
+		t.Fatalf("bad pending debts %+v", debts)
+	}
+}
@@ -0,0 +1,285 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	netrpc "net/rpc"
+	"sync"
+)
+
+const (
+	//subscriptionBuffer the number of notifications kept for a subscription which is not read in time
+	subscriptionBuffer = 16
+
+	//maxEarlySubscriptions the number of unknown subscriptions whose notifications are kept
+	//until their subscribe calls return
+	maxEarlySubscriptions = 16
+
+	methodSubscribeNewHead = "seele.SubscribeNewHead"
+	methodUnsubscribe      = "seele.Unsubscribe"
+)
+
+//ErrNotificationsUnsupported is returned when subscribing over a transport which can not receive
+//the notifications of seele node, such as http
+var ErrNotificationsUnsupported = errors.New("the rpc transport does not support notifications")
+
+//notificationParams is the params of a notification pushed by seele node
+type notificationParams struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+//clientNotification is a request without id pushed by seele node
+type clientNotification struct {
+	Method string             `json:"method"`
+	Params notificationParams `json:"params"`
+}
+
+//subscriber is a transport which can receive the notifications of seele node
+type subscriber interface {
+	subscribe(ctx context.Context, method, unsubscribeMethod string, args interface{}) (*Subscription, error)
+}
+
+//Subscription receive the notifications of a subscription, the channel is closed when the
+//subscription is canceled or the connection is lost. Only the latest notifications are kept
+//if they are not read in time
+type Subscription struct {
+	ID string
+
+	c           chan json.RawMessage
+	unsubscribe func() error
+	once        sync.Once
+}
+
+//Notifications return the channel of the results of the notifications
+func (s *Subscription) Notifications() <-chan json.RawMessage {
+	return s.c
+}
+
+//Unsubscribe cancel the subscription on seele node
+func (s *Subscription) Unsubscribe() error {
+	var err error
+	s.once.Do(func() { err = s.unsubscribe() })
+	return err
+}
+
+//subscriptions route the notifications read by a client codec to their subscriptions
+type subscriptions struct {
+	lock   sync.Mutex
+	subs   map[string]*Subscription
+	early  map[string][]json.RawMessage
+	closed bool
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		subs:  make(map[string]*Subscription),
+		early: make(map[string][]json.RawMessage),
+	}
+}
+
+//dispatch deliver the message if it is a notification, it returns false if it is a response
+func (s *subscriptions) dispatch(raw json.RawMessage) bool {
+	raw = bytes.TrimLeft(raw, " \t\r\n")
+	if len(raw) == 0 || raw[0] != '{' || !bytes.Contains(raw, []byte(`"method"`)) {
+		return false
+	}
+
+	var n clientNotification
+	if err := json.Unmarshal(raw, &n); err != nil || n.Method == "" {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sub, ok := s.subs[n.Params.Subscription]
+	if !ok {
+		//the notification may arrive before the subscribe call returns
+		if s.closed {
+			return true
+		}
+		if len(s.early) >= maxEarlySubscriptions {
+			s.early = make(map[string][]json.RawMessage)
+		}
+		s.early[n.Params.Subscription] = appendLatest(s.early[n.Params.Subscription], n.Params.Result)
+		return true
+	}
+
+	deliver(sub.c, n.Params.Result)
+	return true
+}
+
+//register return the subscription of the id
+func (s *subscriptions) register(id string, unsubscribe func() error) (*Subscription, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil, netrpc.ErrShutdown
+	}
+
+	sub := &Subscription{
+		ID: id,
+		c:  make(chan json.RawMessage, subscriptionBuffer),
+	}
+	sub.unsubscribe = func() error {
+		s.remove(id)
+		return unsubscribe()
+	}
+
+	for _, result := range s.early[id] {
+		deliver(sub.c, result)
+	}
+	delete(s.early, id)
+
+	s.subs[id] = sub
+	return sub, nil
+}
+
+//remove close the subscription of the id
+func (s *subscriptions) remove(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if sub, ok := s.subs[id]; ok {
+		close(sub.c)
+		delete(s.subs, id)
+	}
+}
+
+//closeAll close all the subscriptions after the connection is lost
+func (s *subscriptions) closeAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	for id, sub := range s.subs {
+		close(sub.c)
+		delete(s.subs, id)
+	}
+	s.early = nil
+}
+
+//deliver send the result without blocking, the oldest one is dropped if the channel is full
+func deliver(c chan json.RawMessage, result json.RawMessage) {
+	for {
+		select {
+		case c <- result:
+			return
+		default:
+		}
+
+		select {
+		case <-c:
+		default:
+		}
+	}
+}
+
+func appendLatest(results []json.RawMessage, result json.RawMessage) []json.RawMessage {
+	if len(results) >= subscriptionBuffer {
+		results = results[1:]
+	}
+	return append(results, result)
+}
+
+//HeadSubscription receive the head blocks of seele node
+type HeadSubscription struct {
+	sub   *Subscription
+	heads chan *CurrentBlock
+	quit  chan struct{}
+	once  sync.Once
+}
+
+func newHeadSubscription(sub *Subscription) *HeadSubscription {
+	s := &HeadSubscription{
+		sub:   sub,
+		heads: make(chan *CurrentBlock, subscriptionBuffer),
+		quit:  make(chan struct{}),
+	}
+
+	go s.loop()
+	return s
+}
+
+//loop decode the notifications, an invalid block is skipped
+func (s *HeadSubscription) loop() {
+	defer close(s.heads)
+
+	for raw := range s.sub.Notifications() {
+		head, err := decodeCurrentBlock(methodSubscribeNewHead, raw, false)
+		if err != nil {
+			continue
+		}
+
+		select {
+		case s.heads <- head:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+//Heads return the channel of the new head blocks, it is closed when the subscription ends
+func (s *HeadSubscription) Heads() <-chan *CurrentBlock {
+	return s.heads
+}
+
+//Unsubscribe cancel the subscription
+func (s *HeadSubscription) Unsubscribe() error {
+	s.once.Do(func() { close(s.quit) })
+	return s.sub.Unsubscribe()
+}
+
+//SubscribeNewHead subscribe to the head blocks of seele node, the transport must be a stream
+//connection (tcp or websocket), otherwise ErrNotificationsUnsupported is returned
+func (rpc *SeeleRPC) SubscribeNewHead(ctx context.Context) (*HeadSubscription, error) {
+	conn, err := rpc.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := conn.(subscriber)
+	if !ok {
+		return nil, ErrNotificationsUnsupported
+	}
+
+	if _, ok := ctx.Deadline(); !ok && rpc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rpc.timeout)
+		defer cancel()
+	}
+
+	sub, err := s.subscribe(ctx, methodSubscribeNewHead, methodUnsubscribe, nil)
+	if err != nil {
+		if ctx.Err() != nil || isConnError(err) {
+			rpc.dropConn(conn)
+		}
+		return nil, err
+	}
+	return newHeadSubscription(sub), nil
+}
+
+//SubscribeNewHead subscribe to the head blocks of the fastest endpoint which supports notifications
+func (p *Pool) SubscribeNewHead(ctx context.Context) (*HeadSubscription, error) {
+	err := ErrNoEndpoint
+	for _, e := range p.candidates(0) {
+		var sub *HeadSubscription
+		if sub, err = e.client.SubscribeNewHead(ctx); err == nil {
+			return sub, nil
+		}
+	}
+	return nil, err
+}
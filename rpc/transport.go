@@ -0,0 +1,344 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	netrpc "net/rpc"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	schemeTCP   = "tcp"
+	schemeHTTP  = "http"
+	schemeHTTPS = "https"
+	schemeWS    = "ws"
+	schemeWSS   = "wss"
+)
+
+//transport send json-rpc requests to seele node over a connection
+type transport interface {
+	call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error
+
+	//batch send the requests in a single json-rpc batch, the error of every request is
+	//set in the elements, while the returned error means the whole batch failed
+	batch(ctx context.Context, elems []batchElem) error
+
+	close() error
+}
+
+//batchElem is a request in a batch
+type batchElem struct {
+	method string
+	args   interface{}
+	reply  interface{}
+	err    error
+}
+
+//splitURL return the scheme and the address of the url, an url without scheme is a tcp address,
+//the address of a replay url is the path of the recording
+func splitURL(url string) (scheme, address string) {
+	i := strings.Index(url, "://")
+	if i < 0 {
+		return schemeTCP, url
+	}
+
+	scheme = strings.ToLower(url[:i])
+	if scheme == schemeTCP || scheme == schemeReplay {
+		return scheme, url[i+3:]
+	}
+	return scheme, url
+}
+
+//dialTransport connect to seele node with the transport of the scheme
+func dialTransport(scheme, address string) (transport, error) {
+	switch scheme {
+	case schemeTCP:
+		client, err := Dial(schemeTCP, address)
+		if err != nil {
+			return nil, err
+		}
+		return &clientTransport{client}, nil
+	case schemeHTTP, schemeHTTPS:
+		return &httpTransport{url: address, client: &http.Client{}}, nil
+	case schemeWS, schemeWSS:
+		conn, _, err := websocket.DefaultDialer.Dial(address, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &clientTransport{NewClient(&wsConn{conn: conn})}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported rpc scheme %s", scheme)
+}
+
+//clientTransport send requests with the json-rpc client over a stream connection
+type clientTransport struct {
+	client *Client
+}
+
+func (t *clientTransport) call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	call := t.client.Go(serviceMethod, args, reply, make(chan *netrpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *clientTransport) batch(ctx context.Context, elems []batchElem) error {
+	codec := t.client.codec.(*clientCodec)
+	done := make(chan *netrpc.Call, len(elems))
+	calls := make([]*netrpc.Call, len(elems))
+
+	codec.startBatch()
+	for i := range elems {
+		calls[i] = t.client.Go(elems[i].method, elems[i].args, elems[i].reply, done)
+	}
+	if err := codec.flushBatch(); err != nil {
+		return err
+	}
+
+	for range elems {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for i := range elems {
+		elems[i].err = calls[i].Error
+	}
+	return nil
+}
+
+//subscribe call the subscribe method and return the subscription to the notifications pushed on the connection
+func (t *clientTransport) subscribe(ctx context.Context, method, unsubscribeMethod string, args interface{}) (*Subscription, error) {
+	codec, ok := t.client.codec.(*clientCodec)
+	if !ok {
+		return nil, ErrNotificationsUnsupported
+	}
+
+	var id string
+	if err := t.call(ctx, method, args, &id); err != nil {
+		return nil, err
+	}
+
+	return codec.subs.register(id, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultCallTimeout)
+		defer cancel()
+
+		var ok bool
+		return t.call(ctx, unsubscribeMethod, &id, &ok)
+	})
+}
+
+func (t *clientTransport) close() error {
+	return t.client.Close()
+}
+
+//httpStatusError is returned when the http gateway does not answer with 200
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("rpc http status %d %s", e.code, http.StatusText(e.code))
+}
+
+//httpTransport post every request to the json-rpc http endpoint
+type httpTransport struct {
+	url    string
+	client *http.Client
+	seq    uint64
+}
+
+func (t *httpTransport) call(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	req, err := t.newRequest(serviceMethod, args)
+	if err != nil {
+		return err
+	}
+
+	raw, err := t.post(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var resp clientResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return NewError(errInternal.Code, err.Error())
+	}
+
+	return decodeHTTPResponse(&resp, reply)
+}
+
+func (t *httpTransport) batch(ctx context.Context, elems []batchElem) error {
+	reqs := make([]*clientRequest, 0, len(elems))
+	index := make(map[uint64]int, len(elems))
+	for i := range elems {
+		req, err := t.newRequest(elems[i].method, elems[i].args)
+		if err != nil {
+			elems[i].err = err
+			continue
+		}
+		reqs = append(reqs, req)
+		index[*req.ID] = i
+	}
+
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	raw, err := t.post(ctx, reqs)
+	if err != nil {
+		return err
+	}
+
+	var resps []clientResponse
+	if err := json.Unmarshal(raw, &resps); err != nil {
+		return NewError(errInternal.Code, err.Error())
+	}
+
+	for i := range resps {
+		if resps[i].ID == nil {
+			continue
+		}
+		if j, ok := index[*resps[i].ID]; ok {
+			elems[j].err = decodeHTTPResponse(&resps[i], elems[j].reply)
+			delete(index, *resps[i].ID)
+		}
+	}
+
+	for _, j := range index {
+		elems[j].err = NewError(errInternal.Code, "no response in the batch")
+	}
+	return nil
+}
+
+//newRequest return a json-rpc request with a new id
+func (t *httpTransport) newRequest(serviceMethod string, args interface{}) (*clientRequest, error) {
+	args, err := checkParam(args)
+	if err != nil {
+		return nil, err
+	}
+
+	id := atomic.AddUint64(&t.seq, 1)
+	req := &clientRequest{
+		Version: jsonrpcVersion,
+		Method:  serviceMethod,
+		ID:      &id,
+	}
+	req.Params[0] = args
+	return req, nil
+}
+
+//post send the request or the batch and return the response body
+func (t *httpTransport) post(ctx context.Context, v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, NewError(errInternal.Code, err.Error())
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := t.client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, httpResp.Body)
+		return nil, &httpStatusError{httpResp.StatusCode}
+	}
+
+	return ioutil.ReadAll(httpResp.Body)
+}
+
+//decodeHTTPResponse decode the result into the reply, the errors are the same as the ones of the stream client
+func decodeHTTPResponse(resp *clientResponse, reply interface{}) error {
+	if resp.Error != nil {
+		return netrpc.ServerError(resp.Error.Error())
+	}
+
+	if reply == nil {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(*resp.Result))
+	dec.UseNumber()
+	if err := dec.Decode(reply); err != nil {
+		return errors.New("reading body " + NewError(errInternal.Code, err.Error()).Error())
+	}
+	return nil
+}
+
+func (t *httpTransport) close() error {
+	return nil
+}
+
+//wsConn turn a websocket connection into a stream, every request is written as a text message
+type wsConn struct {
+	conn *websocket.Conn
+
+	writeLock sync.Mutex
+	reader    io.Reader
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			_, reader, err := c.conn.NextReader()
+			if err != nil {
+				return 0, io.EOF
+			}
+			c.reader = reader
+		}
+
+		n, err := c.reader.Read(p)
+		if err == io.EOF {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if err := c.conn.WriteMessage(websocket.TextMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
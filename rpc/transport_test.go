@@ -0,0 +1,223 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	netrpc "net/rpc"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientTransportBatch(t *testing.T) {
+	cli, srv := net.Pipe()
+	go ServeConn(srv)
+
+	transport := &clientTransport{NewClient(cli)}
+	defer transport.close()
+
+	replies := make([]Reply, 3)
+	elems := []batchElem{
+		{method: "Arith.Add", args: &Args{1, 2}, reply: &replies[0]},
+		{method: "Arith.Div", args: &Args{1, 0}, reply: &replies[1]},
+		{method: "Arith.Mul", args: &Args{3, 4}, reply: &replies[2]},
+	}
+
+	if err := transport.batch(context.Background(), elems); err != nil {
+		t.Fatal(err)
+	}
+
+	if elems[0].err != nil || replies[0].C != 3 {
+		t.Fatalf("bad add result %d, %v", replies[0].C, elems[0].err)
+	}
+
+	if elems[1].err == nil {
+		t.Fatal("expected the error of divide by zero")
+	}
+
+	if elems[2].err != nil || replies[2].C != 12 {
+		t.Fatalf("bad mul result %d, %v", replies[2].C, elems[2].err)
+	}
+
+	var reply Reply
+	if err := transport.call(context.Background(), "Arith.Add", &Args{5, 6}, &reply); err != nil || reply.C != 11 {
+		t.Fatalf("bad call after the batch %d, %v", reply.C, err)
+	}
+}
+
+//waitService has a method which does not answer until the server stops
+type waitService struct {
+	quit chan struct{}
+}
+
+func (s *waitService) Wait(args *Args, reply *Reply) error {
+	<-s.quit
+	return nil
+}
+
+//httpConn is the stream of a request posted to the http endpoint
+type httpConn struct {
+	io.Reader
+	io.Writer
+}
+
+func (c *httpConn) Close() error {
+	return nil
+}
+
+//startTransportServer serve Arith and Wait with the transport of the scheme and return the url,
+//stop closes the server and all its connections
+func startTransportServer(t *testing.T, scheme string) (url string, stop func()) {
+	quit := make(chan struct{})
+	srv := netrpc.NewServer()
+	srv.Register(new(Arith))
+	srv.RegisterName("Wait", &waitService{quit})
+
+	var lock sync.Mutex
+	var conns []io.Closer
+	serveConn := func(conn io.ReadWriteCloser) {
+		lock.Lock()
+		conns = append(conns, conn)
+		lock.Unlock()
+		srv.ServeCodec(NewJSONCodec(conn, srv))
+	}
+	closeConns := func() {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+
+	switch scheme {
+	case schemeTCP:
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				go serveConn(conn)
+			}
+		}()
+		return l.Addr().String(), func() {
+			close(quit)
+			l.Close()
+			closeConns()
+		}
+	case schemeHTTP:
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			srv.ServeRequest(NewJSONCodec(&httpConn{Reader: r.Body, Writer: w}, srv))
+		}))
+		return server.URL, func() {
+			close(quit)
+			server.Close()
+		}
+	case schemeWS:
+		var upgrader websocket.Upgrader
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			serveConn(&wsConn{conn: conn})
+		}))
+		//the upgraded connections are not closed by the http server
+		return "ws://" + strings.TrimPrefix(server.URL, "http://"), func() {
+			close(quit)
+			closeConns()
+			server.Close()
+		}
+	}
+
+	t.Fatalf("unsupported scheme %s", scheme)
+	return "", nil
+}
+
+func TestTransports(t *testing.T) {
+	for _, scheme := range []string{schemeTCP, schemeHTTP, schemeWS} {
+		t.Run(scheme, func(t *testing.T) {
+			testTransport(t, scheme)
+		})
+	}
+}
+
+//testTransport send calls and batches with the transport of the scheme, all the transports
+//return the same errors
+func testTransport(t *testing.T, scheme string) {
+	url, stop := startTransportServer(t, scheme)
+	stopped := false
+	defer func() {
+		if !stopped {
+			stop()
+		}
+	}()
+
+	transport, err := dialTransport(splitURL(url))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transport.close()
+
+	ctx := context.Background()
+	var reply Reply
+	if err := transport.call(ctx, "Arith.Add", &Args{1, 2}, &reply); err != nil || reply.C != 3 {
+		t.Fatalf("bad add result %d, %v", reply.C, err)
+	}
+
+	//the errors of the node keep the connection
+	if err := transport.call(ctx, "Arith.Div", &Args{1, 0}, &reply); err == nil || isConnError(err) || !strings.Contains(err.Error(), "divide by zero") {
+		t.Fatalf("expected the error of divide by zero, got %v", err)
+	}
+
+	if err := transport.call(ctx, "Arith.Pow", &Args{2, 3}, &reply); err == nil || isConnError(err) {
+		t.Fatalf("expected the error of the unknown method, got %v", err)
+	}
+
+	replies := make([]Reply, 3)
+	elems := []batchElem{
+		{method: "Arith.Add", args: &Args{1, 2}, reply: &replies[0]},
+		{method: "Arith.Div", args: &Args{1, 0}, reply: &replies[1]},
+		{method: "Arith.Mul", args: &Args{3, 4}, reply: &replies[2]},
+	}
+	if err := transport.batch(ctx, elems); err != nil {
+		t.Fatal(err)
+	}
+
+	if elems[0].err != nil || replies[0].C != 3 || elems[1].err == nil || isConnError(elems[1].err) || elems[2].err != nil || replies[2].C != 12 {
+		t.Fatalf("bad batch results %+v, %+v", replies, elems)
+	}
+
+	if err := transport.call(ctx, "Arith.Mul", &Args{5, 6}, &reply); err != nil || reply.C != 30 {
+		t.Fatalf("bad call after the errors %d, %v", reply.C, err)
+	}
+
+	//the call is given up at the deadline of the context
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := transport.call(timeout, "Wait.Wait", &Args{}, &reply); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline exceeded, got %v", err)
+	}
+
+	stop()
+	stopped = true
+	if err := transport.call(ctx, "Arith.Add", &Args{1, 2}, &reply); !isConnError(err) {
+		t.Fatalf("expected a connection error after the server stopped, got %v", err)
+	}
+}
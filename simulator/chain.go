@@ -0,0 +1,345 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package simulator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"math/rand"
+)
+
+const (
+	//NullAddress is the sender of the reward tx of every block
+	NullAddress = "0x0000000000000000000000000000000000000000"
+
+	defaultDifficulty = 6000000
+	defaultUsedGas    = 21000
+)
+
+//DefaultReward the amount the creator of a block is rewarded
+var DefaultReward = big.NewInt(150000000)
+
+//Tx is a transaction of the simulated chain
+type Tx struct {
+	Hash      string
+	From      string
+	To        string
+	Amount    *big.Int
+	Fee       *big.Int
+	Nonce     uint64
+	Payload   string
+	Timestamp uint64
+}
+
+//Receipt is the execution result of a transaction in the simulated chain
+type Receipt struct {
+	TxHash  string
+	Failed  bool
+	UsedGas uint64
+	Fee     *big.Int
+}
+
+//Block is a block of the simulated chain, the first tx is the reward of the creator
+type Block struct {
+	Hash            string
+	ParentHash      string
+	Height          uint64
+	Timestamp       uint64
+	Difficulty      *big.Int
+	TotalDifficulty *big.Int
+	Creator         string
+	Nonce           uint64
+	Txs             []*Tx
+}
+
+//txLocation is where a tx is in the canonical chain
+type txLocation struct {
+	block   *Block
+	index   int
+	receipt *Receipt
+}
+
+//Chain is a simulated chain of a shard, it is not safe for concurrent use
+type Chain struct {
+	shard  int
+	reward *big.Int
+	forks  uint64
+
+	blocks   []*Block
+	byHash   map[string]*Block
+	txs      map[string]*txLocation
+	balances map[string]*big.Int
+	nonces   map[string]uint64
+}
+
+//NewChain return a chain with only the genesis block
+func NewChain(shard int, genesisTime uint64) *Chain {
+	c := &Chain{
+		shard:  shard,
+		reward: DefaultReward,
+	}
+	c.reset()
+
+	genesis := &Block{
+		ParentHash:      hashOf("genesis", shard),
+		Timestamp:       genesisTime,
+		Difficulty:      big.NewInt(defaultDifficulty),
+		TotalDifficulty: big.NewInt(defaultDifficulty),
+		Creator:         NullAddress,
+	}
+	genesis.Hash = blockHash(genesis, 0)
+	c.append(genesis)
+	return c
+}
+
+//reset clear the state and the indexes
+func (c *Chain) reset() {
+	c.blocks = nil
+	c.byHash = make(map[string]*Block)
+	c.txs = make(map[string]*txLocation)
+	c.balances = make(map[string]*big.Int)
+	c.nonces = make(map[string]uint64)
+}
+
+//Height return the height of the head block
+func (c *Chain) Height() uint64 {
+	return uint64(len(c.blocks) - 1)
+}
+
+//Head return the head block
+func (c *Chain) Head() *Block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+//BlockByHeight return the block at the height in the canonical chain
+func (c *Chain) BlockByHeight(height uint64) (*Block, bool) {
+	if height >= uint64(len(c.blocks)) {
+		return nil, false
+	}
+	return c.blocks[height], true
+}
+
+//BlockByHash return the block with the hash in the canonical chain
+func (c *Chain) BlockByHash(hash string) (*Block, bool) {
+	b, ok := c.byHash[hash]
+	return b, ok
+}
+
+//Tx return the tx, the block which contains it and its index in the block
+func (c *Chain) Tx(hash string) (*Tx, *Block, int, bool) {
+	loc, ok := c.txs[hash]
+	if !ok {
+		return nil, nil, 0, false
+	}
+	return loc.block.Txs[loc.index], loc.block, loc.index, true
+}
+
+//Receipt return the receipt of a mined tx
+func (c *Chain) Receipt(hash string) (*Receipt, bool) {
+	loc, ok := c.txs[hash]
+	if !ok {
+		return nil, false
+	}
+	return loc.receipt, true
+}
+
+//Balance return the balance of the account at the head block
+func (c *Chain) Balance(address string) *big.Int {
+	if b, ok := c.balances[address]; ok {
+		return new(big.Int).Set(b)
+	}
+	return new(big.Int)
+}
+
+//Nonce return the number of txs the account sent in the canonical chain
+func (c *Chain) Nonce(address string) uint64 {
+	return c.nonces[address]
+}
+
+//Mine append a block created by the creator with the txs, a tx which the sender can not
+//afford is mined as failed. It returns the new block
+func (c *Chain) Mine(creator string, timestamp uint64, txs []*Tx) *Block {
+	parent := c.Head()
+	if timestamp <= parent.Timestamp {
+		timestamp = parent.Timestamp + 1
+	}
+
+	reward := &Tx{
+		From:      NullAddress,
+		To:        creator,
+		Amount:    new(big.Int).Set(c.reward),
+		Fee:       new(big.Int),
+		Timestamp: timestamp,
+	}
+	reward.Hash = txHash(reward, parent.Height+1)
+
+	difficulty := big.NewInt(defaultDifficulty + int64(parent.Height%97))
+	b := &Block{
+		ParentHash:      parent.Hash,
+		Height:          parent.Height + 1,
+		Timestamp:       timestamp,
+		Difficulty:      difficulty,
+		TotalDifficulty: new(big.Int).Add(parent.TotalDifficulty, difficulty),
+		Creator:         creator,
+		Nonce:           c.forks,
+		Txs:             append([]*Tx{reward}, txs...),
+	}
+	b.Hash = blockHash(b, c.forks)
+	c.append(b)
+	return b
+}
+
+//append add the block to the canonical chain and apply its txs
+func (c *Chain) append(b *Block) {
+	c.blocks = append(c.blocks, b)
+	c.byHash[b.Hash] = b
+	for i, tx := range b.Txs {
+		c.txs[tx.Hash] = &txLocation{block: b, index: i, receipt: c.apply(b, tx)}
+	}
+}
+
+//apply move the amount and the fee of the tx, the amount is not moved if the sender can not afford it
+func (c *Chain) apply(b *Block, tx *Tx) *Receipt {
+	receipt := &Receipt{TxHash: tx.Hash, Fee: new(big.Int)}
+	if tx.From == NullAddress {
+		c.add(tx.To, tx.Amount)
+		return receipt
+	}
+
+	receipt.UsedGas = defaultUsedGas
+	c.nonces[tx.From]++
+
+	fee := tx.Fee
+	if c.Balance(tx.From).Cmp(fee) < 0 {
+		fee = c.Balance(tx.From)
+	}
+	c.add(tx.From, new(big.Int).Neg(fee))
+	c.add(b.Creator, fee)
+	receipt.Fee.Set(fee)
+
+	if c.Balance(tx.From).Cmp(tx.Amount) < 0 {
+		receipt.Failed = true
+		return receipt
+	}
+
+	c.add(tx.From, new(big.Int).Neg(tx.Amount))
+	c.add(tx.To, tx.Amount)
+	return receipt
+}
+
+func (c *Chain) add(address string, amount *big.Int) {
+	if address == "" || address == NullAddress {
+		return
+	}
+
+	if _, ok := c.balances[address]; !ok {
+		c.balances[address] = new(big.Int)
+	}
+	c.balances[address].Add(c.balances[address], amount)
+}
+
+//Fork drop the blocks above the height and mine as many blocks plus one on a new branch,
+//so that the new branch is longer. The txs in the dropped blocks other than the rewards are returned
+func (c *Chain) Fork(height uint64, creator string) []*Tx {
+	if height >= c.Height() {
+		return nil
+	}
+
+	dropped := c.blocks[height+1:]
+	kept := c.blocks[:height+1]
+
+	c.forks++
+	c.reset()
+	for _, b := range kept {
+		c.append(b)
+	}
+
+	var orphaned []*Tx
+	for _, b := range dropped {
+		orphaned = append(orphaned, b.Txs[1:]...)
+	}
+
+	for _, b := range dropped {
+		c.Mine(creator, b.Timestamp, nil)
+	}
+	c.Mine(creator, c.Head().Timestamp+1, nil)
+	return orphaned
+}
+
+//NewTx return a tx of the sender, its nonce follows the ones of the mined and the pending txs
+func (c *Chain) NewTx(from, to string, amount, fee *big.Int, pendingNonce uint64, timestamp uint64) *Tx {
+	tx := &Tx{
+		From:      from,
+		To:        to,
+		Amount:    new(big.Int).Set(amount),
+		Fee:       new(big.Int).Set(fee),
+		Nonce:     c.Nonce(from) + pendingNonce,
+		Timestamp: timestamp,
+	}
+	tx.Hash = txHash(tx, 0)
+	return tx
+}
+
+//Generate mine the blocks with random transfers between the accounts, the accounts take
+//turns to create the blocks so that they have money to transfer
+func (c *Chain) Generate(r *rand.Rand, blocks, txsPerBlock int, accounts []string, blockInterval uint64) {
+	for i := 0; i < blocks; i++ {
+		timestamp := c.Head().Timestamp + blockInterval
+		var txs []*Tx
+		sent := make(map[string]uint64)
+		for j := 0; j < txsPerBlock && len(accounts) > 1; j++ {
+			from := accounts[r.Intn(len(accounts))]
+			to := accounts[r.Intn(len(accounts))]
+			balance := c.Balance(from)
+			fee := big.NewInt(int64(1 + r.Intn(100)))
+			if from == to || balance.Cmp(big.NewInt(1000)) < 0 {
+				continue
+			}
+
+			max := new(big.Int).Div(balance, big.NewInt(int64(2*txsPerBlock)))
+			if max.Sign() <= 0 {
+				continue
+			}
+
+			amount := new(big.Int).Rand(r, max)
+			tx := c.NewTx(from, to, amount, fee, sent[from], timestamp)
+			sent[from]++
+			txs = append(txs, tx)
+		}
+		c.Mine(accounts[i%len(accounts)], timestamp, txs)
+	}
+}
+
+//Accounts return n deterministic addresses of the shard
+func Accounts(shard, n int) []string {
+	accounts := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("account-%d-%d", shard, i)))
+		address := hash[:20]
+		address[19] = address[19]&0xf0 | 0x01
+		accounts = append(accounts, "0x"+hex.EncodeToString(address))
+	}
+	return accounts
+}
+
+func hashOf(v ...interface{}) string {
+	hash := sha256.Sum256([]byte(fmt.Sprint(v...)))
+	return "0x" + hex.EncodeToString(hash[:])
+}
+
+func txHash(tx *Tx, height uint64) string {
+	return hashOf("tx", tx.From, tx.To, tx.Amount, tx.Fee, tx.Nonce, tx.Payload, tx.Timestamp, height)
+}
+
+func blockHash(b *Block, fork uint64) string {
+	hashes := make([]string, 0, len(b.Txs))
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	return hashOf("block", b.ParentHash, b.Height, b.Timestamp, b.Creator, fork, hashes)
+}
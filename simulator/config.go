@@ -0,0 +1,119 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package simulator
+
+import (
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/seeleteam/scan-api/log"
+)
+
+//FaultConfig config of a fault injected into a method, Delay is in seconds
+type FaultConfig struct {
+	Delay     time.Duration
+	Error     string
+	Malformed bool
+	Times     int
+}
+
+//Config simulator config
+type Config struct {
+	TCPAddr  string
+	HTTPAddr string
+	WriteLog bool
+	LogLevel string
+	LogFile  string
+
+	//ScriptFile the chain is loaded from the script if it is set, otherwise it is generated
+	ScriptFile string
+
+	Shard       int
+	Blocks      int
+	TxsPerBlock int
+	Accounts    int
+	Seed        int64
+
+	//BlockInterval seconds between two mined blocks, 0 means the chain does not grow
+	BlockInterval time.Duration
+	//PendingTxs the number of random txs added to the tx pool before a block is mined
+	PendingTxs int
+	//ForkInterval the chain forks ForkDepth blocks back after every ForkInterval mined blocks, 0 means never
+	ForkInterval int
+	ForkDepth    int
+
+	//Faults the faults injected into the methods, such as seele.GetBlockByHeight
+	Faults map[string]FaultConfig
+}
+
+//NewNodeFromConfig return a node serving the chain of the script or a generated chain, with the faults injected
+func NewNodeFromConfig(cfg *Config) (*Node, error) {
+	var n *Node
+	if cfg.ScriptFile != "" {
+		script, err := LoadScript(cfg.ScriptFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if n, err = script.Node(); err != nil {
+			return nil, err
+		}
+	} else {
+		chain := NewChain(cfg.Shard, uint64(time.Now().Unix())-uint64(cfg.Blocks)*10)
+		chain.Generate(rand.New(rand.NewSource(cfg.Seed)), cfg.Blocks, cfg.TxsPerBlock, Accounts(cfg.Shard, cfg.Accounts), 10)
+		n = NewNode(chain)
+	}
+
+	for method, fault := range cfg.Faults {
+		n.InjectFault(method, Fault{
+			Delay:     fault.Delay * time.Second,
+			Error:     fault.Error,
+			Malformed: fault.Malformed,
+			Times:     fault.Times,
+		})
+	}
+	return n, nil
+}
+
+//Produce mine a block with random pending txs at the interval of the config and fork the chain
+//periodically, until quit is closed
+func (n *Node) Produce(cfg *Config, quit chan struct{}) {
+	if cfg.BlockInterval <= 0 {
+		return
+	}
+
+	r := rand.New(rand.NewSource(cfg.Seed + 1))
+	accounts := Accounts(cfg.Shard, cfg.Accounts)
+	if len(accounts) == 0 {
+		accounts = Accounts(cfg.Shard, 1)
+	}
+
+	ticker := time.NewTicker(cfg.BlockInterval * time.Second)
+	defer ticker.Stop()
+	for mined := 1; ; mined++ {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+
+		for i := 0; i < cfg.PendingTxs && len(accounts) > 1; i++ {
+			from := accounts[r.Intn(len(accounts))]
+			to := accounts[r.Intn(len(accounts))]
+			n.AddPendingTx(from, to, big.NewInt(int64(1+r.Intn(1000))), big.NewInt(int64(1+r.Intn(100))), uint64(time.Now().Unix()))
+		}
+
+		creator := accounts[r.Intn(len(accounts))]
+		b := n.Mine(creator, uint64(time.Now().Unix()))
+		log.Info("[Simulator]mined block %d %s with %d txs", b.Height, b.Hash, len(b.Txs))
+
+		if cfg.ForkInterval > 0 && mined%cfg.ForkInterval == 0 && cfg.ForkDepth > 0 && b.Height > uint64(cfg.ForkDepth) {
+			n.Fork(b.Height-uint64(cfg.ForkDepth), creator)
+			log.Info("[Simulator]forked at height %d, new height %d", b.Height-uint64(cfg.ForkDepth), n.Height())
+		}
+	}
+}
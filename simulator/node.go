@@ -0,0 +1,383 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	netrpc "net/rpc"
+	"sync"
+	"time"
+
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+//malformedReply is sent instead of the result when a malformed fault is injected,
+//it is valid json but none of the fields has the expected type
+var malformedReply = json.RawMessage(`{"hash":0,"height":"malformed","transactions":{},"balance":[]}`)
+
+//Fault is an error injected into the calls of a method
+type Fault struct {
+	//Delay the call is answered after the delay, set it longer than the timeout of the client to simulate a timeout
+	Delay time.Duration
+	//Error the call is answered with the error message
+	Error string
+	//Malformed the call is answered with a result of the wrong shape
+	Malformed bool
+	//Times the number of calls hit by the fault, 0 means until the faults are cleared
+	Times int
+}
+
+//Peer is a peer the simulated node is connected to
+type Peer struct {
+	ID            string
+	Caps          []string
+	LocalAddress  string
+	RemoteAddress string
+	Shard         int
+}
+
+//Node is a simulated seele node serving the json-rpc api of a chain
+type Node struct {
+	lock    sync.RWMutex
+	chain   *Chain
+	pending []*Tx
+	peers   []Peer
+	faults  map[string]*Fault
+
+	server *netrpc.Server
+
+	connLock  sync.Mutex
+	conns     map[io.Closer]bool
+	notifiers map[*notifier]bool
+	listeners []net.Listener
+}
+
+//NewNode return a node serving the chain
+func NewNode(chain *Chain) *Node {
+	n := &Node{
+		chain:     chain,
+		faults:    make(map[string]*Fault),
+		server:    netrpc.NewServer(),
+		conns:     make(map[io.Closer]bool),
+		notifiers: make(map[*notifier]bool),
+	}
+
+	n.register(n.server, &seeleService{n})
+	return n
+}
+
+//register register the services of the node, the seele service of a stream connection also
+//serves the subscriptions
+func (n *Node) register(server *netrpc.Server, seele interface{}) {
+	server.RegisterName("seele", seele)
+	server.RegisterName("txpool", &txPoolService{n})
+	server.RegisterName("network", &networkService{n})
+}
+
+//Do run fn with the chain, the node does not serve any call until it returns
+func (n *Node) Do(fn func(chain *Chain)) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	fn(n.chain)
+}
+
+//Height return the height of the head block
+func (n *Node) Height() uint64 {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.chain.Height()
+}
+
+//Mine mine a block with the pending txs
+func (n *Node) Mine(creator string, timestamp uint64) *Block {
+	n.lock.Lock()
+
+	b := n.chain.Mine(creator, timestamp, n.pending)
+	n.pending = nil
+	n.lock.Unlock()
+
+	n.notifyHead()
+	return b
+}
+
+//Fork replace the blocks above the height with a longer branch, the txs of the dropped blocks are pending again
+func (n *Node) Fork(height uint64, creator string) {
+	n.lock.Lock()
+	orphaned := n.chain.Fork(height, creator)
+	n.pending = append(orphaned, n.pending...)
+	n.lock.Unlock()
+
+	n.notifyHead()
+}
+
+//notifyHead push the head block to the subscriptions of all the connections
+func (n *Node) notifyHead() {
+	n.lock.RLock()
+	head := newOutputBlock(n.chain.Head(), false)
+	n.lock.RUnlock()
+
+	n.connLock.Lock()
+	notifiers := make([]*notifier, 0, len(n.notifiers))
+	for w := range n.notifiers {
+		notifiers = append(notifiers, w)
+	}
+	n.connLock.Unlock()
+
+	for _, w := range notifiers {
+		w.notify(head)
+	}
+}
+
+//AddPendingTx add a transfer to the tx pool and return it
+func (n *Node) AddPendingTx(from, to string, amount, fee *big.Int, timestamp uint64) *Tx {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	var sent uint64
+	for _, tx := range n.pending {
+		if tx.From == from {
+			sent++
+		}
+	}
+
+	tx := n.chain.NewTx(from, to, amount, fee, sent, timestamp)
+	n.pending = append(n.pending, tx)
+	return tx
+}
+
+//DropPendingTxs remove all the txs from the tx pool
+func (n *Node) DropPendingTxs() {
+	n.lock.Lock()
+	n.pending = nil
+	n.lock.Unlock()
+}
+
+//AddPeer add a peer of the node
+func (n *Node) AddPeer(peer Peer) {
+	n.lock.Lock()
+	n.peers = append(n.peers, peer)
+	n.lock.Unlock()
+}
+
+//InjectFault inject the fault into the calls of the method, such as seele.GetBlockByHeight
+func (n *Node) InjectFault(method string, fault Fault) {
+	n.lock.Lock()
+	n.faults[method] = &fault
+	n.lock.Unlock()
+}
+
+//ClearFaults remove all the faults
+func (n *Node) ClearFaults() {
+	n.lock.Lock()
+	n.faults = make(map[string]*Fault)
+	n.lock.Unlock()
+}
+
+//takeFault return the fault of the method and count the call
+func (n *Node) takeFault(method string) *Fault {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	fault, ok := n.faults[method]
+	if !ok {
+		return nil
+	}
+
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			delete(n.faults, method)
+		}
+	}
+
+	f := *fault
+	return &f
+}
+
+//call answer the call of the method with the result of fn, unless a fault is injected
+func (n *Node) call(method string, reply *json.RawMessage, fn func(chain *Chain) (interface{}, error)) error {
+	if fault := n.takeFault(method); fault != nil {
+		time.Sleep(fault.Delay)
+		if fault.Error != "" {
+			return errors.New(fault.Error)
+		}
+		if fault.Malformed {
+			*reply = malformedReply
+			return nil
+		}
+	}
+
+	n.lock.RLock()
+	result, err := fn(n.chain)
+	n.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	*reply = raw
+	return nil
+}
+
+//Serve accept the connections of the listener and serve json-rpc on them until the listener is closed
+func (n *Node) Serve(l net.Listener) error {
+	n.connLock.Lock()
+	n.listeners = append(n.listeners, l)
+	n.connLock.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		n.track(conn, true)
+		go n.serveConn(conn)
+	}
+}
+
+//serveConn serve json-rpc on the connection, the head blocks are pushed to the subscriptions made on it
+func (n *Node) serveConn(conn net.Conn) {
+	w := &notifier{Conn: conn, subs: make(map[string]bool)}
+	server := netrpc.NewServer()
+	n.register(server, &streamSeeleService{seeleService: &seeleService{n}, w: w})
+
+	n.connLock.Lock()
+	n.notifiers[w] = true
+	n.connLock.Unlock()
+
+	server.ServeCodec(rpc.NewJSONCodec(w, server))
+
+	n.connLock.Lock()
+	delete(n.notifiers, w)
+	n.connLock.Unlock()
+	n.track(conn, false)
+}
+
+//ServeHTTP serve a json-rpc request or batch posted to the http endpoint
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "json-rpc requests must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	n.server.ServeRequest(rpc.NewJSONCodec(&httpConn{Reader: r.Body, Writer: w}, n.server))
+}
+
+//DropConnections close all the connections as if the node restarted, the listeners keep accepting new ones
+func (n *Node) DropConnections() {
+	n.connLock.Lock()
+	defer n.connLock.Unlock()
+
+	for conn := range n.conns {
+		conn.Close()
+	}
+	n.conns = make(map[io.Closer]bool)
+}
+
+//Close close the listeners and all the connections
+func (n *Node) Close() {
+	n.connLock.Lock()
+	for _, l := range n.listeners {
+		l.Close()
+	}
+	n.listeners = nil
+	n.connLock.Unlock()
+
+	n.DropConnections()
+}
+
+func (n *Node) track(conn io.Closer, open bool) {
+	n.connLock.Lock()
+	defer n.connLock.Unlock()
+
+	if open {
+		n.conns[conn] = true
+	} else {
+		delete(n.conns, conn)
+	}
+}
+
+//httpConn is the body of a request and the response writer
+type httpConn struct {
+	io.Reader
+	io.Writer
+}
+
+func (c *httpConn) Close() error {
+	return nil
+}
+
+//notifier is a stream connection of the node, the writes are serialized so that the
+//notifications do not interleave with the responses
+type notifier struct {
+	net.Conn
+
+	writeLock sync.Mutex
+
+	subLock sync.Mutex
+	seq     uint64
+	subs    map[string]bool
+}
+
+func (w *notifier) Write(p []byte) (int, error) {
+	w.writeLock.Lock()
+	defer w.writeLock.Unlock()
+	return w.Conn.Write(p)
+}
+
+//subscribe return the id of a new subscription
+func (w *notifier) subscribe() string {
+	w.subLock.Lock()
+	defer w.subLock.Unlock()
+
+	w.seq++
+	id := fmt.Sprintf("0x%x", w.seq)
+	w.subs[id] = true
+	return id
+}
+
+//unsubscribe cancel the subscription, it returns false if there is none of the id
+func (w *notifier) unsubscribe(id string) bool {
+	w.subLock.Lock()
+	defer w.subLock.Unlock()
+
+	ok := w.subs[id]
+	delete(w.subs, id)
+	return ok
+}
+
+//notify push the result to every subscription of the connection
+func (w *notifier) notify(result interface{}) {
+	w.subLock.Lock()
+	ids := make([]string, 0, len(w.subs))
+	for id := range w.subs {
+		ids = append(ids, id)
+	}
+	w.subLock.Unlock()
+
+	for _, id := range ids {
+		msg, err := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "seele.subscription",
+			"params":  map[string]interface{}{"subscription": id, "result": result},
+		})
+		if err != nil {
+			return
+		}
+		w.Write(append(msg, '\n'))
+	}
+}
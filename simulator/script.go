@@ -0,0 +1,107 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+)
+
+//ScriptTx is a transfer in a script, the fee is zero if it is missing
+type ScriptTx struct {
+	From    string
+	To      string
+	Amount  *big.Int
+	Fee     *big.Int
+	Payload string
+}
+
+//ScriptBlock is a block in a script, the timestamp follows the parent if it is missing
+type ScriptBlock struct {
+	Creator   string
+	Timestamp uint64
+	Txs       []ScriptTx
+}
+
+//Script describe a chain block by block, the blocks are mined in order on the genesis block
+type Script struct {
+	Shard       int
+	GenesisTime uint64
+	Blocks      []ScriptBlock
+	Pending     []ScriptTx
+	Peers       []Peer
+}
+
+//LoadScript read a script from the json file
+func LoadScript(path string) (*Script, error) {
+	buff, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var script Script
+	if err := json.Unmarshal(buff, &script); err != nil {
+		return nil, fmt.Errorf("invalid script %s, %v", path, err)
+	}
+	return &script, nil
+}
+
+//Node mine the blocks of the script and return a node serving them
+func (s *Script) Node() (*Node, error) {
+	chain := NewChain(s.Shard, s.GenesisTime)
+	for i, b := range s.Blocks {
+		if b.Creator == "" {
+			return nil, fmt.Errorf("block %d of the script has no creator", i+1)
+		}
+
+		sent := make(map[string]uint64)
+		txs := make([]*Tx, 0, len(b.Txs))
+		for j := range b.Txs {
+			tx, err := newScriptTx(chain, &b.Txs[j], sent, b.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("tx %d of block %d, %v", j, i+1, err)
+			}
+			txs = append(txs, tx)
+		}
+		chain.Mine(b.Creator, b.Timestamp, txs)
+	}
+
+	n := NewNode(chain)
+	for j := range s.Pending {
+		tx := &s.Pending[j]
+		if tx.From == "" || tx.To == "" || tx.Amount == nil {
+			return nil, fmt.Errorf("pending tx %d of the script needs from, to and amount", j)
+		}
+		n.AddPendingTx(tx.From, tx.To, tx.Amount, feeOf(tx), chain.Head().Timestamp)
+	}
+
+	for _, peer := range s.Peers {
+		n.AddPeer(peer)
+	}
+	return n, nil
+}
+
+//newScriptTx return the tx of the script, sent is the number of txs of every sender in the block
+func newScriptTx(chain *Chain, tx *ScriptTx, sent map[string]uint64, timestamp uint64) (*Tx, error) {
+	if tx.From == "" || tx.To == "" || tx.Amount == nil {
+		return nil, fmt.Errorf("from, to and amount are required")
+	}
+
+	t := chain.NewTx(tx.From, tx.To, tx.Amount, feeOf(tx), sent[tx.From], timestamp)
+	t.Payload = tx.Payload
+	t.Hash = txHash(t, 0)
+	sent[tx.From]++
+	return t, nil
+}
+
+func feeOf(tx *ScriptTx) *big.Int {
+	if tx.Fee == nil {
+		return new(big.Int)
+	}
+	return tx.Fee
+}
@@ -0,0 +1,263 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+var (
+	errBlockNotFound = errors.New("leveldb: not found")
+	errTxNotFound    = errors.New("transaction not found")
+)
+
+//outputTx is a transaction in the format of seele node
+type outputTx struct {
+	Hash         string   `json:"hash"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Amount       *big.Int `json:"amount"`
+	AccountNonce uint64   `json:"accountNonce"`
+	Payload      string   `json:"payload"`
+	Timestamp    uint64   `json:"timestamp"`
+	Fee          *big.Int `json:"fee"`
+}
+
+func newOutputTx(tx *Tx) *outputTx {
+	return &outputTx{
+		Hash:         tx.Hash,
+		From:         tx.From,
+		To:           tx.To,
+		Amount:       tx.Amount,
+		AccountNonce: tx.Nonce,
+		Payload:      tx.Payload,
+		Timestamp:    tx.Timestamp,
+		Fee:          tx.Fee,
+	}
+}
+
+//outputBlock is a block in the format of seele node, the transactions are hashes unless fullTx is set
+type outputBlock struct {
+	Hash            string        `json:"hash"`
+	ParentHash      string        `json:"parentHash"`
+	Height          uint64        `json:"height"`
+	StateHash       string        `json:"stateHash"`
+	Timestamp       uint64        `json:"timestamp"`
+	Difficulty      *big.Int      `json:"difficulty"`
+	TotalDifficulty *big.Int      `json:"totalDifficulty"`
+	Creator         string        `json:"creator"`
+	Nonce           uint64        `json:"nonce"`
+	TxHash          string        `json:"txHash"`
+	Transactions    []interface{} `json:"transactions"`
+	Debts           []interface{} `json:"debts"`
+}
+
+func newOutputBlock(b *Block, fullTx bool) *outputBlock {
+	txs := make([]interface{}, 0, len(b.Txs))
+	hashes := make([]string, 0, len(b.Txs))
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash)
+		if fullTx {
+			txs = append(txs, newOutputTx(tx))
+		} else {
+			txs = append(txs, tx.Hash)
+		}
+	}
+
+	return &outputBlock{
+		Hash:            b.Hash,
+		ParentHash:      b.ParentHash,
+		Height:          b.Height,
+		StateHash:       hashOf("state", b.Hash),
+		Timestamp:       b.Timestamp,
+		Difficulty:      b.Difficulty,
+		TotalDifficulty: b.TotalDifficulty,
+		Creator:         b.Creator,
+		Nonce:           b.Nonce,
+		TxHash:          hashOf("txs", hashes),
+		Transactions:    txs,
+		Debts:           []interface{}{},
+	}
+}
+
+//seeleService is the seele api of the simulated node
+type seeleService struct {
+	n *Node
+}
+
+//GetBlockByHeight get the block at the height, a negative height means the head block
+func (s *seeleService) GetBlockByHeight(req rpc.GetBlockByHeightRequest, reply *json.RawMessage) error {
+	return s.n.call("seele.GetBlockByHeight", reply, func(chain *Chain) (interface{}, error) {
+		if req.Height < 0 {
+			return newOutputBlock(chain.Head(), req.FullTx), nil
+		}
+
+		b, ok := chain.BlockByHeight(uint64(req.Height))
+		if !ok {
+			return nil, errBlockNotFound
+		}
+		return newOutputBlock(b, req.FullTx), nil
+	})
+}
+
+//GetBlockByHash get the block with the hash
+func (s *seeleService) GetBlockByHash(req rpc.GetBlockByHashRequest, reply *json.RawMessage) error {
+	return s.n.call("seele.GetBlockByHash", reply, func(chain *Chain) (interface{}, error) {
+		b, ok := chain.BlockByHash(req.HashHex)
+		if !ok {
+			return nil, errBlockNotFound
+		}
+		return newOutputBlock(b, req.FullTx), nil
+	})
+}
+
+//GetBalance get the balance of the account at the head block
+func (s *seeleService) GetBalance(address string, reply *json.RawMessage) error {
+	return s.n.call("seele.GetBalance", reply, func(chain *Chain) (interface{}, error) {
+		return chain.Balance(address), nil
+	})
+}
+
+//GetAccountNonce get the number of txs the account sent
+func (s *seeleService) GetAccountNonce(address string, reply *json.RawMessage) error {
+	return s.n.call("seele.GetAccountNonce", reply, func(chain *Chain) (interface{}, error) {
+		return chain.Nonce(address), nil
+	})
+}
+
+//GetInfo get the state of the node
+func (s *seeleService) GetInfo(input interface{}, reply *json.RawMessage) error {
+	return s.n.call("seele.GetInfo", reply, func(chain *Chain) (interface{}, error) {
+		head := chain.Head()
+		return map[string]interface{}{
+			"Coinbase":           head.Creator,
+			"CurrentBlockHeight": head.Height,
+			"HeaderHash":         head.Hash,
+			"Shard":              chain.shard,
+			"MinerStatus":        "Stopped",
+			"Version":            "simulator",
+			"BlockAge":           0,
+			"PeerCnt":            fmt.Sprint(len(s.n.peers)),
+		}, nil
+	})
+}
+
+//streamSeeleService is the seele api served on a stream connection, which also serves the subscriptions
+type streamSeeleService struct {
+	*seeleService
+	w *notifier
+}
+
+//SubscribeNewHead subscribe to the head blocks, they are pushed as seele.subscription notifications
+func (s *streamSeeleService) SubscribeNewHead(input interface{}, reply *json.RawMessage) error {
+	return s.n.call("seele.SubscribeNewHead", reply, func(chain *Chain) (interface{}, error) {
+		return s.w.subscribe(), nil
+	})
+}
+
+//Unsubscribe cancel the subscription of the id
+func (s *streamSeeleService) Unsubscribe(id string, reply *json.RawMessage) error {
+	return s.n.call("seele.Unsubscribe", reply, func(chain *Chain) (interface{}, error) {
+		return s.w.unsubscribe(id), nil
+	})
+}
+
+//txPoolService is the txpool api of the simulated node
+type txPoolService struct {
+	n *Node
+}
+
+//GetReceiptByTxHash get the receipt of a mined tx
+func (s *txPoolService) GetReceiptByTxHash(txHash string, reply *json.RawMessage) error {
+	return s.n.call("txpool.GetReceiptByTxHash", reply, func(chain *Chain) (interface{}, error) {
+		receipt, ok := chain.Receipt(txHash)
+		if !ok {
+			return nil, errTxNotFound
+		}
+
+		return map[string]interface{}{
+			"result":    "0x",
+			"poststate": hashOf("poststate", txHash),
+			"txhash":    receipt.TxHash,
+			"contract":  "0x",
+			"failed":    receipt.Failed,
+			"usedGas":   receipt.UsedGas,
+			"totalFee":  receipt.Fee,
+		}, nil
+	})
+}
+
+//GetTransactionByHash get the tx in the tx pool or in a block
+func (s *txPoolService) GetTransactionByHash(txHash string, reply *json.RawMessage) error {
+	return s.n.call("txpool.GetTransactionByHash", reply, func(chain *Chain) (interface{}, error) {
+		if tx, b, index, ok := chain.Tx(txHash); ok {
+			return map[string]interface{}{
+				"transaction": newOutputTx(tx),
+				"status":      "block",
+				"blockHash":   b.Hash,
+				"blockHeight": b.Height,
+				"txIndex":     index,
+			}, nil
+		}
+
+		for _, tx := range s.n.pending {
+			if tx.Hash == txHash {
+				return map[string]interface{}{
+					"transaction": newOutputTx(tx),
+					"status":      "pool",
+				}, nil
+			}
+		}
+		return nil, errTxNotFound
+	})
+}
+
+//GetPendingTransactions get the txs in the tx pool
+func (s *txPoolService) GetPendingTransactions(input interface{}, reply *json.RawMessage) error {
+	return s.n.call("txpool.GetPendingTransactions", reply, func(chain *Chain) (interface{}, error) {
+		txs := make([]*outputTx, 0, len(s.n.pending))
+		for _, tx := range s.n.pending {
+			txs = append(txs, newOutputTx(tx))
+		}
+		return txs, nil
+	})
+}
+
+//networkService is the network api of the simulated node
+type networkService struct {
+	n *Node
+}
+
+//GetPeersInfo get the peers of the node
+func (s *networkService) GetPeersInfo(input interface{}, reply *json.RawMessage) error {
+	return s.n.call("network.GetPeersInfo", reply, func(chain *Chain) (interface{}, error) {
+		peers := make([]map[string]interface{}, 0, len(s.n.peers))
+		for _, p := range s.n.peers {
+			peers = append(peers, map[string]interface{}{
+				"id":   p.ID,
+				"caps": p.Caps,
+				"network": map[string]string{
+					"localAddress":  p.LocalAddress,
+					"remoteAddress": p.RemoteAddress,
+				},
+				"shard": p.Shard,
+			})
+		}
+		return peers, nil
+	})
+}
+
+//GetNetVersion get the version of the simulated network
+func (s *networkService) GetNetVersion(input interface{}, reply *json.RawMessage) error {
+	return s.n.call("network.GetNetVersion", reply, func(chain *Chain) (interface{}, error) {
+		return "simulator", nil
+	})
+}
@@ -0,0 +1,298 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package simulator
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+//startNode serve the node on a local tcp port and return a client connected to it
+func startNode(t *testing.T, n *Node, options ...func(rpc *rpc.SeeleRPC)) *rpc.SeeleRPC {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go n.Serve(l)
+
+	client := rpc.NewRPC(l.Addr().String(), options...)
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func generatedNode(blocks int) *Node {
+	chain := NewChain(1, 1537330000)
+	chain.Generate(rand.New(rand.NewSource(1)), blocks, 5, Accounts(1, 6), 10)
+	return NewNode(chain)
+}
+
+func TestBalancesFromBlocks(t *testing.T) {
+	n := generatedNode(30)
+	defer n.Close()
+	client := startNode(t, n)
+	defer client.Release()
+
+	current, err := client.CurrentBlock()
+	if err != nil || current.Height != 30 {
+		t.Fatalf("bad current block %+v, %v", current, err)
+	}
+
+	blocks, err := client.GetBlocksByHeightRange(1, 30, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//replay the blocks the way the syncer does and compare with the balances of the node
+	balances := make(map[string]*big.Int)
+	add := func(address string, amount *big.Int) {
+		if address == NullAddress {
+			return
+		}
+		if balances[address] == nil {
+			balances[address] = new(big.Int)
+		}
+		balances[address].Add(balances[address], amount)
+	}
+
+	for _, b := range blocks {
+		hashes := make([]string, 0, len(b.Txs))
+		for _, tx := range b.Txs {
+			hashes = append(hashes, tx.Hash)
+		}
+
+		receipts, err := client.GetReceiptsByTxHashes(hashes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, tx := range b.Txs {
+			add(tx.From, new(big.Int).Neg(receipts[i].TotalFee))
+			add(b.Creator, receipts[i].TotalFee)
+			if !receipts[i].Failed {
+				add(tx.From, new(big.Int).Neg(tx.Amount))
+				add(tx.To, tx.Amount)
+			}
+		}
+	}
+
+	addresses := Accounts(1, 6)
+	nodeBalances, err := client.GetBalances(addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, address := range addresses {
+		expected := balances[address]
+		if expected == nil {
+			expected = new(big.Int)
+		}
+		if nodeBalances[i].Cmp(expected) != 0 {
+			t.Fatalf("balance of %s is %v, replayed %v", address, nodeBalances[i], expected)
+		}
+	}
+}
+
+func TestFork(t *testing.T) {
+	n := generatedNode(20)
+	defer n.Close()
+	client := startNode(t, n)
+	defer client.Release()
+
+	before, err := client.GetBlockByHeight(15, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := client.GetBlockByHeight(10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.Fork(10, Accounts(1, 1)[0])
+
+	after, err := client.GetBlockByHeight(15, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Hash == before.Hash {
+		t.Fatal("the block above the fork point is not replaced")
+	}
+
+	child, err := client.GetBlockByHeight(11, false)
+	if err != nil || child.ParentHash != kept.Hash {
+		t.Fatalf("the new branch does not start at the fork point, %v", err)
+	}
+
+	if n.Height() != 21 {
+		t.Fatalf("the new branch should be longer, height %d", n.Height())
+	}
+
+	if _, err := client.GetBlockByHash(before.Hash, false); err == nil {
+		t.Fatal("the dropped block is still served")
+	}
+}
+
+func TestFaults(t *testing.T) {
+	n := generatedNode(3)
+	defer n.Close()
+	client := startNode(t, n, rpc.WithTimeout(100*time.Millisecond), rpc.WithRetry(0, 0))
+	defer client.Release()
+
+	address := Accounts(1, 1)[0]
+
+	n.InjectFault("seele.GetBalance", Fault{Malformed: true, Times: 1})
+	if _, err := client.GetBalance(address); err == nil {
+		t.Fatal("expected a decode error")
+	} else if _, ok := err.(*rpc.DecodeError); !ok {
+		t.Fatalf("expected a decode error, got %v", err)
+	}
+
+	n.InjectFault("seele.GetBalance", Fault{Error: "state is pruned", Times: 1})
+	if _, err := client.GetBalance(address); err == nil || !strings.Contains(err.Error(), "state is pruned") {
+		t.Fatalf("expected the injected error, got %v", err)
+	}
+
+	n.InjectFault("seele.GetBalance", Fault{Delay: time.Second, Times: 1})
+	if _, err := client.GetBalance(address); err != context.DeadlineExceeded {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	if _, err := client.GetBalance(address); err != nil {
+		t.Fatalf("the faults should be over, %v", err)
+	}
+
+	n.DropConnections()
+	if _, err := client.GetBalance(address); err == nil {
+		t.Fatal("expected the error of the dropped connection")
+	}
+
+	if _, err := client.GetBalance(address); err != nil {
+		t.Fatalf("the client should reconnect, %v", err)
+	}
+}
+
+func TestScriptOverHTTP(t *testing.T) {
+	script, err := LoadScript("testdata/chain.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := script.Node()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(n)
+	defer server.Close()
+
+	client := rpc.NewRPC(server.URL)
+	balances, err := client.GetBalances([]string{
+		"0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21",
+		"0x2a87b6504cd00af95a83b9887112016a2a991cf1",
+		"0x91dbd8fd6b2f6e6a1e4bd40eec8bc01ac6a98a51",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []int64{299899958, 150100042, 0} {
+		if balances[i].Int64() != expected {
+			t.Fatalf("balance %d is %v, expected %d", i, balances[i], expected)
+		}
+	}
+
+	block, err := client.GetBlockByHeight(3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, err := client.GetReceiptByTxHash(block.Txs[2].Hash)
+	if err != nil || !receipt.Failed {
+		t.Fatalf("the overdraft should fail, %+v %v", receipt, err)
+	}
+
+	pending, err := client.GetPendingTransactions()
+	if err != nil || len(pending) != 1 || pending[0].Amount.Int64() != 1000 {
+		t.Fatalf("bad pending txs %+v, %v", pending, err)
+	}
+
+	peers, err := client.GetPeersInfo()
+	if err != nil || len(peers) != 1 || peers[0].ShardNumber != 2 {
+		t.Fatalf("bad peers %+v, %v", peers, err)
+	}
+
+	n.Mine(block.Creator, 0)
+	pending, err = client.GetPendingTransactions()
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("the pending tx should be mined, %+v %v", pending, err)
+	}
+}
+
+func TestSubscribeNewHead(t *testing.T) {
+	n := generatedNode(5)
+	defer n.Close()
+	client := startNode(t, n)
+	defer client.Release()
+
+	sub, err := client.SubscribeNewHead(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nextHead := func() *rpc.CurrentBlock {
+		select {
+		case head, ok := <-sub.Heads():
+			if !ok {
+				t.Fatal("the subscription is closed")
+			}
+			return head
+		case <-time.After(time.Second):
+			t.Fatal("no head is pushed")
+		}
+		return nil
+	}
+
+	creator := Accounts(1, 1)[0]
+	mined := n.Mine(creator, 1537331000)
+	if head := nextHead(); head.Height != 6 || head.HeadHash != mined.Hash {
+		t.Fatalf("bad head %+v", head)
+	}
+
+	//the calls keep working on the connection carrying the notifications
+	if current, err := client.CurrentBlock(); err != nil || current.Height != 6 {
+		t.Fatalf("bad current block %+v, %v", current, err)
+	}
+
+	n.Fork(4, creator)
+	if head := nextHead(); head.Height != 7 {
+		t.Fatalf("bad head after the fork %+v", head)
+	}
+
+	n.DropConnections()
+	select {
+	case _, ok := <-sub.Heads():
+		if ok {
+			t.Fatal("expected the subscription to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the subscription is not closed after the connection is lost")
+	}
+
+	server := httptest.NewServer(n)
+	defer server.Close()
+	if _, err := rpc.NewRPC(server.URL).SubscribeNewHead(context.Background()); err != rpc.ErrNotificationsUnsupported {
+		t.Fatalf("expected ErrNotificationsUnsupported over http, got %v", err)
+	}
+}
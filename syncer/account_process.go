@@ -1,9 +1,8 @@
 package syncer
 
 import (
-	"sync"
+	"math/big"
 
-	"github.com/seeleteam/scan-api/log"
 	"github.com/seeleteam/scan-api/rpc"
 
 	"github.com/seeleteam/scan-api/database"
@@ -16,7 +15,6 @@ const (
 func (s *Syncer) getAccountFromDBOrCache(address string) *database.DBAccount {
 	account, ok := s.cacheAccount[address]
 	if ok {
-		s.updateAccount[address] = account
 		return account
 	}
 
@@ -25,185 +23,164 @@ func (s *Syncer) getAccountFromDBOrCache(address string) *database.DBAccount {
 		fromAccount = database.CreateEmptyAccount(address, s.shardNumber)
 	}
 
-	s.updateAccount[address] = fromAccount
 	s.cacheAccount[address] = fromAccount
 	return fromAccount
 }
 
-//ProcessAccount Process All Account included in the block
-func (s *Syncer) accountSync(b *rpc.BlockInfo) error {
+//forgetAccount remove the account from the cache
+func (s *Syncer) forgetAccount(address string) {
+	delete(s.cacheAccount, address)
+	delete(s.touchedAccount, address)
+}
+
+//txFee return the fee actually paid by the transaction, the fee in the receipt is preferred
+func txFee(tx *rpc.Transaction, receipt *rpc.Receipt) *big.Int {
+	if receipt != nil && receipt.TotalFee != nil {
+		return receipt.TotalFee
+	}
+	return tx.Fee
+}
+
+//ProcessAccount Process All Account included in the block.
+//Balances are computed from the block: the coinbase transaction mints the reward,
+//fees go to the block creator, and a failed transaction only pays its fee
+func (s *Syncer) accountSync(b *rpc.BlockInfo, receipts map[string]*rpc.Receipt) error {
+	journal := newBlockJournal(s.shardNumber, b, s.cursor.TxIdx)
 	for i := 0; i < len(b.Txs); i++ {
 		tx := b.Txs[i]
+		receipt := receipts[tx.Hash]
+		failed := receipt != nil && receipt.Failed
 
 		//exclude coinbase transaction
 		if tx.From != nullAddress {
 
-			fromAccount := s.getAccountFromDBOrCache(tx.From)
-			fromAccount.TxCount++
-
-			// fromAccount, err := s.db.GetAccountByAddress(tx.From)
-			// if err != nil {
-			// 	fromAccount = database.CreateEmptyAccount(tx.From, s.shardNumber)
-			// 	err := s.db.AddAccount(fromAccount)
-			// 	if err != nil {
-			// 		log.Error("[DB] err : %v", err)
-			// 		continue
-			// 	}
-			// }
-
-			// balance, err := s.rpc.GetBalance(tx.From)
-			// if err != nil {
-			// 	log.Error(err)
-			// 	balance = 0
-			// }
-
-			// txCnt, err := s.db.GetTxCntByShardNumberAndAddress(s.shardNumber, tx.From)
-			// if err != nil {
-			// 	log.Error(err)
-			// 	txCnt = 0
-			// }
-
-			// s.db.UpdateAccount(tx.From, balance, txCnt)
+			journal.account(tx.From).TxCount++
+			journal.transfer(tx.From, b.Creator, txFee(&tx, receipt))
 		}
 
 		if tx.To == "" {
 			//create contract transaction
 			//Get contract address from receipt
 
-			receipt, err := s.rpc.GetReceiptByTxHash(tx.Hash)
-			if err == nil {
+			if receipt != nil && receipt.ContractAddress != "" {
 				contractAddress := receipt.ContractAddress
-				contractAccount := s.getAccountFromDBOrCache(contractAddress)
-				//contractAccount := database.CreateEmptyAccount(contractAddress, s.shardNumber)
-				contractAccount.AccType = 1
-				contractAccount.TxCount++
-				// err := s.db.AddAccount(contractAccount)
-
-				// if err != nil {
-				// 	log.Error("[DB] err : %v", err)
-				// 	continue
-				// }
-
-				// balance, err := s.rpc.GetBalance(contractAddress)
-				// if err != nil {
-				// 	log.Error(err)
-				// 	balance = 0
-				// }
-
-				// txCnt, err := s.db.GetTxCntByShardNumberAndAddress(s.shardNumber, contractAddress)
-				// if err != nil {
-				// 	log.Error(err)
-				// 	txCnt = 0
-				// }
-
-				// s.db.UpdateAccount(contractAddress, balance, txCnt)
+				contractUndo := journal.account(contractAddress)
+				contractUndo.TxCount++
+				contractUndo.CreatedContract = true
+				if !failed {
+					journal.transfer(tx.From, contractAddress, tx.Amount)
+				}
 			}
-
 		} else {
-			toAccount := s.getAccountFromDBOrCache(tx.To)
-			toAccount.TxCount++
-			// toAccount, err := s.db.GetAccountByAddress(tx.To)
-			// if err != nil {
-			// 	toAccount = database.CreateEmptyAccount(tx.To, s.shardNumber)
-			// 	err := s.db.AddAccount(toAccount)
-			// 	if err != nil {
-			// 		log.Error("[DB] err : %v", err)
-			// 		continue
-			// 	}
-			// }
-
-			// balance, err := s.rpc.GetBalance(tx.To)
-			// if err != nil {
-			// 	log.Error(err)
-			// 	balance = 0
-			// }
-
-			// txCnt, err := s.db.GetTxCntByShardNumberAndAddress(s.shardNumber, tx.To)
-			// if err != nil {
-			// 	log.Error(err)
-			// 	txCnt = 0
-			// }
-
-			// s.db.UpdateAccount(tx.To, balance, txCnt)
+			journal.account(tx.To).TxCount++
+			if !failed {
+				journal.transfer(tx.From, tx.To, tx.Amount)
+			}
 		}
 	}
 
 	//exclude genesis block
 	if b.Creator != nullAddress {
-		minerAccount, err := s.db.GetAccountByAddress(b.Creator)
-		if err != nil {
-			minerAccount = database.CreateEmptyAccount(b.Creator, s.shardNumber)
-			err := s.db.AddAccount(minerAccount)
-			if err != nil {
-				log.Error("[DB] err : %v", err)
-			}
-		}
+		journal.account(b.Creator).Mined++
+	}
+
+	stats := s.countStats(journal, len(b.Txs))
+	journal.undo.Stats = stats
+
+	//the undo journal is written first, so that the block can be rolled back
+	//even if the process dies while the accounts are being written
+	if err := s.db.AddBlockUndo(journal.blockUndo()); err != nil {
+		return err
+	}
 
-		blockCnt, err := s.db.GetMinedBlocksCntByShardNumberAndAddress(s.shardNumber, b.Creator)
-		if err != nil {
-			log.Error(err)
-			blockCnt = 0
+	if stats != nil {
+		if err := s.db.ApplyStats(s.shardNumber, journal.undo.Height, stats); err != nil {
+			return err
 		}
+	}
 
-		s.db.UpdateAccountMinedBlock(b.Creator, blockCnt)
+	if err := s.applyJournal(journal); err != nil {
+		return err
 	}
 
+	if stats == nil {
+		return s.db.RecountStats(s.shardNumber)
+	}
 	return nil
 }
 
-func (s *Syncer) accountUpdateSync() {
-	// for _, v := range s.newAccount {
-	// 	balance, err := s.rpc.GetBalance(v.Address)
-	// 	if err != nil {
-	// 		log.Error(err)
-	// 		balance = 0
-	// 	}
-	// 	v.Balance = balance
-
-	// 	// txCnt, err := s.db.GetTxCntByShardNumberAndAddress(s.shardNumber, v.Address)
-	// 	// if err != nil {
-	// 	// 	log.Error(err)
-	// 	// 	txCnt = 0
-	// 	// }
-	// 	// v.TxCount = txCnt
-
-	// 	err = s.db.AddAccount(v)
-	// 	if err != nil {
-	// 		log.Error("[DB] err : %v", err)
-	// 		continue
-	// 	}
-	// }
-
-	var wg sync.WaitGroup
-	wg.Add(len(s.updateAccount))
-
-	for _, v := range s.updateAccount {
-
-		// txCnt, err := s.db.GetTxCntByShardNumberAndAddress(s.shardNumber, v.Address)
-		// if err != nil {
-		// 	log.Error(err)
-		// 	txCnt = 0
-		// }
-
-		// v.TxCount = txCnt
-
-		s.workerpool.Submit(func() {
-			account := v
-			balance, err := s.rpc.GetBalance(account.Address)
-			if err != nil {
-				log.Error(err)
-				balance = 0
-			}
+//countStats mark the accounts created by the block and return the changes the block makes to the stats of the shard.
+//If the block was partly applied before, the accounts it created are already in database, so they are taken
+//from the undo journal of the first attempt. Nil is returned if that journal has no stats, then the stats are recounted
+func (s *Syncer) countStats(journal *blockJournal, txCnt int) *database.DBStatsDelta {
+	height := journal.undo.Height
+	delta := &database.DBStatsDelta{Blocks: 1, Txs: int64(txCnt)}
+	for address, u := range journal.accounts {
+		account := s.getAccountFromDBOrCache(address)
+		if account.SyncHeight >= height {
+			return s.previousStats(journal)
+		}
 
-			account.Balance = balance
+		u.Created = account.SyncHeight < 0
+		switch {
+		case u.Created && u.CreatedContract:
+			delta.Contracts++
+		case u.Created:
+			delta.Accounts++
+		case u.CreatedContract && account.AccType == 0:
+			delta.Accounts--
+			delta.Contracts++
+		}
+		delta.TotalBalance.Add(&delta.TotalBalance.Int, &u.Balance.Int)
+	}
 
-			s.db.UpdateAccount(account)
+	return delta
+}
 
-			wg.Done()
-		})
+//previousStats return the stats delta in the undo journal written by the first attempt of the block,
+//and copy the accounts it created into the journal
+func (s *Syncer) previousStats(journal *blockJournal) *database.DBStatsDelta {
+	undo, err := s.db.GetBlockUndo(s.shardNumber, uint64(journal.undo.Height))
+	if err != nil || undo.HeadHash != journal.undo.HeadHash || undo.Stats == nil {
+		return nil
 	}
 
-	wg.Wait()
+	for _, u := range undo.Accounts {
+		if ju, ok := journal.accounts[u.Address]; ok {
+			ju.Created = u.Created
+		}
+	}
+	return undo.Stats
+}
+
+//applyJournal apply the changes of a block to the accounts and write them into database,
+//an account which has already been updated by the block is left unchanged
+func (s *Syncer) applyJournal(journal *blockJournal) error {
+	height := journal.undo.Height
+	for address, u := range journal.accounts {
+		account := s.getAccountFromDBOrCache(address)
+		if u.CreatedContract {
+			account.AccType = 1
+		}
 
-	s.updateAccount = make(map[string]*database.DBAccount)
+		if account.SyncHeight < height {
+			account.TxCount += u.TxCount
+			account.Mined += u.Mined
+			account.Balance.Add(&account.Balance.Int, &u.Balance.Int)
+			account.SyncHeight = height
+		}
+
+		if err := s.db.UpdateAccount(account); err != nil {
+			return err
+		}
+		s.touchedAccount[address] = true
+
+		if account.SyncHeight == height {
+			if err := s.db.AddBalanceChange(journal.balanceChange(account)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
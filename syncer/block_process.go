@@ -7,7 +7,7 @@ import (
 )
 
 func (s *Syncer) blockSync(block *rpc.BlockInfo) error {
-	log.Info("[BlockSync syncCnt:%d]Get Block %d", s.syncCnt, block.Height)
+	log.Info("[BlockSync shard:%d syncCnt:%d]Get Block %d", s.shardNumber, s.syncCnt, block.Height)
 
 	//added block to cache
 	dbBlock := database.CreateDbBlock(block)
@@ -4,9 +4,29 @@ import (
 	"time"
 )
 
+//ShardConfig config of the syncer for a single shard
+type ShardConfig struct {
+	ShardNumber  int
+	RpcURL       string
+	SyncInterval time.Duration
+
+	//RpcURLs more seele nodes of the shard, the calls fail over between them and RpcURL
+	RpcURLs []string
+
+	//RecordFile append every rpc request and its response to the ndjson file, set RpcURL to
+	//replay://<file> to sync from the recording
+	RecordFile string
+}
+
+//Endpoints return the urls of all the seele nodes of the shard
+func (c *ShardConfig) Endpoints() []string {
+	return append([]string{c.RpcURL}, c.RpcURLs...)
+}
+
 //Config server config
 type Config struct {
 	RpcURL          string
+	RpcURLs         []string
 	WriteLog        bool
 	LogLevel        string
 	LogFile         string
@@ -14,4 +34,37 @@ type Config struct {
 	DataBaseName    string
 	SyncInterval    time.Duration
 	ShardNumber     int
+	RecordFile      string
+
+	//Shards run one syncer for each shard in the list, RpcURL, RpcURLs, ShardNumber, SyncInterval and RecordFile are used if it is empty
+	Shards []ShardConfig
+
+	//FetchConcurrency number of goroutines fetching blocks ahead of the committer
+	FetchConcurrency int
+	//FetchWindow max number of blocks fetched but not committed yet
+	FetchWindow int
+}
+
+//GetShards return the config of all the shards to sync
+func (c *Config) GetShards() []ShardConfig {
+	if len(c.Shards) == 0 {
+		return []ShardConfig{
+			{
+				ShardNumber:  c.ShardNumber,
+				RpcURL:       c.RpcURL,
+				RpcURLs:      c.RpcURLs,
+				SyncInterval: c.SyncInterval,
+				RecordFile:   c.RecordFile,
+			},
+		}
+	}
+
+	shards := make([]ShardConfig, 0, len(c.Shards))
+	for _, shard := range c.Shards {
+		if shard.SyncInterval <= 0 {
+			shard.SyncInterval = c.SyncInterval
+		}
+		shards = append(shards, shard)
+	}
+	return shards
 }
@@ -0,0 +1,106 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"time"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+//loadCursor load the sync cursor of the shard, the cursor of a database synced
+//before the cursor existed is derived from the stored blocks and txs
+func (s *Syncer) loadCursor() error {
+	if s.cursor != nil {
+		return nil
+	}
+
+	cursor, err := s.db.GetSyncCursor(s.shardNumber)
+	if err != nil {
+		return err
+	}
+
+	if cursor == nil {
+		cursor, err = s.legacyCursor()
+		if err != nil {
+			return err
+		}
+		log.Info("[BlockSync shard:%d]create sync cursor at height %d", s.shardNumber, cursor.Height)
+	}
+
+	s.cursor = cursor
+	return nil
+}
+
+//legacyCursor derive the cursor from the block count and tx count of the shard
+func (s *Syncer) legacyCursor() (*database.DBSyncCursor, error) {
+	cursor := &database.DBSyncCursor{
+		ShardNumber: s.shardNumber,
+		Height:      -1,
+	}
+
+	blockCnt, err := s.db.GetBlockHeight(s.shardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockCnt == 0 {
+		return cursor, nil
+	}
+
+	tip, err := s.db.GetBlockByHeight(s.shardNumber, blockCnt-1)
+	if err != nil {
+		return nil, err
+	}
+
+	txCnt, err := s.db.GetTxCntByShardNumber(s.shardNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor.Height = tip.Height
+	cursor.HeadHash = tip.HeadHash
+	cursor.TxIdx = int64(txCnt)
+	return cursor, nil
+}
+
+//nextHeight return the height of the next block to sync
+func (s *Syncer) nextHeight() uint64 {
+	return uint64(s.cursor.Height + 1)
+}
+
+//waitHeight return the height of the block the syncer waits for, any block is new before the cursor is loaded
+func (s *Syncer) waitHeight() uint64 {
+	if s.cursor == nil {
+		return 0
+	}
+	return s.nextHeight()
+}
+
+//advanceCursor persist the cursor after all the writes of the block finish
+func (s *Syncer) advanceCursor(block *rpc.BlockInfo) error {
+	return s.setCursor(int64(block.Height), block.Hash, s.cursor.TxIdx+int64(len(block.Txs)))
+}
+
+//setCursor persist the cursor, the cached cursor is updated only if the write succeeds
+func (s *Syncer) setCursor(height int64, hash string, txIdx int64) error {
+	cursor := &database.DBSyncCursor{
+		ShardNumber: s.shardNumber,
+		Height:      height,
+		HeadHash:    hash,
+		TxIdx:       txIdx,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	if err := s.db.SetSyncCursor(cursor); err != nil {
+		return err
+	}
+
+	s.cursor = cursor
+	return nil
+}
@@ -6,18 +6,42 @@ import "github.com/seeleteam/scan-api/database"
 type Database interface {
 	GetBlockHeight(shardNumber int) (uint64, error)
 	AddBlock(b *database.DBBlock) error
-	RemoveBlock(height uint64) error
-	RemoveTxs(blockHeight uint64) error
+	RemoveBlock(shardNumber int, height uint64) error
+	RemoveTxs(shardNumber int, blockHeight uint64) error
 	GetBlockByHeight(shardNumber int, height uint64) (*database.DBBlock, error)
-	RemoveAllPendingTxs() error
+	GetBlockByHash(hash string) (*database.DBBlock, error)
+	GetTxsByBlock(shardNumber int, blockHeight uint64) ([]*database.DBTx, error)
 	AddTx(tx *database.DBTx) error
 	AddPendingTx(tx *database.DBTx) error
+	GetAllPendingTxs(shardNumber int) ([]*database.DBTx, error)
+	GetPendingTxByHash(hash string) (*database.DBTx, error)
+	UpdatePendingTxState(hash string, state string, leftPoolTime, inclusionTime int64) error
+	RemovePoolTxsBefore(shardNumber int, before int64) error
+	GetTxByHash(hash string) (*database.DBTx, error)
+	AddReceipt(receipt *database.DBReceipt) error
+	RemoveReceipts(shardNumber int, blockHeight uint64) error
+	AddBalanceChange(change *database.DBBalanceChange) error
+	RemoveBalanceChanges(shardNumber int, height uint64) error
 	GetAccountByAddress(address string) (*database.DBAccount, error)
 	AddAccount(account *database.DBAccount) error
 	UpdateAccount(account *database.DBAccount) error
-	UpdateAccountMinedBlock(address string, mined int64) error
+	RemoveAccount(address string) error
 	GetTxCntByShardNumber(shardNumber int) (uint64, error)
-	GetPendingTxCntByShardNumber(shardNumber int) (uint64, error)
-	GetTxCntByShardNumberAndAddress(shardNumber int, address string) (int64, error)
+	AddAddressTxs(shardNumber int, height int64, txs []*database.DBAddressTx) error
+	RemoveAddressTxs(shardNumber int, height uint64) error
+	AddPendingAddressTxs(txs []*database.DBAddressTx) error
+	RemovePendingAddressTxs(hash string) error
+	GetAddressTxCount(address string) (*database.DBAddressTxCount, error)
 	GetMinedBlocksCntByShardNumberAndAddress(shardNumber int, address string) (int64, error)
+	AddBlockUndo(undo *database.DBBlockUndo) error
+	GetBlockUndo(shardNumber int, height uint64) (*database.DBBlockUndo, error)
+	RemoveBlockUndo(shardNumber int, height uint64) error
+	RemoveBlockUndosBefore(shardNumber int, height uint64) error
+	AddReorg(reorg *database.DBReorg) error
+	AddChartRedo(redo *database.DBChartRedo) error
+	GetSyncCursor(shardNumber int) (*database.DBSyncCursor, error)
+	SetSyncCursor(cursor *database.DBSyncCursor) error
+	ApplyStats(shardNumber int, height int64, delta *database.DBStatsDelta) error
+	RevertStats(shardNumber int, height int64, delta *database.DBStatsDelta) error
+	RecountStats(shardNumber int) error
 }
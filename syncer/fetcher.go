@@ -0,0 +1,259 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+const (
+	defaultFetchConcurrency = 1
+	defaultFetchWindow      = 64
+
+	//maxFetchBatch the max number of blocks requested in a single batch
+	maxFetchBatch = 16
+
+	//maxReceiptBatch the max number of receipts requested in a single batch
+	maxReceiptBatch = 256
+)
+
+var (
+	errFetcherStopped = errors.New("block fetcher stopped")
+)
+
+//fetchResult is a block and the receipts of its txs fetched from seele node
+type fetchResult struct {
+	height   uint64
+	block    *rpc.BlockInfo
+	receipts map[string]*rpc.Receipt
+	err      error
+}
+
+//heightRange is a range of heights fetched in a single batch, both ends included
+type heightRange struct {
+	begin uint64
+	end   uint64
+}
+
+//blockFetcher pull blocks ahead of the committer with several goroutines,
+//the committer takes them out strictly in height order
+type blockFetcher struct {
+	rpc         *rpc.Pool
+	concurrency int
+	batch       uint64
+
+	//window limits the number of blocks fetched but not committed yet
+	window  chan struct{}
+	ranges  chan heightRange
+	results chan *fetchResult
+	quit    chan struct{}
+	wg      sync.WaitGroup
+
+	//pending only accessed by the committer
+	pending map[uint64]*fetchResult
+}
+
+//newBlockFetcher return a fetcher, the window is never smaller than the concurrency,
+//and the batch is never larger than the window
+func newBlockFetcher(rpc *rpc.Pool, concurrency, window int) *blockFetcher {
+	if concurrency <= 0 {
+		concurrency = defaultFetchConcurrency
+	}
+
+	if window < concurrency {
+		window = concurrency
+	}
+
+	batch := maxFetchBatch
+	if batch > window {
+		batch = window
+	}
+
+	return &blockFetcher{
+		rpc:         rpc,
+		concurrency: concurrency,
+		batch:       uint64(batch),
+		window:      make(chan struct{}, window),
+		ranges:      make(chan heightRange),
+		results:     make(chan *fetchResult, window),
+		quit:        make(chan struct{}),
+		pending:     make(map[uint64]*fetchResult),
+	}
+}
+
+//start fetch blocks from height begin to height end (both included)
+func (f *blockFetcher) start(begin, end uint64) {
+	go func() {
+		defer close(f.ranges)
+		for h := begin; h <= end; h += f.batch {
+			r := heightRange{begin: h, end: h + f.batch - 1}
+			if r.end > end {
+				r.end = end
+			}
+
+			for i := r.begin; i <= r.end; i++ {
+				select {
+				case f.window <- struct{}{}:
+				case <-f.quit:
+					return
+				}
+			}
+
+			select {
+			case f.ranges <- r:
+			case <-f.quit:
+				return
+			}
+		}
+	}()
+
+	f.wg.Add(f.concurrency)
+	for i := 0; i < f.concurrency; i++ {
+		go f.fetch()
+	}
+
+	go func() {
+		f.wg.Wait()
+		close(f.results)
+	}()
+}
+
+func (f *blockFetcher) fetch() {
+	defer f.wg.Done()
+	for r := range f.ranges {
+		for _, result := range f.fetchRange(r) {
+			select {
+			case f.results <- result:
+			case <-f.quit:
+				return
+			}
+		}
+	}
+}
+
+//fetchRange get the blocks in the range and their receipts from a single node which has all the blocks,
+//the range is fetched again from the next node if any block fails
+func (f *blockFetcher) fetchRange(r heightRange) []*fetchResult {
+	var results []*fetchResult
+	err := f.rpc.Do(r.end, func(client *rpc.SeeleRPC) error {
+		results = fetchRangeFrom(client, r)
+		for _, result := range results {
+			if result.err != nil {
+				return result.err
+			}
+		}
+		return nil
+	})
+
+	if results == nil {
+		for h := r.begin; h <= r.end; h++ {
+			results = append(results, &fetchResult{height: h, err: err})
+		}
+	}
+	return results
+}
+
+//fetchRangeFrom get the blocks in the range and the receipts of their txs with batch calls,
+//a block fails if the block or any of its receipts could not be fetched
+func fetchRangeFrom(client *rpc.SeeleRPC, r heightRange) []*fetchResult {
+	blocks, err := client.GetBlocksByHeightRange(r.begin, r.end, true)
+	batchErr, _ := err.(*rpc.BatchError)
+
+	results := make([]*fetchResult, 0, r.end-r.begin+1)
+	var txHashes []string
+	for h := r.begin; h <= r.end; h++ {
+		result := &fetchResult{height: h}
+		i := h - r.begin
+		switch {
+		case batchErr != nil && batchErr.Errors[i] != nil:
+			result.err = batchErr.Errors[i]
+		case err != nil && batchErr == nil:
+			result.err = err
+		default:
+			result.block = blocks[i]
+			for _, tx := range result.block.Txs {
+				txHashes = append(txHashes, tx.Hash)
+			}
+		}
+		results = append(results, result)
+	}
+
+	receipts, receiptErrs := fetchReceipts(client, txHashes)
+	for _, result := range results {
+		if result.block == nil {
+			continue
+		}
+
+		result.receipts = make(map[string]*rpc.Receipt, len(result.block.Txs))
+		for _, tx := range result.block.Txs {
+			if err, ok := receiptErrs[tx.Hash]; ok {
+				result.block, result.receipts, result.err = nil, nil, err
+				break
+			}
+			result.receipts[tx.Hash] = receipts[tx.Hash]
+		}
+	}
+
+	return results
+}
+
+//fetchReceipts get the receipts of the txs in batches, the errors are mapped by the tx hash
+func fetchReceipts(client *rpc.SeeleRPC, txHashes []string) (map[string]*rpc.Receipt, map[string]error) {
+	receipts := make(map[string]*rpc.Receipt, len(txHashes))
+	errs := make(map[string]error)
+	for begin := 0; begin < len(txHashes); begin += maxReceiptBatch {
+		end := begin + maxReceiptBatch
+		if end > len(txHashes) {
+			end = len(txHashes)
+		}
+
+		hashes := txHashes[begin:end]
+		batch, err := client.GetReceiptsByTxHashes(hashes)
+		batchErr, _ := err.(*rpc.BatchError)
+		for i, hash := range hashes {
+			switch {
+			case batchErr != nil && batchErr.Errors[i] != nil:
+				errs[hash] = batchErr.Errors[i]
+			case err != nil && batchErr == nil:
+				errs[hash] = err
+			default:
+				receipts[hash] = batch[i]
+			}
+		}
+	}
+	return receipts, errs
+}
+
+//next wait for the block at the given height and the receipts of its txs
+func (f *blockFetcher) next(height uint64) (*rpc.BlockInfo, map[string]*rpc.Receipt, error) {
+	for {
+		if r, ok := f.pending[height]; ok {
+			delete(f.pending, height)
+			return r.block, r.receipts, r.err
+		}
+
+		r, ok := <-f.results
+		if !ok {
+			return nil, nil, errFetcherStopped
+		}
+		f.pending[r.height] = r
+	}
+}
+
+//done tell the fetcher that a block has been committed and the next one could be fetched
+func (f *blockFetcher) done() {
+	<-f.window
+}
+
+//stop stop all the fetch goroutines and wait for them to exit
+func (f *blockFetcher) stop() {
+	close(f.quit)
+	for range f.results {
+	}
+}
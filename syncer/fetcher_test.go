@@ -0,0 +1,148 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/seeleteam/scan-api/simulator"
+)
+
+//newTestPool return a started pool of the node, the calls are not retried
+func newTestPool(t *testing.T, url string) *rpc.Pool {
+	pool, err := rpc.NewPool([]string{url}, rpc.WithClientOptions(rpc.WithRetry(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+//nextBlock take the block at the height out of the fetcher and check it
+func nextBlock(t *testing.T, f *blockFetcher, height uint64) {
+	t.Helper()
+	block, _, err := f.next(height)
+	if err != nil {
+		t.Fatalf("fetch block %d failed, %v", height, err)
+	}
+	if block.Height != height {
+		t.Fatalf("got block %d, expected %d", block.Height, height)
+	}
+}
+
+func TestFetcherInOrder(t *testing.T) {
+	const blocks = 40
+	f := newBlockFetcher(nil, 4, blocks)
+
+	//the results arrive in any order, like the ones of several fetch goroutines
+	go func() {
+		for _, i := range rand.New(rand.NewSource(1)).Perm(blocks) {
+			h := uint64(i)
+			f.results <- &fetchResult{height: h, block: &rpc.BlockInfo{Height: h}}
+		}
+		close(f.results)
+	}()
+
+	for h := uint64(0); h < blocks; h++ {
+		nextBlock(t, f, h)
+	}
+
+	if _, _, err := f.next(blocks); err != errFetcherStopped {
+		t.Fatalf("expected the fetcher stopped, got %v", err)
+	}
+}
+
+func TestFetcherWindow(t *testing.T) {
+	n, _ := generatedNode(20)
+	defer n.Close()
+	pool := newTestPool(t, startNode(t, n))
+	defer pool.Close()
+
+	const window = 4
+	f := newBlockFetcher(pool, 2, window)
+	f.start(0, 19)
+	defer f.stop()
+
+	for h := uint64(0); h < window; h++ {
+		nextBlock(t, f, h)
+	}
+
+	//no block is fetched beyond the window until the blocks in it are committed
+	fetched := make(chan *rpc.BlockInfo, 1)
+	go func() {
+		block, _, _ := f.next(window)
+		fetched <- block
+	}()
+
+	select {
+	case <-fetched:
+		t.Fatal("a block beyond the window is fetched")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if len(f.window) != window {
+		t.Fatalf("%d blocks in the window, expected %d", len(f.window), window)
+	}
+
+	for h := uint64(0); h < window; h++ {
+		f.done()
+	}
+
+	select {
+	case block := <-fetched:
+		if block == nil || block.Height != window {
+			t.Fatalf("bad block %+v, expected %d", block, window)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the next block is not fetched after the window is committed")
+	}
+}
+
+func TestFetcherStopAfterError(t *testing.T) {
+	n, _ := generatedNode(40)
+	defer n.Close()
+	pool := newTestPool(t, startNode(t, n))
+	defer pool.Close()
+
+	//the pool and its connection are up before the goroutines are counted
+	if _, err := pool.CurrentBlock(); err != nil {
+		t.Fatal(err)
+	}
+	goroutines := runtime.NumGoroutine()
+
+	f := newBlockFetcher(pool, 4, 8)
+	f.start(0, 39)
+	for h := uint64(0); h < 8; h++ {
+		nextBlock(t, f, h)
+	}
+
+	//the blocks after the first batch fail, the fetcher is stopped with some blocks in flight
+	n.InjectFault("seele.GetBlockByHeight", simulator.Fault{Error: "block not found"})
+	for h := uint64(0); h < 8; h++ {
+		f.done()
+	}
+
+	if _, _, err := f.next(8); err == nil {
+		t.Fatal("expected the error of block 8")
+	}
+	f.stop()
+
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left after the fetcher stopped, expected %d\n%s",
+				runtime.NumGoroutine(), goroutines, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
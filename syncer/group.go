@@ -0,0 +1,285 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+const (
+	//maxSyncFailures the syncer of a shard is restarted after this many failed syncs in a row
+	maxSyncFailures = 5
+
+	minRestartBackoff = time.Second
+	maxRestartBackoff = 2 * time.Minute
+
+	statusReportInterval = time.Minute
+)
+
+//ShardStatus is the sync status of a single shard
+type ShardStatus struct {
+	ShardNumber int
+	RpcURL      string
+	Running     bool
+	Height      int64 //the last committed block, -1 before any block is committed
+	SyncCnt     int
+	Failures    int
+	Restarts    int
+	LastSync    time.Time
+	LastError   string
+
+	//BalanceMismatches the number of computed balances differing from seele node since the syncer started
+	BalanceMismatches int
+
+	//RPC the connection state of the syncer to the seele nodes, summed over all of them
+	RPC rpc.Stats
+
+	//Endpoints the health, height and latency of every seele node of the shard
+	Endpoints []rpc.EndpointStatus
+}
+
+//groupMember supervise the syncer of a single shard
+type groupMember struct {
+	shard   ShardConfig
+	options []func(s *Syncer)
+
+	lock   sync.RWMutex
+	status ShardStatus
+}
+
+//Group run a syncer for each shard in one process, every shard has its
+//own interval, rpc connection, account cache and worker pool
+type Group struct {
+	db      Database
+	members []*groupMember
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+//NewGroup return a group to sync the given shards, the options are applied to the syncer of each shard
+func NewGroup(db Database, shards []ShardConfig, options ...func(s *Syncer)) (*Group, error) {
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no shard to sync")
+	}
+
+	g := &Group{
+		db:   db,
+		quit: make(chan struct{}),
+	}
+
+	seen := make(map[int]bool)
+	for _, shard := range shards {
+		if seen[shard.ShardNumber] {
+			return nil, fmt.Errorf("shard %d is configured more than once", shard.ShardNumber)
+		}
+		seen[shard.ShardNumber] = true
+
+		g.members = append(g.members, &groupMember{
+			shard:   shard,
+			options: options,
+			status: ShardStatus{
+				ShardNumber: shard.ShardNumber,
+				RpcURL:      shard.RpcURL,
+				Height:      -1,
+			},
+		})
+	}
+
+	return g, nil
+}
+
+//Start start syncing all the shards, it returns immediately
+func (g *Group) Start() {
+	for _, m := range g.members {
+		g.wg.Add(1)
+		go g.supervise(m)
+	}
+
+	g.wg.Add(1)
+	go g.reportStatus()
+}
+
+//Stop stop all the syncers and wait for them to exit
+func (g *Group) Stop() {
+	close(g.quit)
+	g.wg.Wait()
+}
+
+//Wait block until the group is stopped
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
+
+//Status return the sync status of all the shards
+func (g *Group) Status() []ShardStatus {
+	status := make([]ShardStatus, 0, len(g.members))
+	for _, m := range g.members {
+		m.lock.RLock()
+		status = append(status, m.status)
+		m.lock.RUnlock()
+	}
+	return status
+}
+
+//supervise create the syncer of a shard and restart it when it keeps failing or panics
+func (g *Group) supervise(m *groupMember) {
+	defer g.wg.Done()
+
+	options := m.options
+	if m.shard.RecordFile != "" {
+		recorder, err := rpc.CreateRecorder(m.shard.RecordFile)
+		if err != nil {
+			m.setError(fmt.Errorf("can not record rpc to %s, %v", m.shard.RecordFile, err))
+			return
+		}
+		defer recorder.Close()
+
+		log.Info("[SyncGroup]record rpc of shard %d to %s", m.shard.ShardNumber, m.shard.RecordFile)
+		options = append(options[:len(options):len(options)], WithRPCOptions(rpc.WithRecorder(recorder)))
+	}
+
+	backoff := minRestartBackoff
+	for {
+		s := NewSyncer(g.db, m.shard.Endpoints(), m.shard.ShardNumber, options...)
+		if s == nil {
+			m.setError(fmt.Errorf("can not connect to node %s", strings.Join(m.shard.Endpoints(), ",")))
+		} else {
+			m.update(func(status *ShardStatus) { status.Running = true })
+			if g.run(m, s) {
+				// reset the backoff if the syncer has worked for a while
+				backoff = minRestartBackoff
+			}
+			s.close()
+			m.update(func(status *ShardStatus) { status.Running = false })
+		}
+
+		select {
+		case <-g.quit:
+			return
+		default:
+		}
+
+		log.Warn("[SyncGroup]restart syncer of shard %d in %v", m.shard.ShardNumber, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-g.quit:
+			return
+		}
+
+		m.update(func(status *ShardStatus) { status.Restarts++ })
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
+//run sync the shard whenever a new block arrives, or at least at its interval, until the group
+//is stopped or the syncer need to be restarted, it returns whether any sync succeeded
+func (g *Group) run(m *groupMember, s *Syncer) (succeeded bool) {
+	w := newHeadWatcher(m.shard.ShardNumber, s.rpc, m.shard.SyncInterval*time.Second)
+	defer w.close()
+
+	failures := 0
+	for {
+		if err := g.syncOnce(s); err != nil {
+			failures++
+			m.setError(err)
+			m.update(func(status *ShardStatus) {
+				status.Failures = failures
+				status.RPC = s.rpc.Stats()
+				status.Endpoints = s.rpc.Status()
+			})
+			if _, panicked := err.(*syncPanic); panicked || failures >= maxSyncFailures {
+				return succeeded
+			}
+		} else {
+			failures = 0
+			succeeded = true
+			m.update(func(status *ShardStatus) {
+				status.Height = s.cursor.Height
+				status.SyncCnt = s.syncCnt
+				status.BalanceMismatches = s.balanceMismatches
+				status.RPC = s.rpc.Stats()
+				status.Endpoints = s.rpc.Status()
+				status.Failures = 0
+				status.LastSync = time.Now()
+				status.LastError = ""
+			})
+		}
+
+		if !w.wait(s.waitHeight(), g.quit) {
+			return succeeded
+		}
+	}
+}
+
+//syncPanic is the error of a sync which panicked
+type syncPanic struct {
+	value interface{}
+}
+
+func (p *syncPanic) Error() string {
+	return fmt.Sprintf("sync panic: %v", p.value)
+}
+
+//syncOnce run a single sync, a panic is turned into an error so that other shards keep running
+func (g *Group) syncOnce(s *Syncer) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &syncPanic{value: r}
+		}
+	}()
+
+	return s.sync()
+}
+
+//reportStatus log the combined status of all the shards periodically
+func (g *Group) reportStatus() {
+	defer g.wg.Done()
+
+	ticker := time.NewTicker(statusReportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-g.quit:
+			return
+		}
+
+		for _, status := range g.Status() {
+			log.Info("[SyncGroup]shard %d running:%v height:%d syncCnt:%d failures:%d restarts:%d lastSync:%v lastError:%s balanceMismatches:%d "+
+				"rpcConnected:%v rpcConnects:%d rpcCalls:%d rpcFailures:%d rpcRetries:%d rpcTimeouts:%d",
+				status.ShardNumber, status.Running, status.Height, status.SyncCnt, status.Failures,
+				status.Restarts, status.LastSync.Format(time.RFC3339), status.LastError, status.BalanceMismatches,
+				status.RPC.Connected, status.RPC.Connects, status.RPC.Calls, status.RPC.Failures, status.RPC.Retries, status.RPC.Timeouts)
+
+			for _, endpoint := range status.Endpoints {
+				log.Info("[SyncGroup]shard %d endpoint %s healthy:%v height:%d latency:%v lastCheck:%v lastError:%s",
+					status.ShardNumber, endpoint.URL, endpoint.Healthy, endpoint.Height, endpoint.Latency,
+					endpoint.LastCheck.Format(time.RFC3339), endpoint.LastError)
+			}
+		}
+	}
+}
+
+func (m *groupMember) update(fn func(status *ShardStatus)) {
+	m.lock.Lock()
+	fn(&m.status)
+	m.lock.Unlock()
+}
+
+func (m *groupMember) setError(err error) {
+	log.Error("[SyncGroup]shard %d: %v", m.shard.ShardNumber, err)
+	m.update(func(status *ShardStatus) { status.LastError = err.Error() })
+}
@@ -0,0 +1,70 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/database/memory"
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+//panickingStore panic the first panics times the cursor is loaded
+type panickingStore struct {
+	*memory.Store
+	panics int32
+}
+
+func (s *panickingStore) GetSyncCursor(shardNumber int) (*database.DBSyncCursor, error) {
+	if atomic.AddInt32(&s.panics, -1) >= 0 {
+		panic("cursor is broken")
+	}
+	return s.Store.GetSyncCursor(shardNumber)
+}
+
+func TestGroupRestart(t *testing.T) {
+	n, _ := generatedNode(10)
+	defer n.Close()
+	url := startNode(t, n)
+
+	db := &panickingStore{Store: memory.NewStore(), panics: 1}
+	g, err := NewGroup(db, []ShardConfig{{ShardNumber: 1, RpcURL: url, SyncInterval: 1}}, WithRPCOptions(rpc.WithRetry(0, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := g.Status()[0]; status.Height != -1 || status.Running {
+		t.Fatalf("bad status before start %+v", status)
+	}
+
+	start := time.Now()
+	g.Start()
+	defer g.Stop()
+
+	//the panicked syncer is replaced by a new one after the backoff, which syncs the whole chain
+	var status ShardStatus
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		status = g.Status()[0]
+		if status.SyncCnt > 0 || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	if status.Restarts != 1 || !status.Running || status.Failures != 0 || status.LastError != "" {
+		t.Fatalf("bad status after restart %+v", status)
+	}
+
+	if status.Height != int64(n.Height()) {
+		t.Fatalf("bad height %d of the status, expected %d", status.Height, n.Height())
+	}
+
+	if elapsed := time.Since(start); elapsed < minRestartBackoff {
+		t.Fatalf("restarted after %v, shorter than the backoff %v", elapsed, minRestartBackoff)
+	}
+}
@@ -0,0 +1,84 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"math/big"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+//blockJournal collect the changes a block makes to the accounts
+type blockJournal struct {
+	undo      *database.DBBlockUndo
+	accounts  map[string]*database.DBAccountUndo
+	timestamp int64
+}
+
+//newBlockJournal return an empty journal of the block, txIdx is the idx of the last tx before the block
+func newBlockJournal(shardNumber int, b *rpc.BlockInfo, txIdx int64) *blockJournal {
+	return &blockJournal{
+		undo: &database.DBBlockUndo{
+			ShardNumber: shardNumber,
+			Height:      int64(b.Height),
+			HeadHash:    b.Hash,
+			PreHash:     b.ParentHash,
+			TxIdx:       txIdx,
+		},
+		accounts:  make(map[string]*database.DBAccountUndo),
+		timestamp: b.Timestamp.Int64(),
+	}
+}
+
+//account return the journal entry of the account
+func (j *blockJournal) account(address string) *database.DBAccountUndo {
+	u, ok := j.accounts[address]
+	if !ok {
+		u = &database.DBAccountUndo{Address: address}
+		j.accounts[address] = u
+	}
+	return u
+}
+
+//transfer move the amount from an account to another, the null address
+//stands for the coins minted by the block or burned
+func (j *blockJournal) transfer(from, to string, amount *big.Int) {
+	if amount == nil || amount.Sign() == 0 {
+		return
+	}
+
+	if from != nullAddress && from != "" {
+		u := j.account(from)
+		u.Balance.Sub(&u.Balance.Int, amount)
+	}
+
+	if to != nullAddress && to != "" {
+		u := j.account(to)
+		u.Balance.Add(&u.Balance.Int, amount)
+	}
+}
+
+//balanceChange return the balance change record of the account after the block is applied
+func (j *blockJournal) balanceChange(account *database.DBAccount) *database.DBBalanceChange {
+	return &database.DBBalanceChange{
+		ShardNumber: j.undo.ShardNumber,
+		Address:     account.Address,
+		Height:      j.undo.Height,
+		Timestamp:   j.timestamp,
+		Balance:     database.NewBigInt(&account.Balance.Int),
+		Delta:       database.NewBigInt(&j.accounts[account.Address].Balance.Int),
+	}
+}
+
+//blockUndo return the undo journal in the database format
+func (j *blockJournal) blockUndo() *database.DBBlockUndo {
+	j.undo.Accounts = make([]database.DBAccountUndo, 0, len(j.accounts))
+	for _, u := range j.accounts {
+		j.undo.Accounts = append(j.undo.Accounts, *u)
+	}
+	return j.undo
+}
@@ -0,0 +1,86 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"math/big"
+
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+const (
+	//reconcileInterval the number of sync rounds between two balance reconciliations
+	reconcileInterval = 10
+
+	//reconcileSampleSize the max number of accounts compared in a reconciliation
+	reconcileSampleSize = 20
+)
+
+//reconcileBalances compare the computed balances of some recently updated accounts with
+//the ones in seele node. A mismatch is logged and counted, the computed balance is kept.
+//The comparison is dropped if seele node moves on during the reconciliation
+func (s *Syncer) reconcileBalances(height uint64) {
+	addresses := make([]string, 0, reconcileSampleSize)
+	for address := range s.touchedAccount {
+		if len(addresses) >= reconcileSampleSize {
+			break
+		}
+		addresses = append(addresses, address)
+	}
+
+	if len(addresses) == 0 {
+		return
+	}
+
+	//the balances and the height are taken from the same node
+	var balances []*big.Int
+	var nodeHeight uint64
+	err := s.rpc.Do(height, func(client *rpc.SeeleRPC) error {
+		var err error
+		if balances, err = client.GetBalances(addresses); err != nil {
+			return err
+		}
+
+		curBlock, err := client.CurrentBlock()
+		if err != nil {
+			return err
+		}
+		nodeHeight = curBlock.Height
+		return nil
+	})
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	//the balances read at another height are not comparable with the computed ones
+	if nodeHeight != height {
+		log.Info("[BlockSync shard:%d syncCnt:%d]Drop the balance reconciliation, height moved from %d to %d",
+			s.shardNumber, s.syncCnt, height, nodeHeight)
+		return
+	}
+
+	var mismatches []string
+	for i, address := range addresses {
+		account, ok := s.cacheAccount[address]
+		if !ok {
+			continue
+		}
+
+		balance := balances[i]
+		if balance.Cmp(&account.Balance.Int) != 0 {
+			mismatches = append(mismatches, address)
+			log.Warn("[BlockSync shard:%d syncCnt:%d]Balance mismatch of %s at height %d, computed %s, node %s",
+				s.shardNumber, s.syncCnt, address, height, account.Balance.String(), balance.String())
+		}
+	}
+
+	s.touchedAccount = make(map[string]bool)
+	s.balanceMismatches += len(mismatches)
+	log.Info("[BlockSync shard:%d syncCnt:%d]Reconciled %d account balances, %d mismatches, %d in total",
+		s.shardNumber, s.syncCnt, len(addresses), len(mismatches), s.balanceMismatches)
+}
@@ -0,0 +1,316 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+const (
+	maxReorgDepth = database.MaxReorgDepth
+)
+
+var (
+	errReorgTooDeep     = errors.New("chain reorganization is deeper than the max reorg depth")
+	errNoCommonAncestor = errors.New("could not find the common ancestor with seele node")
+)
+
+//checkReorg compare the stored chain with seele node, if it has been reorganized,
+//roll back to the common ancestor, the new branch is replayed by the following sync.
+//All the blocks are got from the client of the node which reported nodeHeight
+func (s *Syncer) checkReorg(client *rpc.SeeleRPC, nodeHeight uint64) error {
+	if s.cursor.Height < 0 {
+		return nil
+	}
+
+	tip, err := s.db.GetBlockByHeight(s.shardNumber, uint64(s.cursor.Height))
+	if err != nil {
+		return err
+	}
+
+	//seele node is behind us, wait for it to catch up
+	if uint64(tip.Height) > nodeHeight {
+		log.Warn("[Reorg] node height %d is lower than stored height %d", nodeHeight, tip.Height)
+		return nil
+	}
+
+	ancestor, err := s.findForkPoint(client, tip)
+	if err != nil {
+		return err
+	}
+
+	if ancestor.HeadHash == tip.HeadHash {
+		return nil
+	}
+
+	reorg := &database.DBReorg{
+		ShardNumber: s.shardNumber,
+		Depth:       tip.Height - ancestor.Height,
+		ForkHeight:  ancestor.Height,
+		ForkHash:    ancestor.HeadHash,
+		Timestamp:   time.Now().Unix(),
+	}
+	log.Warn("[Reorg] shard %d reorganized, fork height %d, depth %d", s.shardNumber, reorg.ForkHeight, reorg.Depth)
+
+	oldestTimestamp := tip.Timestamp
+	for h := tip.Height; h > ancestor.Height; h-- {
+		dbBlock, err := s.db.GetBlockByHeight(s.shardNumber, uint64(h))
+		if err != nil {
+			return err
+		}
+
+		if err := s.rollbackBlock(dbBlock); err != nil {
+			return err
+		}
+
+		reorg.OldHashes = append([]string{dbBlock.HeadHash}, reorg.OldHashes...)
+		oldestTimestamp = dbBlock.Timestamp
+	}
+
+	for h := ancestor.Height + 1; h <= tip.Height; h++ {
+		rpcBlock, err := client.GetBlockByHeight(uint64(h), false)
+		if err != nil {
+			log.Error(err)
+			break
+		}
+		reorg.NewHashes = append(reorg.NewHashes, rpcBlock.Hash)
+	}
+
+	//the one day charts containing the orphaned blocks are counted again by the chart service
+	//after the new branch is synced
+	day := time.Unix(oldestTimestamp, 0)
+	zeroTime := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	redo := &database.DBChartRedo{
+		ShardNumber: s.shardNumber,
+		ZeroTime:    zeroTime.Unix(),
+		Height:      int64(nodeHeight),
+	}
+	if err := s.db.AddChartRedo(redo); err != nil {
+		log.Error(err)
+	}
+
+	return s.db.AddReorg(reorg)
+}
+
+//findForkPoint follow the parent hash of the stored blocks until a block is the same as the one in seele node
+func (s *Syncer) findForkPoint(client *rpc.SeeleRPC, tip *database.DBBlock) (*database.DBBlock, error) {
+	dbBlock := tip
+	for depth := 0; depth <= maxReorgDepth; depth++ {
+		rpcBlock, err := client.GetBlockByHeight(uint64(dbBlock.Height), false)
+		if err != nil {
+			return nil, err
+		}
+
+		if rpcBlock.Hash == dbBlock.HeadHash {
+			return dbBlock, nil
+		}
+
+		if dbBlock.Height == 0 {
+			return nil, errNoCommonAncestor
+		}
+
+		dbBlock, err = s.db.GetBlockByHash(dbBlock.PreHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errReorgTooDeep
+}
+
+//rollbackBlock remove the block and its txs, and revert the changes it made to the accounts.
+//The cursor is moved back right after the block is removed, and every step can be
+//done again safely if the process dies in the middle
+func (s *Syncer) rollbackBlock(dbBlock *database.DBBlock) error {
+	height := uint64(dbBlock.Height)
+	txs, err := s.db.GetTxsByBlock(s.shardNumber, height)
+	if err != nil {
+		return err
+	}
+
+	undo, err := s.db.GetBlockUndo(s.shardNumber, height)
+	hasUndo := err == nil && undo.HeadHash == dbBlock.HeadHash
+	if hasUndo && undo.Stats != nil {
+		if err := s.db.RevertStats(s.shardNumber, undo.Height, undo.Stats); err != nil {
+			return err
+		}
+	}
+
+	if hasUndo {
+		if err := s.revertAccounts(undo); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.RemoveAddressTxs(s.shardNumber, height); err != nil {
+		return err
+	}
+
+	if err := s.db.RemoveTxs(s.shardNumber, height); err != nil {
+		return err
+	}
+
+	if err := s.db.RemoveReceipts(s.shardNumber, height); err != nil {
+		return err
+	}
+
+	if err := s.db.RemoveBalanceChanges(s.shardNumber, height); err != nil {
+		return err
+	}
+
+	if err := s.db.RemoveBlock(s.shardNumber, height); err != nil {
+		return err
+	}
+
+	if !hasUndo {
+		//the block was synced before the undo journal existed
+		s.recountAccounts(dbBlock)
+	}
+
+	if !hasUndo || undo.Stats == nil {
+		//the block was synced before the stats existed
+		if err := s.db.RecountStats(s.shardNumber); err != nil {
+			return err
+		}
+	}
+
+	txIdx := s.cursor.TxIdx
+	if hasUndo {
+		txIdx = undo.TxIdx
+	} else if len(txs) > 0 {
+		txIdx = txs[0].Idx - 1
+	}
+
+	if err := s.setCursor(dbBlock.Height-1, dbBlock.PreHash, txIdx); err != nil {
+		return err
+	}
+
+	if err := s.db.RemoveBlockUndo(s.shardNumber, height); err != nil {
+		return err
+	}
+
+	//txs in the orphaned block go back to the tx pool
+	for _, tx := range txs {
+		if tx.From == nullAddress {
+			continue
+		}
+
+		tx.Pending = true
+		tx.Failed = false
+		tx.PoolState = database.PoolStatePending
+		tx.LeftPoolTime = 0
+		tx.InclusionTime = 0
+		if tx.FirstSeen == 0 {
+			tx.FirstSeen = time.Now().Unix()
+		}
+		if err := s.db.AddPendingTx(tx); err != nil {
+			log.Error(err)
+			continue
+		}
+
+		if err := s.db.AddPendingAddressTxs(database.CreateDbAddressTxs(tx, "")); err != nil {
+			log.Error(err)
+		}
+	}
+
+	return nil
+}
+
+//revertAccounts apply the undo journal of a block to the accounts and write them into database,
+//an account which has already been reverted is left unchanged
+func (s *Syncer) revertAccounts(undo *database.DBBlockUndo) error {
+	for _, u := range undo.Accounts {
+		account := s.getAccountFromDBOrCache(u.Address)
+		if account.SyncHeight < undo.Height {
+			if account.SyncHeight < 0 {
+				//the account is not in database
+				s.forgetAccount(u.Address)
+			}
+			continue
+		}
+
+		account.TxCount -= u.TxCount
+		account.Mined -= u.Mined
+		account.Balance.Sub(&account.Balance.Int, &u.Balance.Int)
+		account.SyncHeight = undo.Height - 1
+
+		if u.Created || (u.CreatedContract && account.TxCount <= 0) {
+			s.forgetAccount(u.Address)
+			if err := s.db.RemoveAccount(u.Address); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if u.CreatedContract {
+			account.AccType = 0
+		}
+
+		if err := s.db.UpdateAccount(account); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//recountAccounts count the txs and mined blocks of the accounts in the block from the database.
+//There is no balance delta to revert, so the balances are taken from seele node
+func (s *Syncer) recountAccounts(dbBlock *database.DBBlock) {
+	addresses := make(map[string]bool)
+	for _, tx := range dbBlock.Txs {
+		addresses[tx.From] = true
+		addresses[tx.To] = true
+	}
+	addresses[dbBlock.Creator] = true
+	delete(addresses, nullAddress)
+	delete(addresses, "")
+
+	list := make([]string, 0, len(addresses))
+	for address := range addresses {
+		list = append(list, address)
+	}
+
+	balances, balanceErr := s.rpc.GetBalances(list)
+	batchErr, _ := balanceErr.(*rpc.BatchError)
+	for i, address := range list {
+		account := s.getAccountFromDBOrCache(address)
+		txCnt, err := s.db.GetAddressTxCount(address)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		account.TxCount = txCnt.Txs
+		account.SyncHeight = dbBlock.Height - 1
+
+		if address == dbBlock.Creator {
+			blockCnt, err := s.db.GetMinedBlocksCntByShardNumberAndAddress(s.shardNumber, address)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+			account.Mined = blockCnt
+		}
+
+		switch {
+		case batchErr != nil && batchErr.Errors[i] != nil:
+			log.Error("[BlockSync shard:%d syncCnt:%d]Keep the balance of %s, %v", s.shardNumber, s.syncCnt, address, batchErr.Errors[i])
+		case balanceErr != nil && batchErr == nil:
+			log.Error("[BlockSync shard:%d syncCnt:%d]Keep the balance of %s, %v", s.shardNumber, s.syncCnt, address, balanceErr)
+		default:
+			account.Balance = database.NewBigInt(balances[i])
+		}
+
+		if err := s.db.UpdateAccount(account); err != nil {
+			log.Error(err)
+		}
+	}
+}
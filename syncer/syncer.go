@@ -1,6 +1,7 @@
 package syncer
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gammazero/workerpool"
@@ -13,222 +14,256 @@ import (
 
 const (
 	maxInsertConn = 200
+
+	throughputReportInterval = 10 * time.Second
+
+	//poolTxRetention how long the mined and dropped txs stay in the pending collection
+	poolTxRetention = 24 * time.Hour
+
+	//maxRefetch how many times a sync fetches the blocks again after they turn out to be on another branch
+	maxRefetch = 3
+)
+
+var (
+	errParentMismatch = errors.New("block is not a child of the committed tip")
 )
 
 //Syncer
 type Syncer struct {
-	rpc         *rpc.SeeleRPC
+	rpc         *rpc.Pool
 	db          Database
 	shardNumber int
 	syncCnt     int
 	workerpool  *workerpool.WorkerPool
 
-	cacheAccount  map[string]*database.DBAccount
-	updateAccount map[string]*database.DBAccount
+	fetchConcurrency int
+	fetchWindow      int
+	rpcOptions       []func(rpc *rpc.SeeleRPC)
+
+	//cursor the last committed block, loaded when the first sync begins
+	cursor *database.DBSyncCursor
+
+	cacheAccount map[string]*database.DBAccount
+
+	//touchedAccount the accounts updated since the last balance reconciliation
+	touchedAccount    map[string]bool
+	balanceMismatches int
 }
 
-//NewSyncer return a syncer to sync block data from seele node
-func NewSyncer(db Database, rpcConnUrl string, shardNumber int) *Syncer {
-	rpc := rpc.NewRPC(rpcConnUrl)
-	if rpc == nil {
-		return nil
+//WithFetchConcurrency set the number of goroutines fetching blocks ahead of the committer
+func WithFetchConcurrency(concurrency int) func(s *Syncer) {
+	return func(s *Syncer) {
+		if concurrency > 0 {
+			s.fetchConcurrency = concurrency
+		}
 	}
+}
 
-	if err := rpc.Connect(); err != nil {
-		fmt.Printf("rpc init failed, connurl:%v\n", rpcConnUrl)
-		return nil
+//WithFetchWindow set the max number of blocks fetched but not committed yet
+func WithFetchWindow(window int) func(s *Syncer) {
+	return func(s *Syncer) {
+		if window > 0 {
+			s.fetchWindow = window
+		}
 	}
+}
 
-	return &Syncer{
-		db:            db,
-		rpc:           rpc,
-		shardNumber:   shardNumber,
-		syncCnt:       0,
-		cacheAccount:  make(map[string]*database.DBAccount),
-		updateAccount: make(map[string]*database.DBAccount),
-		workerpool:    workerpool.New(maxInsertConn),
+//WithRPCOptions set the options of the clients connecting to the seele nodes
+func WithRPCOptions(options ...func(rpc *rpc.SeeleRPC)) func(s *Syncer) {
+	return func(s *Syncer) {
+		s.rpcOptions = append(s.rpcOptions, options...)
 	}
 }
 
-//Blocks that are already in storage may be modified
-func (s *Syncer) checkOlderBlocks() bool {
-	dbBlockHeight, err := s.db.GetBlockHeight(s.shardNumber)
+//NewSyncer return a syncer to sync block data from the seele nodes of the shard
+func NewSyncer(db Database, rpcURLs []string, shardNumber int, options ...func(s *Syncer)) *Syncer {
+	s := &Syncer{
+		db:               db,
+		shardNumber:      shardNumber,
+		syncCnt:          0,
+		cacheAccount:     make(map[string]*database.DBAccount),
+		touchedAccount:   make(map[string]bool),
+		workerpool:       workerpool.New(maxInsertConn),
+		fetchConcurrency: defaultFetchConcurrency,
+		fetchWindow:      defaultFetchWindow,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	pool, err := rpc.NewPool(rpcURLs, rpc.WithClientOptions(s.rpcOptions...))
 	if err != nil {
-		log.Error(err)
-		return false
+		fmt.Printf("rpc init failed, %v\n", err)
+		s.workerpool.Stop()
+		return nil
 	}
 
-	if dbBlockHeight == 0 {
-		return false
+	if err := pool.Start(); err != nil {
+		fmt.Printf("rpc init failed, connurl:%v\n", rpcURLs)
+		pool.Close()
+		s.workerpool.Stop()
+		return nil
 	}
 
-	fallBack := false
-	for i := dbBlockHeight - 1; i >= 0; i-- {
-		rpcBlock, err := s.rpc.GetBlockByHeight(i, true)
-		if err != nil {
-			return fallBack
-		}
+	s.rpc = pool
+	return s
+}
 
-		dbBlock, err := s.db.GetBlockByHeight(s.shardNumber, i)
-		if err != nil {
-			return fallBack
-		}
+//sync get block data from seele node and store it in the mongodb
+func (s *Syncer) sync() error {
+	log.Info("[BlockSync shard:%d syncCnt:%d]Begin Sync", s.shardNumber, s.syncCnt)
 
-		//if the block data is different wo should sync the data again
-		if dbBlock.HeadHash == rpcBlock.Hash {
-			return fallBack
-		}
+	if err := s.loadCursor(); err != nil {
+		log.Error(err)
+		return err
+	}
 
-		//Delete dbBlock
-		s.db.RemoveBlock(i)
-
-		//Delete txs
-		s.db.RemoveTxs(i)
-
-		//Modify accounts
-		for j := 0; j < len(dbBlock.Txs); j++ {
-			tx := dbBlock.Txs[j]
-
-			toAccount, err := s.db.GetAccountByAddress(tx.To)
-			if err != nil {
-				log.Error(err)
-				return fallBack
-			}
-
-			toAccount.Balance, err = s.rpc.GetBalance(tx.To)
-			if err != nil {
-				log.Error(err)
-				toAccount.Balance = 0
-			}
-
-			txCnt, err := s.db.GetTxCntByShardNumberAndAddress(s.shardNumber, tx.From)
-			if err != nil {
-				log.Error(err)
-				txCnt = 0
-			}
-
-			toAccount.TxCount = int64(txCnt)
-			s.db.UpdateAccount(toAccount)
-
-			if tx.From != nullAddress {
-				fromAccount, err := s.db.GetAccountByAddress(tx.From)
-
-				fromAccount.Balance, err = s.rpc.GetBalance(tx.From)
-				if err != nil {
-					log.Error(err)
-					fromAccount.Balance = 0
-				}
-
-				txCnt, err := s.db.GetTxCntByShardNumberAndAddress(s.shardNumber, tx.From)
-				if err != nil {
-					log.Error(err)
-					txCnt = 0
-				}
-
-				fromAccount.TxCount = int64(txCnt)
-				s.db.UpdateAccount(fromAccount)
-			}
+	nodeHeight, err := s.checkHead()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	//the fetched blocks which are not on the branch of the committed ones are dropped,
+	//then the chain is checked again from the committed tip and they are fetched again
+	for refetch := 0; s.nextHeight() <= nodeHeight; refetch++ {
+		if !s.syncBlocks(s.nextHeight(), nodeHeight) || refetch >= maxRefetch {
+			break
 		}
 
-		if dbBlock.Creator != nullAddress {
-			minerAccount, err := s.db.GetAccountByAddress(dbBlock.Creator)
-			if err != nil {
-				minerAccount = database.CreateEmptyAccount(dbBlock.Creator, s.shardNumber)
-				err := s.db.AddAccount(minerAccount)
-				if err != nil {
-					log.Error("[DB] err : %v", err)
-				} else {
-					minerAccount, err = s.db.GetAccountByAddress(dbBlock.Creator)
-					if err != nil {
-						log.Error("[DB] err : %v", err)
-					}
-				}
-			}
-
-			blockCnt, err := s.db.GetMinedBlocksCntByShardNumberAndAddress(s.shardNumber, dbBlock.Creator)
-			if err != nil {
-				log.Error(err)
-				blockCnt = 0
-			}
-
-			s.db.UpdateAccountMinedBlock(dbBlock.Creator, blockCnt)
+		if nodeHeight, err = s.checkHead(); err != nil {
+			log.Error(err)
+			return err
 		}
+	}
+
+	if s.syncCnt%reconcileInterval == 0 && s.nextHeight() > nodeHeight {
+		s.reconcileBalances(nodeHeight)
+	}
 
-		fallBack = true
+	err = s.pendingTxsSync()
+	if err != nil {
+		log.Error(err)
 	}
+	log.Info("[BlockSync shard:%d syncCnt:%d]End Sync", s.shardNumber, s.syncCnt)
+	s.syncCnt++
+	return nil
+}
 
-	return fallBack
+//checkHead get the height of seele node and roll back the committed blocks which are not on its chain
+func (s *Syncer) checkHead() (uint64, error) {
+	var nodeHeight uint64
+	err := s.rpc.DoWithHead(func(client *rpc.SeeleRPC, head *rpc.CurrentBlock) error {
+		nodeHeight = head.Height
+		//a failed rollback is not the fault of the endpoint, so it does not move to the next one
+		if err := s.checkReorg(client, head.Height); err != nil {
+			log.Error(err)
+		}
+		return nil
+	})
+	return nodeHeight, err
 }
 
-//sync get block data from seele node and store it in the mongodb
-func (s *Syncer) sync() error {
-	log.Info("[BlockSync syncCnt:%d]Begin Sync", s.syncCnt)
+//commitBlock store the block, its transactions with their receipts and the accounts touched by it,
+//then advance the cursor. All the writes are idempotent, so a block which was
+//partly written before a crash is simply written again
+func (s *Syncer) commitBlock(block *rpc.BlockInfo, receipts map[string]*rpc.Receipt) error {
+	//the block is on another branch if the chain reorganized while it was fetched,
+	//or if the fetched ranges were served by nodes on different forks
+	if s.cursor.Height >= 0 && block.ParentHash != s.cursor.HeadHash {
+		return errParentMismatch
+	}
 
-	s.checkOlderBlocks()
+	if err := s.blockSync(block); err != nil {
+		return err
+	}
 
-	curBlock, err := s.rpc.CurrentBlock()
-	if err != nil {
-		log.Error(err)
+	if err := s.txSync(block, receipts); err != nil {
 		return err
 	}
 
-	dbBlockHeight, err := s.db.GetBlockHeight(s.shardNumber)
-	if err != nil {
-		log.Error(err)
+	if err := s.accountSync(block, receipts); err != nil {
 		return err
 	}
 
-	for i := dbBlockHeight; i <= curBlock.Height; i++ {
-		rpcBlock, err := s.rpc.GetBlockByHeight(i, true)
-		if err != nil {
-			s.rpc.Release()
+	if err := s.advanceCursor(block); err != nil {
+		return err
+	}
+
+	//a reorganization never rolls back deeper than maxReorgDepth, so the older undo journals are not needed
+	if block.Height > maxReorgDepth {
+		if err := s.db.RemoveBlockUndosBefore(s.shardNumber, block.Height-maxReorgDepth); err != nil {
 			log.Error(err)
-			break
 		}
+	}
+	return nil
+}
 
-		err = s.blockSync(rpcBlock)
+//syncBlocks fetch blocks from height begin to end with the pipelined fetcher and commit them in height order.
+//It returns true if it stops at a block which is not a child of the committed tip
+func (s *Syncer) syncBlocks(begin, end uint64) bool {
+	fetcher := newBlockFetcher(s.rpc, s.fetchConcurrency, s.fetchWindow)
+	fetcher.start(begin, end)
+
+	var blockCnt, txCnt int
+	start := time.Now()
+	lastReport := start
+	fetchFailed := false
+	forked := false
+	for i := begin; i <= end; i++ {
+		rpcBlock, receipts, err := fetcher.next(i)
 		if err != nil {
 			log.Error(err)
+			fetchFailed = true
 			break
 		}
 
-		err = s.txSync(rpcBlock)
-		if err != nil {
-			log.Error(err)
+		err = s.commitBlock(rpcBlock, receipts)
+		if err == errParentMismatch {
+			log.Warn("[BlockSync shard:%d syncCnt:%d]Parent %s of block %d is not the committed tip %s",
+				s.shardNumber, s.syncCnt, rpcBlock.ParentHash, i, s.cursor.HeadHash)
+			forked = true
 			break
 		}
-
-		err = s.accountSync(rpcBlock)
 		if err != nil {
 			log.Error(err)
 			break
 		}
+		fetcher.done()
+
+		blockCnt++
+		txCnt += len(rpcBlock.Txs)
+		if time.Since(lastReport) >= throughputReportInterval {
+			s.reportThroughput(blockCnt, txCnt, time.Since(start), i, end)
+			lastReport = time.Now()
+		}
 	}
 
-	s.accountUpdateSync()
+	fetcher.stop()
+	if fetchFailed {
+		s.rpc.Check()
+	}
 
-	err = s.pendingTxsSync()
-	if err != nil {
-		log.Error(err)
+	if blockCnt > 0 {
+		s.reportThroughput(blockCnt, txCnt, time.Since(start), begin+uint64(blockCnt)-1, end)
 	}
-	log.Info("[BlockSync syncCnt:%d]End Sync", s.syncCnt)
-	s.syncCnt++
-	return nil
+	return forked
 }
 
-//StartSync start an timer to sync block data from seele node
-func (s *Syncer) StartSync(interval time.Duration) {
-	s.sync()
-
-	ticks := time.NewTicker(interval * time.Second)
-	tick := ticks.C
-	go func() {
-		for range tick {
-			s.sync()
-			_, ok := <-tick
-			if !ok {
-				break
-			}
-		}
-	}()
+//reportThroughput log the catch-up speed
+func (s *Syncer) reportThroughput(blockCnt, txCnt int, elapsed time.Duration, height, target uint64) {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+
+	log.Info("[BlockSync shard:%d syncCnt:%d]Committed %d blocks and %d txs in %v, %.2f blocks/s, %.2f txs/s, height %d/%d",
+		s.shardNumber, s.syncCnt, blockCnt, txCnt, elapsed, float64(blockCnt)/seconds, float64(txCnt)/seconds, height, target)
+}
+
+//close release the rpc connections and stop the worker pool of the syncer
+func (s *Syncer) close() {
+	s.workerpool.Stop()
+	s.rpc.Close()
 }
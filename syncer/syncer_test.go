@@ -0,0 +1,401 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/database/memory"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/seeleteam/scan-api/simulator"
+)
+
+const genesisTime = 1537330000
+
+func init() {
+	//the syncer logs every block, keep the test output readable
+	log.NewLogger("", "error", false)
+	log.GetLogger().Out = ioutil.Discard
+}
+
+//startNode serve the node on a local tcp port and return its url
+func startNode(t *testing.T, n *simulator.Node) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go n.Serve(l)
+	return l.Addr().String()
+}
+
+func generatedNode(blocks int) (*simulator.Node, []string) {
+	accounts := simulator.Accounts(1, 6)
+	chain := simulator.NewChain(1, genesisTime)
+	chain.Generate(rand.New(rand.NewSource(1)), blocks, 5, accounts, 10)
+	return simulator.NewNode(chain), accounts
+}
+
+//newTestSyncer return a syncer of shard 1, the rpc calls are not retried so that the injected faults hit the sync
+func newTestSyncer(t *testing.T, db Database, url string, options ...func(s *Syncer)) *Syncer {
+	options = append(options, WithRPCOptions(rpc.WithRetry(0, 0)))
+	s := NewSyncer(db, []string{url}, 1, options...)
+	if s == nil {
+		t.Fatal("could not create the syncer")
+	}
+	return s
+}
+
+//syncTo run a sync and check the cursor reaches the height
+func syncTo(t *testing.T, s *Syncer, height uint64) {
+	t.Helper()
+	if err := s.sync(); err != nil {
+		t.Fatal(err)
+	}
+	if s.cursor.Height != int64(height) {
+		t.Fatalf("synced to %d, expected %d", s.cursor.Height, height)
+	}
+}
+
+//resync sync the chain of the node into an empty store
+func resync(t *testing.T, url string, height uint64) *memory.Store {
+	t.Helper()
+	db := memory.NewStore()
+	s := newTestSyncer(t, db, url)
+	defer s.close()
+
+	syncTo(t, s, height)
+	return db
+}
+
+//fetchBlock get the block and its receipts the way the syncer does
+func fetchBlock(t *testing.T, s *Syncer, height uint64) (*rpc.BlockInfo, map[string]*rpc.Receipt) {
+	//let the pool know the node has the block
+	s.rpc.Check()
+
+	f := newBlockFetcher(s.rpc, 1, 1)
+	f.start(height, height)
+	defer f.stop()
+
+	b, receipts, err := f.next(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, receipts
+}
+
+//addressTxKeys return the hash and the direction of the tx history entries of the address in order
+func addressTxKeys(t *testing.T, db *memory.Store, filter *database.AddressTxFilter) string {
+	txs, err := db.GetAddressTxs(filter, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		keys = append(keys, tx.Hash+"/"+tx.Direction)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+//poolTxKeys return the hash and the pool state of the txs in the pending collection in order
+func poolTxKeys(t *testing.T, db *memory.Store) string {
+	txs, err := db.GetAllPendingTxs(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		keys = append(keys, tx.Hash+"/"+tx.PoolState)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+//checkSameState compare the store with the one synced from scratch, and the computed balances with the node
+func checkSameState(t *testing.T, got, want *memory.Store, n *simulator.Node) {
+	t.Helper()
+	gotCursor, _ := got.GetSyncCursor(1)
+	wantCursor, _ := want.GetSyncCursor(1)
+	if gotCursor.Height != wantCursor.Height || gotCursor.HeadHash != wantCursor.HeadHash || gotCursor.TxIdx != wantCursor.TxIdx {
+		t.Fatalf("cursor %+v, expected %+v", gotCursor, wantCursor)
+	}
+
+	gotStats, _ := got.GetShardStats(1)
+	wantStats, _ := want.GetShardStats(1)
+	if gotStats.Height != wantStats.Height || gotStats.Blocks != wantStats.Blocks || gotStats.Txs != wantStats.Txs ||
+		gotStats.Accounts != wantStats.Accounts || gotStats.Contracts != wantStats.Contracts ||
+		gotStats.TotalBalance.Cmp(&wantStats.TotalBalance.Int) != 0 {
+		t.Fatalf("stats %+v, expected %+v", gotStats, wantStats)
+	}
+
+	gotTxCnt, _ := got.GetTxCntByShardNumber(1)
+	wantTxCnt, _ := want.GetTxCntByShardNumber(1)
+	if gotTxCnt != wantTxCnt {
+		t.Fatalf("%d txs, expected %d", gotTxCnt, wantTxCnt)
+	}
+
+	for h := int64(0); h <= wantCursor.Height; h++ {
+		gotTxs, _ := got.GetTxsByBlock(1, uint64(h))
+		wantTxs, _ := want.GetTxsByBlock(1, uint64(h))
+		if len(gotTxs) != len(wantTxs) {
+			t.Fatalf("block %d has %d txs, expected %d", h, len(gotTxs), len(wantTxs))
+		}
+		for i := range wantTxs {
+			if gotTxs[i].Hash != wantTxs[i].Hash || gotTxs[i].Idx != wantTxs[i].Idx {
+				t.Fatalf("tx %d of block %d is %+v, expected %+v", i, h, gotTxs[i], wantTxs[i])
+			}
+		}
+	}
+
+	if gotPool, wantPool := poolTxKeys(t, got), poolTxKeys(t, want); gotPool != wantPool {
+		t.Fatalf("pool txs %s, expected %s", gotPool, wantPool)
+	}
+
+	gotAccounts, _ := got.GetAccountsByShardNumber(1, 1000)
+	wantAccounts, _ := want.GetAccountsByShardNumber(1, 1000)
+	if len(gotAccounts) != len(wantAccounts) {
+		t.Fatalf("%d accounts, expected %d", len(gotAccounts), len(wantAccounts))
+	}
+
+	for _, w := range wantAccounts {
+		g, err := got.GetAccountByAddress(w.Address)
+		if err != nil {
+			t.Fatalf("account %s is missing, %v", w.Address, err)
+		}
+		if g.TxCount != w.TxCount || g.Mined != w.Mined || g.AccType != w.AccType || g.Balance.Cmp(&w.Balance.Int) != 0 {
+			t.Fatalf("account %+v, expected %+v", g, w)
+		}
+
+		var balance *big.Int
+		n.Do(func(chain *simulator.Chain) {
+			balance = chain.Balance(w.Address)
+		})
+		if balance.Cmp(&g.Balance.Int) != 0 {
+			t.Fatalf("computed balance of %s is %s, node %s", w.Address, g.Balance.String(), balance)
+		}
+
+		gotCnt, _ := got.GetAddressTxCount(w.Address)
+		wantCnt, _ := want.GetAddressTxCount(w.Address)
+		if gotCnt.Txs != wantCnt.Txs || gotCnt.In != wantCnt.In || gotCnt.Out != wantCnt.Out ||
+			gotCnt.Created != wantCnt.Created || gotCnt.Failed != wantCnt.Failed {
+			t.Fatalf("tx counts of %s are %+v, expected %+v", w.Address, gotCnt, wantCnt)
+		}
+
+		for _, pending := range []bool{false, true} {
+			filter := &database.AddressTxFilter{Address: w.Address, Pending: pending}
+			if gotTxs, wantTxs := addressTxKeys(t, got, filter), addressTxKeys(t, want, filter); gotTxs != wantTxs {
+				t.Fatalf("tx history of %s, pending %v is %s, expected %s", w.Address, pending, gotTxs, wantTxs)
+			}
+		}
+	}
+}
+
+func TestReorg(t *testing.T) {
+	t.Run("WithUndo", func(t *testing.T) {
+		testReorg(t, false)
+	})
+
+	//the lowest orphaned block was synced before the undo journals existed, its accounts are recounted
+	t.Run("WithoutUndo", func(t *testing.T) {
+		testReorg(t, true)
+	})
+}
+
+func testReorg(t *testing.T, legacy bool) {
+	n, accounts := generatedNode(30)
+	defer n.Close()
+	url := startNode(t, n)
+
+	db := memory.NewStore()
+	s := newTestSyncer(t, db, url)
+	defer s.close()
+
+	//a tx to a new account is seen in the tx pool, then mined in a block the fork orphans
+	newAccounts := simulator.Accounts(1, 8)
+	tx := n.AddPendingTx(accounts[0], newAccounts[6], big.NewInt(1000), big.NewInt(1), genesisTime+400)
+	syncTo(t, s, 30)
+	n.Mine(accounts[1], genesisTime+410)
+	syncTo(t, s, 31)
+
+	if legacy {
+		if err := db.RemoveBlockUndo(1, 26); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//the new branch is mined by another new account
+	n.Fork(25, newAccounts[7])
+	syncTo(t, s, 32)
+
+	reorgs, err := db.GetReorgs(1, 1)
+	if err != nil || len(reorgs) != 1 || reorgs[0].ForkHeight != 25 || reorgs[0].Depth != 6 ||
+		len(reorgs[0].OldHashes) != 6 || len(reorgs[0].NewHashes) != 6 {
+		t.Fatalf("bad reorg %+v, %v", reorgs, err)
+	}
+
+	if _, err := db.GetTxByHash(tx.Hash); err == nil {
+		t.Fatal("the orphaned tx is still mined")
+	}
+	poolTx, err := db.GetPendingTxByHash(tx.Hash)
+	if err != nil || poolTx.PoolState != database.PoolStatePending {
+		t.Fatalf("the orphaned tx is not back in the tx pool %+v, %v", poolTx, err)
+	}
+	if _, err := db.GetAccountByAddress(newAccounts[6]); err == nil {
+		t.Fatal("the account created by the orphaned tx is not removed")
+	}
+
+	//the days of the orphaned blocks are counted again once the new branch is synced
+	redo, err := db.GetChartRedo(1)
+	if err != nil || redo == nil || redo.Height != 32 {
+		t.Fatalf("bad chart redo %+v, %v", redo, err)
+	}
+
+	checkSameState(t, db, resync(t, url, 32), n)
+}
+
+//TestCommitRedo restart the syncer after it dies in the middle of a block, the block is written again
+func TestCommitRedo(t *testing.T) {
+	crashes := []struct {
+		name  string
+		crash func(s *Syncer, b *rpc.BlockInfo, receipts map[string]*rpc.Receipt) error
+	}{
+		{"AfterTxs", func(s *Syncer, b *rpc.BlockInfo, receipts map[string]*rpc.Receipt) error {
+			if err := s.blockSync(b); err != nil {
+				return err
+			}
+			return s.txSync(b, receipts)
+		}},
+		{"AfterAccounts", func(s *Syncer, b *rpc.BlockInfo, receipts map[string]*rpc.Receipt) error {
+			if err := s.blockSync(b); err != nil {
+				return err
+			}
+			if err := s.txSync(b, receipts); err != nil {
+				return err
+			}
+			return s.accountSync(b, receipts)
+		}},
+	}
+
+	for _, c := range crashes {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			n, accounts := generatedNode(20)
+			defer n.Close()
+			url := startNode(t, n)
+
+			db := memory.NewStore()
+			s := newTestSyncer(t, db, url)
+			syncTo(t, s, 20)
+			n.Do(func(chain *simulator.Chain) {
+				chain.Generate(rand.New(rand.NewSource(2)), 5, 5, accounts, 10)
+			})
+
+			b, receipts := fetchBlock(t, s, 21)
+			if err := c.crash(s, b, receipts); err != nil {
+				t.Fatal(err)
+			}
+			s.close()
+
+			s = newTestSyncer(t, db, url)
+			defer s.close()
+			syncTo(t, s, 25)
+
+			checkSameState(t, db, resync(t, url, 25), n)
+		})
+	}
+}
+
+//TestCommitInOrder fail a block while the later ones are fetched, nothing above the failed block is committed
+func TestCommitInOrder(t *testing.T) {
+	n, accounts := generatedNode(20)
+	defer n.Close()
+	url := startNode(t, n)
+
+	db := memory.NewStore()
+	s := newTestSyncer(t, db, url, WithFetchConcurrency(4), WithFetchWindow(8))
+	defer s.close()
+	syncTo(t, s, 20)
+
+	n.Do(func(chain *simulator.Chain) {
+		chain.Generate(rand.New(rand.NewSource(2)), 20, 5, accounts, 10)
+	})
+	n.InjectFault("txpool.GetReceiptByTxHash", simulator.Fault{Error: "receipt is not ready", Times: 1})
+	s.sync()
+
+	height := s.cursor.Height
+	if height >= 40 {
+		t.Fatal("the block with the failed receipt is committed")
+	}
+	for h := height + 1; h <= 40; h++ {
+		if _, err := db.GetBlockByHeight(1, uint64(h)); err == nil {
+			t.Fatalf("block %d above the cursor %d is committed", h, height)
+		}
+	}
+	if stats, _ := db.GetShardStats(1); stats.Height != height {
+		t.Fatalf("stats are counted to %d, the cursor is %d", stats.Height, height)
+	}
+
+	syncTo(t, s, 40)
+	checkSameState(t, db, resync(t, url, 40), n)
+}
+
+//forkingStore fork the node while the block at the height is written, the blocks above it which
+//are already fetched are on the old branch
+type forkingStore struct {
+	*memory.Store
+	height int64
+	fork   func()
+}
+
+func (s *forkingStore) AddBlock(b *database.DBBlock) error {
+	if b.Height == s.height && s.fork != nil {
+		s.fork()
+		s.fork = nil
+	}
+	return s.Store.AddBlock(b)
+}
+
+//TestForkWhileFetching switch the node to a new branch after a fetch window is fetched, the blocks
+//of the old branch are committed until the first block of the new branch, which must not be
+//committed on top of them
+func TestForkWhileFetching(t *testing.T) {
+	n, accounts := generatedNode(20)
+	defer n.Close()
+	url := startNode(t, n)
+
+	db := &forkingStore{Store: memory.NewStore()}
+	s := newTestSyncer(t, db, url, WithFetchWindow(4))
+	defer s.close()
+	syncTo(t, s, 20)
+
+	n.Do(func(chain *simulator.Chain) {
+		chain.Generate(rand.New(rand.NewSource(2)), 10, 5, accounts, 10)
+	})
+
+	//blocks 21 to 24 are fetched before block 21 is committed, the next window is fetched from the new branch
+	db.height = 21
+	db.fork = func() {
+		n.Fork(22, simulator.Accounts(1, 8)[7])
+	}
+	syncTo(t, s, 31)
+
+	reorgs, err := db.GetReorgs(1, 1)
+	if err != nil || len(reorgs) != 1 || reorgs[0].ForkHeight != 22 || reorgs[0].Depth != 2 {
+		t.Fatalf("bad reorg %+v, %v", reorgs, err)
+	}
+
+	checkSameState(t, db.Store, resync(t, url, 31), n)
+}
@@ -1,17 +1,32 @@
 package syncer
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
 	"github.com/seeleteam/scan-api/rpc"
 )
 
-func (s *Syncer) txSync(block *rpc.BlockInfo) error {
-	transIdx, _ := s.db.GetTxCntByShardNumber(s.shardNumber)
+//txSync store the txs of the block and their receipts
+func (s *Syncer) txSync(block *rpc.BlockInfo, receipts map[string]*rpc.Receipt) error {
+	for _, tx := range block.Txs {
+		if _, ok := receipts[tx.Hash]; !ok {
+			return fmt.Errorf("receipt of tx %s is missing", tx.Hash)
+		}
+	}
+
+	blockTime := block.Timestamp.Int64()
+
+	//idx is derived from the cursor, so txs get the same idx when the block is written again
+	transIdx := uint64(s.cursor.TxIdx)
 
 	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var firstErr error
+	dbTxs := make([]*database.DBTx, len(block.Txs))
 	wg.Add(len(block.Txs))
 
 	for j := 0; j < len(block.Txs); j++ {
@@ -21,52 +36,155 @@ func (s *Syncer) txSync(block *rpc.BlockInfo) error {
 		if trans.To == "" {
 
 			trans.TxType = 1
-
-			receipt, err := s.rpc.GetReceiptByTxHash(trans.Hash)
-			if err == nil {
-				trans.ContractAddress = receipt.ContractAddress
-			}
 		}
 
+		receipt := receipts[trans.Hash]
+		trans.ContractAddress = receipt.ContractAddress
+
 		transIdx++
 		trans.Idx = transIdx
 		dbTx := database.CreateDbTx(trans)
 		dbTx.Pending = false
+		dbTx.Failed = receipt.Failed
 		dbTx.ShardNumber = s.shardNumber
+		dbReceipt := database.CreateDbReceipt(receipt, s.shardNumber, block.Height)
+		dbTxs[j] = dbTx
 
 		s.workerpool.Submit(func() {
-			s.db.AddTx(dbTx)
-			wg.Done()
+			defer wg.Done()
+			err := s.markPoolTxMined(dbTx, blockTime)
+			if err == nil {
+				err = s.db.AddTx(dbTx)
+			}
+
+			if err == nil {
+				err = s.db.AddReceipt(dbReceipt)
+			}
+
+			if err != nil {
+				lock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				lock.Unlock()
+			}
 		})
 	}
 
 	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
 
-	return nil
+	//the history entries are built after the pool lifecycle is copied to the txs
+	var addressTxs []*database.DBAddressTx
+	for j, dbTx := range dbTxs {
+		contractAddress := receipts[block.Txs[j].Hash].ContractAddress
+		addressTxs = append(addressTxs, database.CreateDbAddressTxs(dbTx, contractAddress)...)
+	}
+	return s.db.AddAddressTxs(s.shardNumber, int64(block.Height), addressTxs)
 }
 
+//pendingTxsSync reconcile the pending collection with the tx pool of seele node by hash.
+//New txs are recorded with the time first seen, txs which left the pool without
+//being mined are marked dropped, txs in synced blocks have been marked mined by txSync
 func (s *Syncer) pendingTxsSync() error {
-	s.db.RemoveAllPendingTxs()
-	transIdx, err := s.db.GetPendingTxCntByShardNumber(s.shardNumber)
-
 	txs, err := s.rpc.GetPendingTransactions()
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
+	stored, err := s.db.GetAllPendingTxs(s.shardNumber)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	known := make(map[string]bool, len(stored))
+	for _, tx := range stored {
+		known[tx.Hash] = true
+	}
+
+	now := time.Now().Unix()
+	inPool := make(map[string]bool, len(txs))
 	for i := 0; i < len(txs); i++ {
-		transIdx++
-		txs[i].Idx = transIdx
+		inPool[txs[i].Hash] = true
+		if known[txs[i].Hash] {
+			continue
+		}
+
 		dbTx := database.CreateDbTx(txs[i])
 		dbTx.ShardNumber = s.shardNumber
 		dbTx.Pending = true
-		err := s.db.AddPendingTx(dbTx)
-		if err != nil {
+		dbTx.PoolState = database.PoolStatePending
+		dbTx.FirstSeen = now
+
+		if old, err := s.db.GetPendingTxByHash(dbTx.Hash); err == nil {
+			//the node may still report a tx which has just been mined
+			if old.PoolState == database.PoolStateMined {
+				continue
+			}
+
+			//a dropped tx comes back to the pool
+			if old.FirstSeen > 0 {
+				dbTx.FirstSeen = old.FirstSeen
+			}
+		}
+
+		if err := s.db.AddPendingTx(dbTx); err != nil {
 			log.Error(err)
 			continue
 		}
+
+		if err := s.db.AddPendingAddressTxs(database.CreateDbAddressTxs(dbTx, "")); err != nil {
+			log.Error(err)
+		}
+	}
+
+	for _, tx := range stored {
+		if inPool[tx.Hash] {
+			continue
+		}
+
+		state := database.PoolStateDropped
+		if _, err := s.db.GetTxByHash(tx.Hash); err == nil {
+			state = database.PoolStateMined
+		}
+
+		if err := s.db.UpdatePendingTxState(tx.Hash, state, now, 0); err != nil {
+			log.Error(err)
+			continue
+		}
+
+		//a mined tx has its entries replaced by the ones of the block
+		if err := s.db.RemovePendingAddressTxs(tx.Hash); err != nil {
+			log.Error(err)
+		}
+	}
+
+	return s.db.RemovePoolTxsBefore(s.shardNumber, now-int64(poolTxRetention.Seconds()))
+}
+
+//markPoolTxMined copy the pool lifecycle to the mined tx and move the pool tx to mined
+func (s *Syncer) markPoolTxMined(dbTx *database.DBTx, blockTime int64) error {
+	poolTx, err := s.db.GetPendingTxByHash(dbTx.Hash)
+	if err != nil || poolTx.FirstSeen == 0 {
+		//the tx was never seen in the tx pool
+		return nil
+	}
+
+	dbTx.PoolState = database.PoolStateMined
+	dbTx.FirstSeen = poolTx.FirstSeen
+	if blockTime > poolTx.FirstSeen {
+		dbTx.InclusionTime = blockTime - poolTx.FirstSeen
+	}
+
+	leftPoolTime := poolTx.LeftPoolTime
+	if poolTx.PoolState != database.PoolStateMined {
+		leftPoolTime = time.Now().Unix()
 	}
+	dbTx.LeftPoolTime = leftPoolTime
 
-	return nil
+	return s.db.UpdatePendingTxState(dbTx.Hash, database.PoolStateMined, leftPoolTime, dbTx.InclusionTime)
 }
@@ -0,0 +1,137 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package syncer
+
+import (
+	"context"
+	"time"
+
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+const (
+	//minPollInterval the poll interval right after a new block is seen
+	minPollInterval = time.Second
+
+	//resubscribeInterval how long the watcher polls before subscribing again after a subscription failed
+	resubscribeInterval = time.Minute
+)
+
+//headWatcher wait for the new blocks of the shard. It listens to the new head notifications of
+//seele node when the transport supports them, otherwise it polls the current block, backing off
+//while no block arrives and tightening the interval once one does
+type headWatcher struct {
+	shardNumber int
+	rpc         *rpc.Pool
+
+	//maxWait a sync starts after maxWait even if no block arrives, so that the pending txs are refreshed
+	maxWait  time.Duration
+	interval time.Duration
+
+	sub           *rpc.HeadSubscription
+	lastSubscribe time.Time
+}
+
+func newHeadWatcher(shardNumber int, pool *rpc.Pool, maxWait time.Duration) *headWatcher {
+	if maxWait < minPollInterval {
+		maxWait = minPollInterval
+	}
+
+	return &headWatcher{
+		shardNumber: shardNumber,
+		rpc:         pool,
+		maxWait:     maxWait,
+		interval:    minPollInterval,
+	}
+}
+
+//wait block until the block of the next height is seen or maxWait elapses, it returns false
+//if quit is closed
+func (w *headWatcher) wait(next uint64, quit chan struct{}) bool {
+	//the last sync did not catch up, go on after a short pause so that a failing block is not hammered
+	if w.rpc.Height() >= next {
+		select {
+		case <-time.After(minPollInterval):
+			return true
+		case <-quit:
+			return false
+		}
+	}
+
+	deadline := time.NewTimer(w.maxWait)
+	defer deadline.Stop()
+
+	w.subscribe()
+	for {
+		if w.sub != nil {
+			select {
+			case head, ok := <-w.sub.Heads():
+				if !ok {
+					log.Warn("[BlockSync shard:%d]new head subscription is closed, fall back to polling", w.shardNumber)
+					w.sub = nil
+				} else if head.Height >= next {
+					return true
+				}
+			case <-deadline.C:
+				return true
+			case <-quit:
+				return false
+			}
+			continue
+		}
+
+		poll := time.NewTimer(w.interval)
+		select {
+		case <-poll.C:
+		case <-deadline.C:
+			poll.Stop()
+			return true
+		case <-quit:
+			poll.Stop()
+			return false
+		}
+
+		current, err := w.rpc.CurrentBlock()
+		if err == nil && current.Height >= next {
+			w.interval /= 2
+			if w.interval < minPollInterval {
+				w.interval = minPollInterval
+			}
+			return true
+		}
+
+		w.interval *= 2
+		if w.interval > w.maxWait {
+			w.interval = w.maxWait
+		}
+	}
+}
+
+//subscribe subscribe to the new heads if there is no subscription and the last attempt is not too recent
+func (w *headWatcher) subscribe() {
+	if w.sub != nil || time.Since(w.lastSubscribe) < resubscribeInterval {
+		return
+	}
+	w.lastSubscribe = time.Now()
+
+	sub, err := w.rpc.SubscribeNewHead(context.Background())
+	if err != nil {
+		log.Debug("[BlockSync shard:%d]poll the current block, new head subscription failed: %v", w.shardNumber, err)
+		return
+	}
+
+	log.Info("[BlockSync shard:%d]subscribed to new heads", w.shardNumber)
+	w.sub = sub
+}
+
+//close cancel the subscription
+func (w *headWatcher) close() {
+	if w.sub != nil {
+		w.sub.Unsubscribe()
+		w.sub = nil
+	}
+}